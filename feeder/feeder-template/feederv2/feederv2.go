@@ -197,7 +197,7 @@ func initUdsEndpoint(udsChan chan DomainData) {
 		os.Exit(-1)
 	}
 	defer conn.Close()
-	buf := make([]byte, 512)
+	buf := make([]byte, 4096)
 	for {
 		n, err := conn.Read(buf)
 		if err != nil {
@@ -205,9 +205,28 @@ func initUdsEndpoint(udsChan chan DomainData) {
 			continue
 		}
 		utils.Info.Printf("Feeder:Server message: %s", string(buf[:n]))
-		domainData, _ := splitToDomainDataAndTs(string(buf[:n]))
-		udsChan <- domainData
+		for _, serverMessage := range splitServerMessage(string(buf[:n])) {
+			domainData, _ := splitToDomainDataAndTs(serverMessage)
+			udsChan <- domainData
+		}
+	}
+}
+
+func splitServerMessage(serverMessage string) []string { // a multi-path set is received as an array of messages, server=[{"dp": {...},"path": "X1"}, ...]
+	if !strings.HasPrefix(serverMessage, "[") {
+		return []string{serverMessage}
+	}
+	var messageList []json.RawMessage
+	err := json.Unmarshal([]byte(serverMessage), &messageList)
+	if err != nil {
+		utils.Error.Printf("splitServerMessage:Unmarshal error=%s", err)
+		return nil
+	}
+	serverMessages := make([]string, len(messageList))
+	for i := range messageList {
+		serverMessages[i] = string(messageList[i])
 	}
+	return serverMessages
 }
 
 func splitToDomainDataAndTs(serverMessage string) (DomainData, string) { // server={"dp": {"ts": "Z","value": "Y"},"path": "X"}, redis={"value":"xxx", "ts":"zzz"}
@@ -933,6 +933,188 @@ func (x *UnsubscribeResponseMessage) GetTs() string {
 	return ""
 }
 
+type HistoryControlRequestMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path          string  `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Command       string  `protobuf:"bytes,2,opt,name=Command,proto3" json:"Command,omitempty"` // create, start, stop, delete, or list
+	BufSize       *string `protobuf:"bytes,3,opt,name=BufSize,proto3,oneof" json:"BufSize,omitempty"`
+	Retention     *string `protobuf:"bytes,4,opt,name=Retention,proto3,oneof" json:"Retention,omitempty"`
+	Frequency     *string `protobuf:"bytes,5,opt,name=Frequency,proto3,oneof" json:"Frequency,omitempty"`
+	Authorization *string `protobuf:"bytes,6,opt,name=Authorization,proto3,oneof" json:"Authorization,omitempty"`
+	RequestId     *string `protobuf:"bytes,7,opt,name=RequestId,proto3,oneof" json:"RequestId,omitempty"`
+}
+
+func (x *HistoryControlRequestMessage) Reset() {
+	*x = HistoryControlRequestMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_VISSv2_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryControlRequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryControlRequestMessage) ProtoMessage() {}
+
+func (x *HistoryControlRequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_VISSv2_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryControlRequestMessage.ProtoReflect.Descriptor instead.
+func (*HistoryControlRequestMessage) Descriptor() ([]byte, []int) {
+	return file_VISSv2_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *HistoryControlRequestMessage) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetBufSize() string {
+	if x != nil && x.BufSize != nil {
+		return *x.BufSize
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetRetention() string {
+	if x != nil && x.Retention != nil {
+		return *x.Retention
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetFrequency() string {
+	if x != nil && x.Frequency != nil {
+		return *x.Frequency
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetAuthorization() string {
+	if x != nil && x.Authorization != nil {
+		return *x.Authorization
+	}
+	return ""
+}
+
+func (x *HistoryControlRequestMessage) GetRequestId() string {
+	if x != nil && x.RequestId != nil {
+		return *x.RequestId
+	}
+	return ""
+}
+
+type HistoryControlResponseMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status        ResponseStatus                                  `protobuf:"varint,1,opt,name=Status,proto3,enum=grpcProtobufMessages.ResponseStatus" json:"Status,omitempty"`
+	Captures      []*HistoryControlResponseMessage_CaptureMessage `protobuf:"bytes,2,rep,name=Captures,proto3" json:"Captures,omitempty"` // list command
+	ErrorResponse *ErrorResponseMessage                           `protobuf:"bytes,3,opt,name=ErrorResponse,proto3,oneof" json:"ErrorResponse,omitempty"`
+	RequestId     *string                                         `protobuf:"bytes,4,opt,name=RequestId,proto3,oneof" json:"RequestId,omitempty"`
+	Ts            string                                          `protobuf:"bytes,5,opt,name=Ts,proto3" json:"Ts,omitempty"`
+	Authorization *string                                         `protobuf:"bytes,6,opt,name=Authorization,proto3,oneof" json:"Authorization,omitempty"`
+}
+
+func (x *HistoryControlResponseMessage) Reset() {
+	*x = HistoryControlResponseMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_VISSv2_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryControlResponseMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryControlResponseMessage) ProtoMessage() {}
+
+func (x *HistoryControlResponseMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_VISSv2_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryControlResponseMessage.ProtoReflect.Descriptor instead.
+func (*HistoryControlResponseMessage) Descriptor() ([]byte, []int) {
+	return file_VISSv2_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HistoryControlResponseMessage) GetStatus() ResponseStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ResponseStatus_SUCCESS
+}
+
+func (x *HistoryControlResponseMessage) GetCaptures() []*HistoryControlResponseMessage_CaptureMessage {
+	if x != nil {
+		return x.Captures
+	}
+	return nil
+}
+
+func (x *HistoryControlResponseMessage) GetErrorResponse() *ErrorResponseMessage {
+	if x != nil {
+		return x.ErrorResponse
+	}
+	return nil
+}
+
+func (x *HistoryControlResponseMessage) GetRequestId() string {
+	if x != nil && x.RequestId != nil {
+		return *x.RequestId
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage) GetTs() string {
+	if x != nil {
+		return x.Ts
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage) GetAuthorization() string {
+	if x != nil && x.Authorization != nil {
+		return *x.Authorization
+	}
+	return ""
+}
+
 type FilterExpressions_FilterExpression struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -945,7 +1127,7 @@ type FilterExpressions_FilterExpression struct {
 func (x *FilterExpressions_FilterExpression) Reset() {
 	*x = FilterExpressions_FilterExpression{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[11]
+		mi := &file_VISSv2_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -958,7 +1140,7 @@ func (x *FilterExpressions_FilterExpression) String() string {
 func (*FilterExpressions_FilterExpression) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[11]
+	mi := &file_VISSv2_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1006,7 +1188,7 @@ type FilterExpressions_FilterExpression_FilterValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[12]
+		mi := &file_VISSv2_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1019,7 +1201,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue) String() string {
 func (*FilterExpressions_FilterExpression_FilterValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[12]
+	mi := &file_VISSv2_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1102,7 +1284,7 @@ type FilterExpressions_FilterExpression_FilterValue_PathsValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_PathsValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_PathsValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[13]
+		mi := &file_VISSv2_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1115,7 +1297,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_PathsValue) String() str
 func (*FilterExpressions_FilterExpression_FilterValue_PathsValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_PathsValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[13]
+	mi := &file_VISSv2_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1149,7 +1331,7 @@ type FilterExpressions_FilterExpression_FilterValue_TimebasedValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_TimebasedValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_TimebasedValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[14]
+		mi := &file_VISSv2_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1162,7 +1344,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_TimebasedValue) String()
 func (*FilterExpressions_FilterExpression_FilterValue_TimebasedValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_TimebasedValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[14]
+	mi := &file_VISSv2_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1197,7 +1379,7 @@ type FilterExpressions_FilterExpression_FilterValue_RangeValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_RangeValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_RangeValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[15]
+		mi := &file_VISSv2_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1210,7 +1392,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_RangeValue) String() str
 func (*FilterExpressions_FilterExpression_FilterValue_RangeValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_RangeValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[15]
+	mi := &file_VISSv2_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1252,7 +1434,7 @@ type FilterExpressions_FilterExpression_FilterValue_ChangeValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_ChangeValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_ChangeValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[16]
+		mi := &file_VISSv2_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1265,7 +1447,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_ChangeValue) String() st
 func (*FilterExpressions_FilterExpression_FilterValue_ChangeValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_ChangeValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[16]
+	mi := &file_VISSv2_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1307,7 +1489,7 @@ type FilterExpressions_FilterExpression_FilterValue_CurvelogValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_CurvelogValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_CurvelogValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[17]
+		mi := &file_VISSv2_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1320,7 +1502,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_CurvelogValue) String()
 func (*FilterExpressions_FilterExpression_FilterValue_CurvelogValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_CurvelogValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[17]
+	mi := &file_VISSv2_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1361,7 +1543,7 @@ type FilterExpressions_FilterExpression_FilterValue_HistoryValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_HistoryValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_HistoryValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[18]
+		mi := &file_VISSv2_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1374,7 +1556,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_HistoryValue) String() s
 func (*FilterExpressions_FilterExpression_FilterValue_HistoryValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_HistoryValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[18]
+	mi := &file_VISSv2_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1408,7 +1590,7 @@ type FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue struct {
 func (x *FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[19]
+		mi := &file_VISSv2_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1421,7 +1603,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue) Str
 func (*FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[19]
+	mi := &file_VISSv2_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1455,7 +1637,7 @@ type FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue struct
 func (x *FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue) Reset() {
 	*x = FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[20]
+		mi := &file_VISSv2_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1468,7 +1650,7 @@ func (x *FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue) St
 func (*FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue) ProtoMessage() {}
 
 func (x *FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[20]
+	mi := &file_VISSv2_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1504,7 +1686,7 @@ type DataPackages_DataPackage struct {
 func (x *DataPackages_DataPackage) Reset() {
 	*x = DataPackages_DataPackage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[21]
+		mi := &file_VISSv2_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1517,7 +1699,7 @@ func (x *DataPackages_DataPackage) String() string {
 func (*DataPackages_DataPackage) ProtoMessage() {}
 
 func (x *DataPackages_DataPackage) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[21]
+	mi := &file_VISSv2_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1567,7 +1749,7 @@ type DataPackages_DataPackage_DataPoint struct {
 func (x *DataPackages_DataPackage_DataPoint) Reset() {
 	*x = DataPackages_DataPackage_DataPoint{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[22]
+		mi := &file_VISSv2_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1580,7 +1762,7 @@ func (x *DataPackages_DataPackage_DataPoint) String() string {
 func (*DataPackages_DataPackage_DataPoint) ProtoMessage() {}
 
 func (x *DataPackages_DataPackage_DataPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[22]
+	mi := &file_VISSv2_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1629,7 +1811,7 @@ type GetResponseMessage_SuccessResponseMessage struct {
 func (x *GetResponseMessage_SuccessResponseMessage) Reset() {
 	*x = GetResponseMessage_SuccessResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[23]
+		mi := &file_VISSv2_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1642,7 +1824,7 @@ func (x *GetResponseMessage_SuccessResponseMessage) String() string {
 func (*GetResponseMessage_SuccessResponseMessage) ProtoMessage() {}
 
 func (x *GetResponseMessage_SuccessResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[23]
+	mi := &file_VISSv2_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1687,7 +1869,7 @@ type SubscribeStreamMessage_SubscribeResponseMessage struct {
 func (x *SubscribeStreamMessage_SubscribeResponseMessage) Reset() {
 	*x = SubscribeStreamMessage_SubscribeResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[24]
+		mi := &file_VISSv2_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1700,7 +1882,7 @@ func (x *SubscribeStreamMessage_SubscribeResponseMessage) String() string {
 func (*SubscribeStreamMessage_SubscribeResponseMessage) ProtoMessage() {}
 
 func (x *SubscribeStreamMessage_SubscribeResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[24]
+	mi := &file_VISSv2_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1766,7 +1948,7 @@ type SubscribeStreamMessage_SubscribeEventMessage struct {
 func (x *SubscribeStreamMessage_SubscribeEventMessage) Reset() {
 	*x = SubscribeStreamMessage_SubscribeEventMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[25]
+		mi := &file_VISSv2_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1779,7 +1961,7 @@ func (x *SubscribeStreamMessage_SubscribeEventMessage) String() string {
 func (*SubscribeStreamMessage_SubscribeEventMessage) ProtoMessage() {}
 
 func (x *SubscribeStreamMessage_SubscribeEventMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[25]
+	mi := &file_VISSv2_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1841,7 +2023,7 @@ type SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage struct
 func (x *SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage) Reset() {
 	*x = SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_VISSv2_proto_msgTypes[26]
+		mi := &file_VISSv2_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1854,7 +2036,7 @@ func (x *SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage) St
 func (*SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage) ProtoMessage() {}
 
 func (x *SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_VISSv2_proto_msgTypes[26]
+	mi := &file_VISSv2_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1877,6 +2059,101 @@ func (x *SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage) Ge
 	return nil
 }
 
+type HistoryControlResponseMessage_CaptureMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path      string  `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Status    string  `protobuf:"bytes,2,opt,name=Status,proto3" json:"Status,omitempty"`
+	Frequency string  `protobuf:"bytes,3,opt,name=Frequency,proto3" json:"Frequency,omitempty"`
+	BufSize   string  `protobuf:"bytes,4,opt,name=BufSize,proto3" json:"BufSize,omitempty"`
+	Retention *string `protobuf:"bytes,5,opt,name=Retention,proto3,oneof" json:"Retention,omitempty"`
+	Samples   string  `protobuf:"bytes,6,opt,name=Samples,proto3" json:"Samples,omitempty"`
+	FillLevel *string `protobuf:"bytes,7,opt,name=FillLevel,proto3,oneof" json:"FillLevel,omitempty"`
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) Reset() {
+	*x = HistoryControlResponseMessage_CaptureMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_VISSv2_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryControlResponseMessage_CaptureMessage) ProtoMessage() {}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_VISSv2_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryControlResponseMessage_CaptureMessage.ProtoReflect.Descriptor instead.
+func (*HistoryControlResponseMessage_CaptureMessage) Descriptor() ([]byte, []int) {
+	return file_VISSv2_proto_rawDescGZIP(), []int{12, 0}
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetFrequency() string {
+	if x != nil {
+		return x.Frequency
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetBufSize() string {
+	if x != nil {
+		return x.BufSize
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetRetention() string {
+	if x != nil && x.Retention != nil {
+		return *x.Retention
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetSamples() string {
+	if x != nil {
+		return x.Samples
+	}
+	return ""
+}
+
+func (x *HistoryControlResponseMessage_CaptureMessage) GetFillLevel() string {
+	if x != nil && x.FillLevel != nil {
+		return *x.FillLevel
+	}
+	return ""
+}
+
 var File_VISSv2_proto protoreflect.FileDescriptor
 
 var file_VISSv2_proto_rawDesc = []byte{
@@ -2240,42 +2517,112 @@ var file_VISSv2_proto_rawDesc = []byte{
 	0x52, 0x09, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x0e,
 	0x0a, 0x02, 0x54, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x54, 0x73, 0x42, 0x10,
 	0x0a, 0x0e, 0x5f, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x2a, 0x28,
-	0x0a, 0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x12, 0x0b, 0x0a, 0x07, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53, 0x10, 0x00, 0x12, 0x09, 0x0a,
-	0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x2a, 0x30, 0x0a, 0x15, 0x53, 0x75, 0x62, 0x73,
-	0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70,
-	0x65, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45, 0x10, 0x00, 0x12,
-	0x09, 0x0a, 0x05, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x10, 0x01, 0x32, 0xb6, 0x03, 0x0a, 0x06, 0x56,
-	0x49, 0x53, 0x53, 0x76, 0x32, 0x12, 0x5f, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x27, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x28, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x5f, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x53, 0x65, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x28, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x73, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x71, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63,
-	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x73, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x2c, 0x2e, 0x67, 0x72, 0x70,
+	0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0xc7,
+	0x02, 0x0a, 0x1c, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50,
+	0x61, 0x74, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1d, 0x0a,
+	0x07, 0x42, 0x75, 0x66, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
+	0x52, 0x07, 0x42, 0x75, 0x66, 0x53, 0x69, 0x7a, 0x65, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09,
+	0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x01, 0x52, 0x09, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12,
+	0x21, 0x0a, 0x09, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x02, 0x52, 0x09, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x88,
+	0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03, 0x52, 0x0d, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a,
+	0x09, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x04, 0x52, 0x09, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x88, 0x01, 0x01,
+	0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x42, 0x75, 0x66, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x46,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0x97, 0x05, 0x0a, 0x1d, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3c, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x24, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x5e, 0x0a, 0x08, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x42, 0x2e, 0x67, 0x72, 0x70,
 	0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x73, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61,
-	0x6d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x30, 0x01, 0x12, 0x77, 0x0a, 0x12, 0x55, 0x6e,
-	0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x2f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72,
-	0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x1a, 0x30, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63,
-	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x42, 0x04, 0x5a, 0x02, 0x2e, 0x2f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x73, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x08,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x55, 0x0a, 0x0d, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x2a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0d, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x21, 0x0a, 0x09, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x88,
+	0x01, 0x01, 0x12, 0x0e, 0x0a, 0x02, 0x54, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x54, 0x73, 0x12, 0x29, 0x0a, 0x0d, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0d, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x1a, 0xf0, 0x01,
+	0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x50, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09,
+	0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x42, 0x75,
+	0x66, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x42, 0x75, 0x66,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x21, 0x0a, 0x09, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x52, 0x65, 0x74, 0x65, 0x6e,
+	0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x73, 0x12, 0x21, 0x0a, 0x09, 0x46, 0x69, 0x6c, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x46, 0x69, 0x6c, 0x6c, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69,
+	0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x46, 0x69, 0x6c, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64,
+	0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2a, 0x28, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53, 0x10,
+	0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x2a, 0x30, 0x0a, 0x15,
+	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53,
+	0x45, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x10, 0x01, 0x32, 0xb9,
+	0x04, 0x0a, 0x06, 0x56, 0x49, 0x53, 0x53, 0x76, 0x32, 0x12, 0x5f, 0x0a, 0x0a, 0x47, 0x65, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x1a, 0x28, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x5f, 0x0a, 0x0a, 0x53, 0x65,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e,
+	0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x1a, 0x28, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x71, 0x0a, 0x10, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x2d, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x2c,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x30, 0x01, 0x12, 0x77,
+	0x0a, 0x12, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x55, 0x6e, 0x73, 0x75,
+	0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x30, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x55, 0x6e, 0x73,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x80, 0x01, 0x0a, 0x15, 0x48, 0x69, 0x73, 0x74,
+	0x6f, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x32, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x33, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x04, 0x5a, 0x02, 0x2e, 0x2f,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -2291,7 +2638,7 @@ func file_VISSv2_proto_rawDescGZIP() []byte {
 }
 
 var file_VISSv2_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_VISSv2_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
+var file_VISSv2_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_VISSv2_proto_goTypes = []interface{}{
 	(ResponseStatus)(0),                                                         // 0: grpcProtobufMessages.ResponseStatus
 	(SubscribeResponseType)(0),                                                  // 1: grpcProtobufMessages.SubscribeResponseType
@@ -2307,68 +2654,76 @@ var file_VISSv2_proto_goTypes = []interface{}{
 	(*SubscribeStreamMessage)(nil),                                              // 11: grpcProtobufMessages.SubscribeStreamMessage
 	(*UnsubscribeRequestMessage)(nil),                                           // 12: grpcProtobufMessages.UnsubscribeRequestMessage
 	(*UnsubscribeResponseMessage)(nil),                                          // 13: grpcProtobufMessages.UnsubscribeResponseMessage
-	(*FilterExpressions_FilterExpression)(nil),                                  // 14: grpcProtobufMessages.FilterExpressions.FilterExpression
-	(*FilterExpressions_FilterExpression_FilterValue)(nil),                      // 15: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue
-	(*FilterExpressions_FilterExpression_FilterValue_PathsValue)(nil),           // 16: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.PathsValue
-	(*FilterExpressions_FilterExpression_FilterValue_TimebasedValue)(nil),       // 17: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.TimebasedValue
-	(*FilterExpressions_FilterExpression_FilterValue_RangeValue)(nil),           // 18: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.RangeValue
-	(*FilterExpressions_FilterExpression_FilterValue_ChangeValue)(nil),          // 19: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ChangeValue
-	(*FilterExpressions_FilterExpression_FilterValue_CurvelogValue)(nil),        // 20: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.CurvelogValue
-	(*FilterExpressions_FilterExpression_FilterValue_HistoryValue)(nil),         // 21: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.HistoryValue
-	(*FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue)(nil),  // 22: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.StaticMetadataValue
-	(*FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue)(nil), // 23: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.DynamicMetadataValue
-	(*DataPackages_DataPackage)(nil),                                            // 24: grpcProtobufMessages.DataPackages.DataPackage
-	(*DataPackages_DataPackage_DataPoint)(nil),                                  // 25: grpcProtobufMessages.DataPackages.DataPackage.DataPoint
-	(*GetResponseMessage_SuccessResponseMessage)(nil),                           // 26: grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage
-	(*SubscribeStreamMessage_SubscribeResponseMessage)(nil),                     // 27: grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage
-	(*SubscribeStreamMessage_SubscribeEventMessage)(nil),                        // 28: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage
-	(*SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage)(nil), // 29: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage
+	(*HistoryControlRequestMessage)(nil),                                        // 14: grpcProtobufMessages.HistoryControlRequestMessage
+	(*HistoryControlResponseMessage)(nil),                                       // 15: grpcProtobufMessages.HistoryControlResponseMessage
+	(*FilterExpressions_FilterExpression)(nil),                                  // 16: grpcProtobufMessages.FilterExpressions.FilterExpression
+	(*FilterExpressions_FilterExpression_FilterValue)(nil),                      // 17: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue
+	(*FilterExpressions_FilterExpression_FilterValue_PathsValue)(nil),           // 18: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.PathsValue
+	(*FilterExpressions_FilterExpression_FilterValue_TimebasedValue)(nil),       // 19: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.TimebasedValue
+	(*FilterExpressions_FilterExpression_FilterValue_RangeValue)(nil),           // 20: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.RangeValue
+	(*FilterExpressions_FilterExpression_FilterValue_ChangeValue)(nil),          // 21: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ChangeValue
+	(*FilterExpressions_FilterExpression_FilterValue_CurvelogValue)(nil),        // 22: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.CurvelogValue
+	(*FilterExpressions_FilterExpression_FilterValue_HistoryValue)(nil),         // 23: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.HistoryValue
+	(*FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue)(nil),  // 24: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.StaticMetadataValue
+	(*FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue)(nil), // 25: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.DynamicMetadataValue
+	(*DataPackages_DataPackage)(nil),                                            // 26: grpcProtobufMessages.DataPackages.DataPackage
+	(*DataPackages_DataPackage_DataPoint)(nil),                                  // 27: grpcProtobufMessages.DataPackages.DataPackage.DataPoint
+	(*GetResponseMessage_SuccessResponseMessage)(nil),                           // 28: grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage
+	(*SubscribeStreamMessage_SubscribeResponseMessage)(nil),                     // 29: grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage
+	(*SubscribeStreamMessage_SubscribeEventMessage)(nil),                        // 30: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage
+	(*SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage)(nil), // 31: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage
+	(*HistoryControlResponseMessage_CaptureMessage)(nil),                        // 32: grpcProtobufMessages.HistoryControlResponseMessage.CaptureMessage
 }
 var file_VISSv2_proto_depIdxs = []int32{
-	14, // 0: grpcProtobufMessages.FilterExpressions.FilterExp:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression
-	24, // 1: grpcProtobufMessages.DataPackages.Data:type_name -> grpcProtobufMessages.DataPackages.DataPackage
+	16, // 0: grpcProtobufMessages.FilterExpressions.FilterExp:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression
+	26, // 1: grpcProtobufMessages.DataPackages.Data:type_name -> grpcProtobufMessages.DataPackages.DataPackage
 	4,  // 2: grpcProtobufMessages.GetRequestMessage.Filter:type_name -> grpcProtobufMessages.FilterExpressions
 	0,  // 3: grpcProtobufMessages.GetResponseMessage.Status:type_name -> grpcProtobufMessages.ResponseStatus
-	26, // 4: grpcProtobufMessages.GetResponseMessage.SuccessResponse:type_name -> grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage
+	28, // 4: grpcProtobufMessages.GetResponseMessage.SuccessResponse:type_name -> grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage
 	3,  // 5: grpcProtobufMessages.GetResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
 	0,  // 6: grpcProtobufMessages.SetResponseMessage.Status:type_name -> grpcProtobufMessages.ResponseStatus
 	3,  // 7: grpcProtobufMessages.SetResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
 	4,  // 8: grpcProtobufMessages.SubscribeRequestMessage.Filter:type_name -> grpcProtobufMessages.FilterExpressions
 	1,  // 9: grpcProtobufMessages.SubscribeStreamMessage.MType:type_name -> grpcProtobufMessages.SubscribeResponseType
 	0,  // 10: grpcProtobufMessages.SubscribeStreamMessage.Status:type_name -> grpcProtobufMessages.ResponseStatus
-	27, // 11: grpcProtobufMessages.SubscribeStreamMessage.Response:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage
-	28, // 12: grpcProtobufMessages.SubscribeStreamMessage.Event:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage
+	29, // 11: grpcProtobufMessages.SubscribeStreamMessage.Response:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage
+	30, // 12: grpcProtobufMessages.SubscribeStreamMessage.Event:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage
 	0,  // 13: grpcProtobufMessages.UnsubscribeResponseMessage.Status:type_name -> grpcProtobufMessages.ResponseStatus
 	3,  // 14: grpcProtobufMessages.UnsubscribeResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
-	2,  // 15: grpcProtobufMessages.FilterExpressions.FilterExpression.FType:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterType
-	15, // 16: grpcProtobufMessages.FilterExpressions.FilterExpression.Value:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue
-	16, // 17: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValuePaths:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.PathsValue
-	17, // 18: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueTimebased:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.TimebasedValue
-	18, // 19: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueRange:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.RangeValue
-	19, // 20: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueChange:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ChangeValue
-	20, // 21: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueCurvelog:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.CurvelogValue
-	21, // 22: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueHistory:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.HistoryValue
-	22, // 23: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueStaticMetadata:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.StaticMetadataValue
-	23, // 24: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueDynamicMetadata:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.DynamicMetadataValue
-	25, // 25: grpcProtobufMessages.DataPackages.DataPackage.Dp:type_name -> grpcProtobufMessages.DataPackages.DataPackage.DataPoint
-	5,  // 26: grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage.DataPack:type_name -> grpcProtobufMessages.DataPackages
-	3,  // 27: grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
-	29, // 28: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponse:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage
-	3,  // 29: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
-	5,  // 30: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage.DataPack:type_name -> grpcProtobufMessages.DataPackages
-	6,  // 31: grpcProtobufMessages.VISSv2.GetRequest:input_type -> grpcProtobufMessages.GetRequestMessage
-	8,  // 32: grpcProtobufMessages.VISSv2.SetRequest:input_type -> grpcProtobufMessages.SetRequestMessage
-	10, // 33: grpcProtobufMessages.VISSv2.SubscribeRequest:input_type -> grpcProtobufMessages.SubscribeRequestMessage
-	12, // 34: grpcProtobufMessages.VISSv2.UnsubscribeRequest:input_type -> grpcProtobufMessages.UnsubscribeRequestMessage
-	7,  // 35: grpcProtobufMessages.VISSv2.GetRequest:output_type -> grpcProtobufMessages.GetResponseMessage
-	9,  // 36: grpcProtobufMessages.VISSv2.SetRequest:output_type -> grpcProtobufMessages.SetResponseMessage
-	11, // 37: grpcProtobufMessages.VISSv2.SubscribeRequest:output_type -> grpcProtobufMessages.SubscribeStreamMessage
-	13, // 38: grpcProtobufMessages.VISSv2.UnsubscribeRequest:output_type -> grpcProtobufMessages.UnsubscribeResponseMessage
-	35, // [35:39] is the sub-list for method output_type
-	31, // [31:35] is the sub-list for method input_type
-	31, // [31:31] is the sub-list for extension type_name
-	31, // [31:31] is the sub-list for extension extendee
-	0,  // [0:31] is the sub-list for field type_name
+	0,  // 15: grpcProtobufMessages.HistoryControlResponseMessage.Status:type_name -> grpcProtobufMessages.ResponseStatus
+	32, // 16: grpcProtobufMessages.HistoryControlResponseMessage.Captures:type_name -> grpcProtobufMessages.HistoryControlResponseMessage.CaptureMessage
+	3,  // 17: grpcProtobufMessages.HistoryControlResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
+	2,  // 18: grpcProtobufMessages.FilterExpressions.FilterExpression.FType:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterType
+	17, // 19: grpcProtobufMessages.FilterExpressions.FilterExpression.Value:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue
+	18, // 20: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValuePaths:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.PathsValue
+	19, // 21: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueTimebased:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.TimebasedValue
+	20, // 22: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueRange:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.RangeValue
+	21, // 23: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueChange:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ChangeValue
+	22, // 24: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueCurvelog:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.CurvelogValue
+	23, // 25: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueHistory:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.HistoryValue
+	24, // 26: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueStaticMetadata:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.StaticMetadataValue
+	25, // 27: grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.ValueDynamicMetadata:type_name -> grpcProtobufMessages.FilterExpressions.FilterExpression.FilterValue.DynamicMetadataValue
+	27, // 28: grpcProtobufMessages.DataPackages.DataPackage.Dp:type_name -> grpcProtobufMessages.DataPackages.DataPackage.DataPoint
+	5,  // 29: grpcProtobufMessages.GetResponseMessage.SuccessResponseMessage.DataPack:type_name -> grpcProtobufMessages.DataPackages
+	3,  // 30: grpcProtobufMessages.SubscribeStreamMessage.SubscribeResponseMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
+	31, // 31: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponse:type_name -> grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage
+	3,  // 32: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.ErrorResponse:type_name -> grpcProtobufMessages.ErrorResponseMessage
+	5,  // 33: grpcProtobufMessages.SubscribeStreamMessage.SubscribeEventMessage.SuccessResponseMessage.DataPack:type_name -> grpcProtobufMessages.DataPackages
+	6,  // 34: grpcProtobufMessages.VISSv2.GetRequest:input_type -> grpcProtobufMessages.GetRequestMessage
+	8,  // 35: grpcProtobufMessages.VISSv2.SetRequest:input_type -> grpcProtobufMessages.SetRequestMessage
+	10, // 36: grpcProtobufMessages.VISSv2.SubscribeRequest:input_type -> grpcProtobufMessages.SubscribeRequestMessage
+	12, // 37: grpcProtobufMessages.VISSv2.UnsubscribeRequest:input_type -> grpcProtobufMessages.UnsubscribeRequestMessage
+	14, // 38: grpcProtobufMessages.VISSv2.HistoryControlRequest:input_type -> grpcProtobufMessages.HistoryControlRequestMessage
+	7,  // 39: grpcProtobufMessages.VISSv2.GetRequest:output_type -> grpcProtobufMessages.GetResponseMessage
+	9,  // 40: grpcProtobufMessages.VISSv2.SetRequest:output_type -> grpcProtobufMessages.SetResponseMessage
+	11, // 41: grpcProtobufMessages.VISSv2.SubscribeRequest:output_type -> grpcProtobufMessages.SubscribeStreamMessage
+	13, // 42: grpcProtobufMessages.VISSv2.UnsubscribeRequest:output_type -> grpcProtobufMessages.UnsubscribeResponseMessage
+	15, // 43: grpcProtobufMessages.VISSv2.HistoryControlRequest:output_type -> grpcProtobufMessages.HistoryControlResponseMessage
+	39, // [39:44] is the sub-list for method output_type
+	34, // [34:39] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_VISSv2_proto_init() }
@@ -2510,7 +2865,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression); i {
+			switch v := v.(*HistoryControlRequestMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2522,7 +2877,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue); i {
+			switch v := v.(*HistoryControlResponseMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2534,7 +2889,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_PathsValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2546,7 +2901,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_TimebasedValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2558,7 +2913,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_RangeValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_PathsValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2570,7 +2925,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_ChangeValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_TimebasedValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2582,7 +2937,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_CurvelogValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_RangeValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2594,7 +2949,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_HistoryValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_ChangeValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2606,7 +2961,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_CurvelogValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2618,7 +2973,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_HistoryValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2630,7 +2985,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataPackages_DataPackage); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_StaticMetadataValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2642,7 +2997,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataPackages_DataPackage_DataPoint); i {
+			switch v := v.(*FilterExpressions_FilterExpression_FilterValue_DynamicMetadataValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2654,7 +3009,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetResponseMessage_SuccessResponseMessage); i {
+			switch v := v.(*DataPackages_DataPackage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2666,7 +3021,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubscribeStreamMessage_SubscribeResponseMessage); i {
+			switch v := v.(*DataPackages_DataPackage_DataPoint); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2678,7 +3033,7 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubscribeStreamMessage_SubscribeEventMessage); i {
+			switch v := v.(*GetResponseMessage_SuccessResponseMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2690,6 +3045,30 @@ func file_VISSv2_proto_init() {
 			}
 		}
 		file_VISSv2_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeStreamMessage_SubscribeResponseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_VISSv2_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeStreamMessage_SubscribeEventMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_VISSv2_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubscribeStreamMessage_SubscribeEventMessage_SuccessResponseMessage); i {
 			case 0:
 				return &v.state
@@ -2701,6 +3080,18 @@ func file_VISSv2_proto_init() {
 				return nil
 			}
 		}
+		file_VISSv2_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryControlResponseMessage_CaptureMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_VISSv2_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[3].OneofWrappers = []interface{}{}
@@ -2711,19 +3102,22 @@ func file_VISSv2_proto_init() {
 	file_VISSv2_proto_msgTypes[8].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[9].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[10].OneofWrappers = []interface{}{}
+	file_VISSv2_proto_msgTypes[11].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[12].OneofWrappers = []interface{}{}
-	file_VISSv2_proto_msgTypes[21].OneofWrappers = []interface{}{}
-	file_VISSv2_proto_msgTypes[22].OneofWrappers = []interface{}{}
+	file_VISSv2_proto_msgTypes[14].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[23].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[24].OneofWrappers = []interface{}{}
 	file_VISSv2_proto_msgTypes[25].OneofWrappers = []interface{}{}
+	file_VISSv2_proto_msgTypes[26].OneofWrappers = []interface{}{}
+	file_VISSv2_proto_msgTypes[27].OneofWrappers = []interface{}{}
+	file_VISSv2_proto_msgTypes[29].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_VISSv2_proto_rawDesc,
 			NumEnums:      3,
-			NumMessages:   27,
+			NumMessages:   30,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -26,6 +26,7 @@ type VISSv2Client interface {
 	SetRequest(ctx context.Context, in *SetRequestMessage, opts ...grpc.CallOption) (*SetResponseMessage, error)
 	SubscribeRequest(ctx context.Context, in *SubscribeRequestMessage, opts ...grpc.CallOption) (VISSv2_SubscribeRequestClient, error)
 	UnsubscribeRequest(ctx context.Context, in *UnsubscribeRequestMessage, opts ...grpc.CallOption) (*UnsubscribeResponseMessage, error)
+	HistoryControlRequest(ctx context.Context, in *HistoryControlRequestMessage, opts ...grpc.CallOption) (*HistoryControlResponseMessage, error)
 }
 
 type vISSv2Client struct {
@@ -95,6 +96,15 @@ func (c *vISSv2Client) UnsubscribeRequest(ctx context.Context, in *UnsubscribeRe
 	return out, nil
 }
 
+func (c *vISSv2Client) HistoryControlRequest(ctx context.Context, in *HistoryControlRequestMessage, opts ...grpc.CallOption) (*HistoryControlResponseMessage, error) {
+	out := new(HistoryControlResponseMessage)
+	err := c.cc.Invoke(ctx, "/grpcProtobufMessages.VISSv2/HistoryControlRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VISSv2Server is the server API for VISSv2 service.
 // All implementations must embed UnimplementedVISSv2Server
 // for forward compatibility
@@ -103,6 +113,7 @@ type VISSv2Server interface {
 	SetRequest(context.Context, *SetRequestMessage) (*SetResponseMessage, error)
 	SubscribeRequest(*SubscribeRequestMessage, VISSv2_SubscribeRequestServer) error
 	UnsubscribeRequest(context.Context, *UnsubscribeRequestMessage) (*UnsubscribeResponseMessage, error)
+	HistoryControlRequest(context.Context, *HistoryControlRequestMessage) (*HistoryControlResponseMessage, error)
 	mustEmbedUnimplementedVISSv2Server()
 }
 
@@ -122,6 +133,9 @@ func (UnimplementedVISSv2Server) SubscribeRequest(*SubscribeRequestMessage, VISS
 func (UnimplementedVISSv2Server) UnsubscribeRequest(context.Context, *UnsubscribeRequestMessage) (*UnsubscribeResponseMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnsubscribeRequest not implemented")
 }
+func (UnimplementedVISSv2Server) HistoryControlRequest(context.Context, *HistoryControlRequestMessage) (*HistoryControlResponseMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HistoryControlRequest not implemented")
+}
 func (UnimplementedVISSv2Server) mustEmbedUnimplementedVISSv2Server() {}
 
 // UnsafeVISSv2Server may be embedded to opt out of forward compatibility for this service.
@@ -210,6 +224,24 @@ func _VISSv2_UnsubscribeRequest_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VISSv2_HistoryControlRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryControlRequestMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VISSv2Server).HistoryControlRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcProtobufMessages.VISSv2/HistoryControlRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VISSv2Server).HistoryControlRequest(ctx, req.(*HistoryControlRequestMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VISSv2_ServiceDesc is the grpc.ServiceDesc for VISSv2 service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,6 +261,10 @@ var VISSv2_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnsubscribeRequest",
 			Handler:    _VISSv2_UnsubscribeRequest_Handler,
 		},
+		{
+			MethodName: "HistoryControlRequest",
+			Handler:    _VISSv2_HistoryControlRequest_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
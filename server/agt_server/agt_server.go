@@ -10,6 +10,7 @@ package main
 
 import (
 	"crypto/rsa"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,8 +37,8 @@ const PORT = 7500
 
 var privKey *rsa.PrivateKey
 
-// Stores a cache of the jwt ids received to not be reused
-var jtiCache map[string]struct{}
+// Stores the jwt ids received to not be reused, opened with the replay_store of the client authentication configuration
+var replayStore utils.ReplayStore
 
 type Payload struct {
 	// Action  string `json:"action"`
@@ -58,7 +59,7 @@ func makeAgtServerHandler(serverChannel chan string) func(http.ResponseWriter, *
 			//CORS POLICY, necessary for web client
 			if req.Method == "OPTIONS" {
 				w.Header().Set("Access-Control-Allow-Origin", "*")
-				w.Header().Set("Access-Control-Allow-Headers", "PoP")
+				w.Header().Set("Access-Control-Allow-Headers", "PoP, Authorization")
 				w.Header().Set("Access-Control-Allow-Methods", "POST")
 				w.Header().Set("Access-Control-Max-Age", "57600")
 			} else {
@@ -70,6 +71,15 @@ func makeAgtServerHandler(serverChannel chan string) func(http.ResponseWriter, *
 				http.Error(w, "400 request unreadable.", 400)
 			} else { // POST REQUEST TO /agts
 				utils.Info.Printf("agtServer:received POST request=%s\n", string(bodyBytes))
+				var payload Payload
+				if json.Unmarshal(bodyBytes, &payload) == nil { // a malformed request is responded by generateResponse()
+					clientId, err := authenticateClient(req, payload)
+					if err != nil {
+						rejectClient(w, err)
+						return
+					}
+					utils.Info.Printf("agtServer:client %s authenticated", clientId)
+				}
 				serverChannel <- string(bodyBytes) // Sends to serverChannel the body of the request
 				pop := string(req.Header.Get("PoP"))
 				if pop != "" {
@@ -92,10 +102,21 @@ func makeAgtServerHandler(serverChannel chan string) func(http.ResponseWriter, *
 	}
 }
 
+// Responds to a request of a client that could not be authenticated
+func rejectClient(w http.ResponseWriter, err error) {
+	utils.Info.Printf("agtServer:client authentication failed, err=%s", err)
+	if challenge := authenticator.Challenge(); challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+	w.Write([]byte(`{"action": "agt-request", "error": "Client authentication failed"}`))
+}
+
 // Initializes the AGT Server to work on the port desired
 func initAgtServer(serverChannel chan string, muxServer *http.ServeMux) {
 	utils.Info.Printf("initAgtServer(): Starting AGT server")
-	utils.ReadTransportSecConfig()                          // loads the secure configuration file
 	agtServerHandler := makeAgtServerHandler(serverChannel) // Generates handlers for the AGT server
 	muxServer.HandleFunc("/agts", agtServerHandler)
 	// Initializes the AGT Server depending on sec configuration
@@ -106,12 +127,15 @@ func initAgtServer(serverChannel chan string, muxServer *http.ServeMux) {
 			/*TLSConfig: utils.GetTLSConfig("localhost", "../transport_sec/"+utils.SecureConfiguration.CaSecPath+"Root.CA.crt",
 			tls.ClientAuthType(utils.CertOptToInt(utils.SecureConfiguration.ServerCertOpt))),*/
 		}
+		if _, ok := authenticator.(certAuthenticator); ok { // the certificate is verified by the authenticator
+			server.TLSConfig = &tls.Config{ClientAuth: tls.RequestClientCert, MinVersion: tls.VersionTLS12}
+		}
 		utils.Info.Printf("initAgtServer():Starting AGT Server with TLS on %s/agts", utils.SecureConfiguration.AgtsSecPort)
 		utils.Info.Printf("initAgtServer():HTTPS:CerOpt=%s", utils.SecureConfiguration.ServerCertOpt)
 		utils.Error.Fatal(server.ListenAndServeTLS("../transport_sec/"+utils.SecureConfiguration.ServerSecPath+"server.crt",
 			"../transport_sec/"+utils.SecureConfiguration.ServerSecPath+"server.key"))
 	} else { // No TLS
-		utils.Info.Printf("initAgtServer():Starting AGT Server without TLS on %s/agts", PORT)
+		utils.Info.Printf("initAgtServer():Starting AGT Server without TLS on %d/agts", PORT)
 		utils.Error.Fatal(http.ListenAndServe(":"+strconv.Itoa(PORT), muxServer))
 	}
 }
@@ -145,13 +169,10 @@ func generateResponse(input string, pop string) string {
 		utils.Error.Printf("generateResponse:error input=%s", input)
 		return `{"action": "agt-request", "error": "Client request malformed"}`
 	}
-	if authenticateClient(payload) {
-		if pop != "" {
-			return generateLTAgt(payload, pop) // In case a pop claim appears, a LT agt must be generated
-		}
-		return generateAgt(payload) // In case no pop claim appears, an ST AGT is issued
+	if pop != "" { // the client is authenticated by the handler
+		return generateLTAgt(payload, pop) // In case a pop claim appears, a LT agt must be generated
 	}
-	return `{"action": "agt-request", "error": "Client authentication failed"}`
+	return generateAgt(payload) // In case no pop claim appears, an ST AGT is issued
 }
 
 // Client roles checking
@@ -186,35 +207,9 @@ func checkRoles(context string) bool {
 
 }
 
-// Checks client context + Proof of context => Not implemented a complex validation
-func authenticateClient(payload Payload) bool {
-	if checkRoles(payload.Context) && payload.Proof == "ABC" { // a bit too simple validation...
-		return true
-	}
-	return false
-}
-
-// Checks if jwt id exist in cache, if it does, return false. If not, it adds it and automatically clear it from cache when it expires
+// Checks if jwt id exist in the replay store, if it does, return false. If not, it adds it until the PoP expires
 func addCheckJti(jti string) bool {
-	if jtiCache == nil { // If map is empty (first time), it doesnt even check, initializes and add
-		jtiCache = make(map[string]struct{})
-		jtiCache[jti] = struct{}{}
-		go deleteJti(jti)
-		return true
-	}
-	if _, ok := jtiCache[jti]; ok { // Check if jti exist in cache
-		return false
-	}
-	// If we get here, it does not exist in cache
-	jtiCache[jti] = struct{}{}
-	go deleteJti(jti)
-	return true
-}
-
-// Deletes the JTI from cache
-func deleteJti(jti string) {
-	time.Sleep((GAP + LIFETIME + 5) * time.Second)
-	delete(jtiCache, jti)
+	return utils.CheckReplay(replayStore, jti, (GAP+LIFETIME+5)*time.Second, "agts")
 }
 
 // generate UUID
@@ -236,10 +231,6 @@ func generateLTAgt(payload Payload, pop string) string {
 		utils.Error.Printf("generateLTAgt: Error unmarshalling pop, err = %s", err)
 		return `{"action": "agt-request", "error": "Client request malformed"}`
 	}
-	if !addCheckJti(popToken.PayloadClaims["jti"]) {
-		utils.Error.Printf("generateLTAgt: JTI used")
-		return `{"action": "agt-request", "error": "Repeated JTI"}`
-	}
 	err = popToken.CheckSignature()
 	if err != nil {
 		utils.Info.Printf("generateLTAgt: Invalid POP signature")
@@ -249,6 +240,10 @@ func generateLTAgt(payload Payload, pop string) string {
 		utils.Info.Printf("generateLTAgt: Not valid POP Token: %s", info)
 		return `{"action": "agt-request", "error": "Invalid POP Token"}`
 	}
+	if !addCheckJti(popToken.PayloadClaims["jti"]) { // only valid PoPs are stored
+		utils.Error.Printf("generateLTAgt: JTI used")
+		return `{"action": "agt-request", "error": "Repeated JTI"}`
+	}
 	// Generates the response token
 	var jwtoken utils.JsonWebToken
 	iat := int(time.Now().Unix())
@@ -296,6 +291,8 @@ func main() {
 		Required: false,
 		Help:     "changes log output level",
 		Default:  "info"})
+	authConfigFile := parser.String("", "authconfig", &argparse.Options{Required: false, Help: "client authentication configuration file", Default: "auth-config.json"})
+	secret := parser.String("", "hashsecret", &argparse.Options{Required: false, Help: "prints the stored form of a client secret for the clients file, then terminates"})
 
 	// Parse input
 	err := parser.Parse(os.Args)
@@ -303,7 +300,30 @@ func main() {
 		fmt.Print(parser.Usage(err))
 	}
 
+	if len(*secret) > 0 {
+		hash, err := hashSecret(*secret)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(hash)
+		return
+	}
+
 	utils.InitLog("agtserver-log.txt", "./logs", *logFile, *logLevel)
+	utils.ReadTransportSecConfig() // loads the secure configuration file
+	authConfig, err := readAuthConfig(*authConfigFile)
+	if err == nil {
+		err = initAuthenticator(authConfig)
+	}
+	if err == nil {
+		if replayStore, err = utils.OpenReplayStore(authConfig.ReplayStore); err != nil {
+			err = fmt.Errorf("replay_store: %s", err)
+		}
+	}
+	if err != nil {
+		utils.Error.Fatalf("Client authentication configuration error: %s", err)
+	}
 	serverChan := make(chan string)
 	muxServer := http.NewServeMux()
 	initKey()
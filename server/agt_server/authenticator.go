@@ -0,0 +1,304 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* The client authentication configuration, read from the auth-config.json file.
+* The clients file registers the clients and the contexts that each client may request. It is required by the credentials authenticator,
+* and optional for the others, which then allow any valid context. A file with the .db extension is an SQLite database, otherwise a JSON file.
+ */
+type AuthConfig struct {
+	Authenticator string                  `json:"authenticator"`
+	Clients       string                  `json:"clients"`
+	CaFile        string                  `json:"ca_file"` // mtls, defaults to the CA in transportSec.json
+	Introspection IntrospectionConfig     `json:"introspection"`
+	ReplayStore   utils.ReplayStoreConfig `json:"replay_store"` // the JWT ids of accepted PoPs, which may be shared with the access token server
+}
+
+// The OAuth2 token introspection endpoint, RFC7662, and the credentials that the AGT server authenticates with to it.
+type IntrospectionConfig struct {
+	Endpoint     string `json:"endpoint"`
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Timeout      string `json:"timeout"`
+}
+
+// Authenticates the client of an AGT request, and returns the client id.
+type Authenticator interface {
+	Authenticate(req *http.Request, payload Payload) (string, error)
+	Challenge() string // the WWW-Authenticate header of a rejected request, or empty
+}
+
+type AuthenticatorFactory func(config AuthConfig) (Authenticator, error)
+
+var authenticatorFactories = map[string]AuthenticatorFactory{}
+
+// Registers an authenticator under the name that selects it in the configuration
+func RegisterAuthenticator(name string, factory AuthenticatorFactory) {
+	authenticatorFactories[name] = factory
+}
+
+func AuthenticatorNames() []string {
+	names := make([]string, 0, len(authenticatorFactories))
+	for name := range authenticatorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterAuthenticator("demo", func(config AuthConfig) (Authenticator, error) { return demoAuthenticator{}, nil })
+	RegisterAuthenticator("mtls", newCertAuthenticator)
+	RegisterAuthenticator("credentials", newCredentialsAuthenticator)
+	RegisterAuthenticator("introspection", newIntrospectionAuthenticator)
+}
+
+var authenticator Authenticator
+var registry clientRegistry // nil if no clients file is configured
+
+// Reads the configuration file. The demo authenticator is used if the file does not exist.
+func readAuthConfig(fileName string) (AuthConfig, error) {
+	config := AuthConfig{Authenticator: "demo", Introspection: IntrospectionConfig{Timeout: "5s"},
+		ReplayStore: utils.ReplayStoreConfig{Type: "sqlite", DbFile: utils.DefaultReplayStoreFile, MaxEntries: 100000}}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return config, nil
+		}
+		return config, err
+	}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return config, nil
+}
+
+func initAuthenticator(config AuthConfig) error {
+	factory, ok := authenticatorFactories[config.Authenticator]
+	if !ok {
+		return fmt.Errorf("authenticator %s is not one of %s", config.Authenticator, strings.Join(AuthenticatorNames(), ", "))
+	}
+	var err error
+	registry = nil
+	if len(config.Clients) > 0 {
+		if registry, err = openClientRegistry(config.Clients); err != nil {
+			return fmt.Errorf("clients: %s", err)
+		}
+	}
+	if authenticator, err = factory(config); err != nil {
+		return fmt.Errorf("%s: %s", config.Authenticator, err)
+	}
+	if config.Authenticator == "demo" {
+		utils.Warning.Printf("initAuthenticator:clients are not authenticated, the demo authenticator accepts the proof ABC")
+	}
+	return nil
+}
+
+// Authenticates the client, and checks that it may request the context.
+func authenticateClient(req *http.Request, payload Payload) (string, error) {
+	if !checkRoles(payload.Context) {
+		return "", fmt.Errorf("invalid context %s", payload.Context)
+	}
+	clientId, err := authenticator.Authenticate(req, payload)
+	if err != nil {
+		return "", err
+	}
+	if registry != nil {
+		client, ok, err := registry.lookup(clientId)
+		if err != nil {
+			return "", err
+		}
+		if !ok || !slices.Contains(client.Contexts, payload.Context) {
+			return "", fmt.Errorf("client %s may not request context %s", clientId, payload.Context)
+		}
+	}
+	return clientId, nil
+}
+
+// Accepts the proof ABC, for the demo clients
+type demoAuthenticator struct{}
+
+func (demoAuthenticator) Authenticate(req *http.Request, payload Payload) (string, error) {
+	if payload.Proof != "ABC" {
+		return "", errors.New("invalid proof")
+	}
+	return "demo", nil
+}
+
+func (demoAuthenticator) Challenge() string {
+	return ""
+}
+
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// Authenticates clients by a TLS client certificate that is issued by the CA.
+type certAuthenticator struct {
+	roots *x509.CertPool
+}
+
+func newCertAuthenticator(config AuthConfig) (Authenticator, error) {
+	if utils.SecureConfiguration.TransportSec != "yes" {
+		return nil, errors.New("transport security is required")
+	}
+	caFile := config.CaFile
+	if len(caFile) == 0 {
+		caFile = utils.TrSecConfigPath + utils.SecureConfiguration.CaSecPath + "Root.CA.crt"
+	}
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("%s: no certificates", caFile)
+	}
+	return certAuthenticator{roots}, nil
+}
+
+func (auth certAuthenticator) Authenticate(req *http.Request, payload Payload) (string, error) {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return "", errors.New("no client certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range req.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	cert := req.TLS.PeerCertificates[0]
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: auth.roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		return "", err
+	}
+	if clientId := certClientId(cert); len(clientId) > 0 {
+		return clientId, nil
+	}
+	return "", errors.New("client certificate without common name or email address")
+}
+
+// Returns the common name, or the email address, which identifies the client in the testCredGen certificates
+func certClientId(cert *x509.Certificate) string {
+	if len(cert.Subject.CommonName) > 0 {
+		return cert.Subject.CommonName
+	}
+	for _, name := range cert.Subject.Names {
+		if email, ok := name.Value.(string); ok && name.Type.Equal(oidEmailAddress) {
+			return email
+		}
+	}
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	return ""
+}
+
+func (certAuthenticator) Challenge() string {
+	return ""
+}
+
+// Authenticates clients by the client id and secret in the clients file, sent with HTTP basic authentication.
+type credentialsAuthenticator struct{}
+
+func newCredentialsAuthenticator(config AuthConfig) (Authenticator, error) {
+	if registry == nil {
+		return nil, errors.New("clients file is required")
+	}
+	return credentialsAuthenticator{}, nil
+}
+
+func (credentialsAuthenticator) Authenticate(req *http.Request, payload Payload) (string, error) {
+	clientId, secret, ok := req.BasicAuth()
+	if !ok {
+		return "", errors.New("no client credentials")
+	}
+	client, ok, err := registry.lookup(clientId)
+	if err != nil {
+		return "", err
+	}
+	if !ok || !verifySecret(secret, client.Secret) {
+		return "", fmt.Errorf("invalid credentials of client %s", clientId)
+	}
+	return clientId, nil
+}
+
+func (credentialsAuthenticator) Challenge() string {
+	return `Basic realm="agts"`
+}
+
+// Authenticates clients by an OAuth2 access token, which they obtained with the client credentials grant, and send as bearer token.
+type introspectionAuthenticator struct {
+	config IntrospectionConfig
+	client *http.Client
+}
+
+func newIntrospectionAuthenticator(config AuthConfig) (Authenticator, error) {
+	endpoint, err := url.Parse(config.Introspection.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("endpoint %s is not an HTTP URL", config.Introspection.Endpoint)
+	}
+	timeout, err := time.ParseDuration(config.Introspection.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("timeout %s: %s", config.Introspection.Timeout, err)
+	}
+	return introspectionAuthenticator{config.Introspection, &http.Client{Timeout: timeout}}, nil
+}
+
+func (auth introspectionAuthenticator) Authenticate(req *http.Request, payload Payload) (string, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || len(token) == 0 {
+		return "", errors.New("no access token")
+	}
+	introspectionReq, err := http.NewRequest("POST", auth.config.Endpoint, strings.NewReader(url.Values{"token": {token}, "token_type_hint": {"access_token"}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	introspectionReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	introspectionReq.Header.Set("Accept", "application/json")
+	if len(auth.config.ClientId) > 0 {
+		introspectionReq.SetBasicAuth(auth.config.ClientId, auth.config.ClientSecret)
+	}
+	resp, err := auth.client.Do(introspectionReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("introspection failed with status %d", resp.StatusCode)
+	}
+	var introspection struct {
+		Active   bool   `json:"active"`
+		ClientId string `json:"client_id"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&introspection); err != nil {
+		return "", err
+	}
+	if !introspection.Active || len(introspection.ClientId) == 0 {
+		return "", errors.New("access token not active")
+	}
+	return introspection.ClientId, nil
+}
+
+func (introspectionAuthenticator) Challenge() string {
+	return `Bearer realm="agts"`
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	fileName := filepath.Join(dir, name)
+	if err := os.WriteFile(fileName, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed, err=%s", err)
+	}
+	return fileName
+}
+
+func agtRequest(context string) (*http.Request, Payload) {
+	payload := Payload{Vin: "GEO001", Context: context}
+	return httptest.NewRequest("POST", "/agts", strings.NewReader(`{"vin":"GEO001","context":"`+context+`"}`)), payload
+}
+
+func TestCredentialsAuthenticator(t *testing.T) {
+	utils.InitLog("agtserver-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	hash, _ := hashSecret("s3cret")
+	fileName := writeFile(t, dir, "clients.json", `{"clients":[{"client_id":"dealer-app", "secret":"`+hash+`", "contexts":["Dealer+OEM+Vehicle"]}]}`)
+	dbFile := filepath.Join(dir, "clients.db")
+	db, _ := sql.Open("sqlite3", dbFile)
+	if _, err := db.Exec("CREATE TABLE CLIENTS (`client_id` TEXT PRIMARY KEY, `secret` TEXT, `contexts` TEXT)"); err != nil {
+		t.Fatalf("Database not created, err=%s", err)
+	}
+	db.Exec("INSERT INTO CLIENTS VALUES (?, ?, ?)", "dealer-app", hash, `["Dealer+OEM+Vehicle"]`)
+	db.Close()
+
+	for _, clients := range []string{fileName, dbFile} {
+		if err := initAuthenticator(AuthConfig{Authenticator: "credentials", Clients: clients}); err != nil {
+			t.Fatalf("%s: initAuthenticator failed, err=%s", clients, err)
+		}
+		tests := []struct {
+			clientId string
+			secret   string
+			context  string
+			valid    bool
+		}{
+			{"dealer-app", "s3cret", "Dealer+OEM+Vehicle", true},
+			{"dealer-app", "wrong", "Dealer+OEM+Vehicle", false},
+			{"other-app", "s3cret", "Dealer+OEM+Vehicle", false},
+			{"dealer-app", "s3cret", "Owner+OEM+Vehicle", false},
+			{"", "", "Dealer+OEM+Vehicle", false},
+		}
+		for _, test := range tests {
+			req, payload := agtRequest(test.context)
+			if len(test.clientId) > 0 {
+				req.SetBasicAuth(test.clientId, test.secret)
+			}
+			if clientId, err := authenticateClient(req, payload); (err == nil) != test.valid || (test.valid && clientId != test.clientId) {
+				t.Errorf("%s: %+v expected valid=%t, got client %s, err=%v", clients, test, test.valid, clientId, err)
+			}
+		}
+	}
+	if err := initAuthenticator(AuthConfig{Authenticator: "credentials"}); err == nil {
+		t.Errorf("Credentials authenticator without clients file accepted")
+	}
+	if verifySecret("s3cret", "s3cret") {
+		t.Errorf("Secret stored in plain text accepted")
+	}
+}
+
+// Returns a certificate signed by the parent, or a self-signed one if parent is nil.
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore, template.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed, err=%s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestCertAuthenticator(t *testing.T) {
+	utils.InitLog("agtserver-log.txt", t.TempDir(), false, "error")
+	caTemplate := &x509.Certificate{Subject: pkix.Name{CommonName: "Root CA"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}
+	ca, caKey := newCertificate(t, caTemplate, nil, nil)
+	otherCa, otherCaKey := newCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Other CA"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil, nil)
+	client, _ := newCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "dealer-app"}}, ca, caKey)
+	emailClient, _ := newCertificate(t, &x509.Certificate{Subject: pkix.Name{Organization: []string{"Example Inc"}, ExtraNames: []pkix.AttributeTypeAndValue{{Type: oidEmailAddress, Value: "clt@example.com"}}}}, ca, caKey)
+	serverOnly, _ := newCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "server"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, ca, caKey)
+	forged, _ := newCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "dealer-app"}}, otherCa, otherCaKey)
+
+	caFile := writeFile(t, t.TempDir(), "Root.CA.crt", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})))
+	utils.SecureConfiguration.TransportSec = "no"
+	if err := initAuthenticator(AuthConfig{Authenticator: "mtls", CaFile: caFile}); err == nil {
+		t.Errorf("Client certificates accepted without transport security")
+	}
+	utils.SecureConfiguration.TransportSec = "yes"
+	defer func() { utils.SecureConfiguration.TransportSec = "no" }()
+	if err := initAuthenticator(AuthConfig{Authenticator: "mtls", CaFile: caFile}); err != nil {
+		t.Fatalf("initAuthenticator failed, err=%s", err)
+	}
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		clientId string
+	}{
+		{"client", client, "dealer-app"},
+		{"email", emailClient, "clt@example.com"},
+		{"server", serverOnly, ""},
+		{"forged", forged, ""},
+		{"none", nil, ""},
+	}
+	for _, test := range tests {
+		req, payload := agtRequest("Dealer+OEM+Vehicle")
+		if test.cert != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{test.cert}}
+		}
+		if clientId, err := authenticateClient(req, payload); clientId != test.clientId || (err == nil) != (len(test.clientId) > 0) {
+			t.Errorf("%s: expected client %q, got %q, err=%v", test.name, test.clientId, clientId, err)
+		}
+	}
+}
+
+func TestIntrospectionAuthenticator(t *testing.T) {
+	utils.InitLog("agtserver-log.txt", t.TempDir(), false, "error")
+	introspection := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if id, secret, ok := req.BasicAuth(); !ok || id != "agts" || secret != "agts-secret" {
+			w.WriteHeader(401)
+			return
+		}
+		req.ParseForm()
+		switch req.PostForm.Get("token") {
+		case "valid-token":
+			w.Write([]byte(`{"active":true, "client_id":"fleet-app", "scope":"agt"}`))
+		case "broken-token":
+			w.Write([]byte(`{"active":`))
+		default:
+			w.Write([]byte(`{"active":false}`))
+		}
+	}))
+	defer introspection.Close()
+	config := AuthConfig{Authenticator: "introspection", Introspection: IntrospectionConfig{Endpoint: introspection.URL, ClientId: "agts", ClientSecret: "agts-secret", Timeout: "5s"}}
+	if err := initAuthenticator(config); err != nil {
+		t.Fatalf("initAuthenticator failed, err=%s", err)
+	}
+	for token, clientId := range map[string]string{"valid-token": "fleet-app", "expired-token": "", "broken-token": "", "": ""} {
+		req, payload := agtRequest("Owner+Third party+Cloud")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if id, err := authenticateClient(req, payload); id != clientId || (err == nil) != (len(clientId) > 0) {
+			t.Errorf("Token %q: expected client %q, got %q, err=%v", token, clientId, id, err)
+		}
+	}
+	config.Introspection.ClientSecret = "wrong"
+	initAuthenticator(config)
+	req, payload := agtRequest("Owner+Third party+Cloud")
+	req.Header.Set("Authorization", "Bearer valid-token")
+	if _, err := authenticateClient(req, payload); err == nil {
+		t.Errorf("Client authenticated although the introspection request was rejected")
+	}
+	config.Introspection.Endpoint = "localhost:9999"
+	if err := initAuthenticator(config); err == nil {
+		t.Errorf("Endpoint without scheme accepted")
+	}
+}
+
+func TestRejectedClient(t *testing.T) {
+	utils.InitLog("agtserver-log.txt", t.TempDir(), false, "error")
+	hash, _ := hashSecret("s3cret")
+	clients := writeFile(t, t.TempDir(), "clients.json", `{"clients":[{"client_id":"dealer-app", "secret":"`+hash+`", "contexts":["Dealer+OEM+Vehicle"]}]}`)
+	if err := initAuthenticator(AuthConfig{Authenticator: "credentials", Clients: clients}); err != nil {
+		t.Fatalf("initAuthenticator failed, err=%s", err)
+	}
+	serverChannel := make(chan string)
+	handler := makeAgtServerHandler(serverChannel)
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("POST", "/agts", strings.NewReader(`{"vin":"GEO001","context":"Dealer+OEM+Vehicle","proof":"ABC"}`)))
+	if recorder.Code != 401 || recorder.Header().Get("WWW-Authenticate") != `Basic realm="agts"` || !strings.Contains(recorder.Body.String(), "Client authentication failed") {
+		t.Errorf("Expected status 401 with a Basic challenge, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,135 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+/*
+* A registered client. The secret is stored as sha256:<salt>:<hash>, see hashSecret(), and is only used by the credentials authenticator.
+* Client secrets must be random strings, as they are not stretched like passwords.
+ */
+type registeredClient struct {
+	ClientId string   `json:"client_id"`
+	Secret   string   `json:"secret"`
+	Contexts []string `json:"contexts"`
+}
+
+type clientRegistry interface {
+	lookup(clientId string) (registeredClient, bool, error)
+}
+
+func openClientRegistry(fileName string) (clientRegistry, error) {
+	if filepath.Ext(fileName) == ".db" {
+		return openSqliteRegistry(fileName)
+	}
+	return readFileRegistry(fileName)
+}
+
+// The clients in a JSON file, {"clients":[{"client_id":..., "secret":..., "contexts":[...]}, ...]}, which is read at startup
+type fileRegistry map[string]registeredClient
+
+func readFileRegistry(fileName string) (clientRegistry, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var clients struct {
+		Clients []registeredClient `json:"clients"`
+	}
+	if err = json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
+	registry := fileRegistry{}
+	for _, client := range clients.Clients {
+		if len(client.ClientId) == 0 {
+			return nil, fmt.Errorf("%s: client without client_id", fileName)
+		}
+		registry[client.ClientId] = client
+	}
+	return registry, nil
+}
+
+func (registry fileRegistry) lookup(clientId string) (registeredClient, bool, error) {
+	client, ok := registry[clientId]
+	return client, ok, nil
+}
+
+// The clients in the CLIENTS table of an SQLite database, where the contexts are a JSON array. Clients can be registered while the server runs.
+type sqliteRegistry struct {
+	dbHandle *sql.DB
+}
+
+func openSqliteRegistry(fileName string) (clientRegistry, error) {
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, err
+	}
+	dbHandle, err := sql.Open("sqlite3", fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dbHandle.Exec("SELECT `client_id`, `secret`, `contexts` FROM CLIENTS LIMIT 1"); err != nil {
+		dbHandle.Close()
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return sqliteRegistry{dbHandle}, nil
+}
+
+func (registry sqliteRegistry) lookup(clientId string) (registeredClient, bool, error) {
+	client := registeredClient{ClientId: clientId}
+	var contexts string
+	err := registry.dbHandle.QueryRow("SELECT `secret`, `contexts` FROM CLIENTS WHERE `client_id`=?", clientId).Scan(&client.Secret, &contexts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return client, false, nil
+	}
+	if err != nil {
+		return client, false, err
+	}
+	if err = json.Unmarshal([]byte(contexts), &client.Contexts); err != nil {
+		return client, false, fmt.Errorf("contexts of client %s: %s", clientId, err)
+	}
+	return client, true, nil
+}
+
+// Returns the stored form of a client secret
+func hashSecret(secret string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(append(salt, secret...))
+	return "sha256:" + hex.EncodeToString(salt) + ":" + hex.EncodeToString(hash[:]), nil
+}
+
+func verifySecret(secret string, stored string) bool {
+	parts := strings.Split(stored, ":")
+	if len(parts) != 3 || parts[0] != "sha256" {
+		return false
+	}
+	salt, err1 := hex.DecodeString(parts[1])
+	expected, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	hash := sha256.Sum256(append(salt, secret...))
+	return subtle.ConstantTimeCompare(hash[:], expected) == 1
+}
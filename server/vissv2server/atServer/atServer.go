@@ -13,7 +13,6 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"encoding/json"
-	"flag"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"io"
@@ -21,8 +20,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	gomodel "github.com/COVESA/vss-tools/binary/go_parser/datamodel"
@@ -39,14 +40,19 @@ const MAXFOUNDNODES = 1500
 const GAP = 3      // Used for PoP check
 const LIFETIME = 5 // Used for PoP check
 
-const theAtSecret = "averysecretkeyvalue2" //not shared
 const AGT_PUB_KEY_DIRECTORY = "agt_public_key.rsa"
-const PORT = 8600
 const AT_DURATION = 1 * 60 * 60 // 1 hour
 
+// The purpose list, scope list, and AGT key files, set from the server configuration
+var PurposeListFile = "atServer/purposelist.json"
+var ScopeListFile = "atServer/scopelist.json"
+var AgtPublicKeyFile = AGT_PUB_KEY_DIRECTORY
+
 var agtKey *rsa.PublicKey
 
-var jtiCache map[string]struct{} // PoPs JTIs that must be refused to not be reused
+// The store of the PoP JTIs that must be refused to not be reused, set from the server configuration
+var ReplayStoreConfig = utils.ReplayStoreConfig{Type: "sqlite", DbFile: utils.DefaultReplayStoreFile, MaxEntries: 100000}
+var replayStore utils.ReplayStore
 
 var muxServer = []*http.ServeMux{
 	http.NewServeMux(), // HTTP
@@ -122,6 +128,7 @@ type ActiveListElem struct {
 	Atoken       string
 	AtokenHandle string
 	AtExpiryTime string
+	PopKey       string // the pub claim of a long term AGT, which the token can be refreshed with
 }
 
 const LISTSIZE = 100
@@ -132,7 +139,7 @@ var expiryTicker *time.Ticker
 
 // Initializes AGT Server public key for AGT checking
 func initAgtKey() {
-	err := utils.ImportRsaPubKey(AGT_PUB_KEY_DIRECTORY, &agtKey)
+	err := utils.ImportRsaPubKey(AgtPublicKeyFile, &agtKey)
 	if err != nil {
 		utils.Error.Printf("Error importing AGT key: %s", fmt.Sprintf("%v", err))
 		return
@@ -182,29 +189,26 @@ func initClientComm(atsChannel chan string, muxServer *http.ServeMux) {
 	utils.ReadTransportSecConfig()                     // loads the secure configuration file
 	atServerHandler := makeAtServerHandler(atsChannel) // Generates handlers for the AT server
 	muxServer.HandleFunc("/ats", atServerHandler)
+	muxServer.HandleFunc(jwksPath, jwksHandler)
 	// Initializes the AT Server depending on sec configuration
+	server := utils.ServerConfiguration.Ats.NewHttpServer(muxServer)
+	address := utils.ServerConfiguration.Ats.ListenAddress(utils.SecureConfiguration.AtsSecPort)
 	if utils.SecureConfiguration.TransportSec == "yes" {
-		server := http.Server{
-			Addr:    ":" + utils.SecureConfiguration.AtsSecPort,
-			Handler: muxServer,
-			TLSConfig: utils.GetTLSConfig("localhost", "../transport_sec/"+utils.SecureConfiguration.CaSecPath+"Root.CA.crt",
-				tls.ClientAuthType(utils.CertOptToInt(utils.SecureConfiguration.ServerCertOpt)), nil),
-		}
-		utils.Info.Printf("initClientComm():Starting AT Server with TLS on %s/ats", utils.SecureConfiguration.AtsSecPort)
+		server.TLSConfig = utils.GetTLSConfig("localhost", utils.TrSecConfigPath+utils.SecureConfiguration.CaSecPath+"Root.CA.crt",
+			tls.ClientAuthType(utils.CertOptToInt(utils.SecureConfiguration.ServerCertOpt)), nil)
+		utils.Info.Printf("initClientComm():Starting AT Server with TLS on %s/ats", address)
 		utils.Info.Printf("HTTPS:CerOpt=%s", utils.SecureConfiguration.ServerCertOpt)
-		utils.Error.Fatal(server.ListenAndServeTLS("../transport_sec/"+utils.SecureConfiguration.ServerSecPath+"server.crt",
-			"../transport_sec/"+utils.SecureConfiguration.ServerSecPath+"server.key"))
+		utils.Error.Fatal(utils.ServeHttp(server, address, utils.TrSecConfigPath+utils.SecureConfiguration.ServerSecPath+"server.crt",
+			utils.TrSecConfigPath+utils.SecureConfiguration.ServerSecPath+"server.key"))
 	} else { // No TLSmtvacuc14uma
-		//utils.Info.Printf("initAtServer():Starting AT Server without TLS on %s/ats", PORT)
-		utils.Error.Fatal(http.ListenAndServe(":"+strconv.Itoa(PORT), muxServer))
+		utils.Info.Printf("initClientComm():Starting AT Server without TLS on %s/ats", address)
+		utils.Error.Fatal(utils.ServeHttp(server, address, "", ""))
 	}
 }
 
 func initEcfComm(ecfReceiveChan chan string, ecfSendChan chan string, muxServer *http.ServeMux) {
 	scheme := "ws"
-	portNum := "8445"
-	var addr = flag.String("addr", "localhost:"+portNum, "http service address")
-	dataSessionUrl := url.URL{Scheme: scheme, Host: *addr, Path: ""}
+	dataSessionUrl := url.URL{Scheme: scheme, Host: utils.ServerConfiguration.EcfAddress, Path: ""}
 	dialer := websocket.Dialer{
 		HandshakeTimeout: time.Second,
 		ReadBufferSize:   1024,
@@ -272,6 +276,8 @@ func generateClientResponse(input string, ecfSendChan chan string, ecfAvailable
 		return accessTokenResponse(input, ecfSendChan, ecfAvailable)
 	} else if strings.Contains(input, "at-inquiry") {
 		return consentInquiryResponse(input)
+	} else if strings.Contains(input, "at-refresh") {
+		return refreshResponse(input)
 	} else {
 		return `{"action":"unknown","status":"401-Bad request"}`
 	}
@@ -384,7 +390,7 @@ func validatePurposeAndAccessPermission(purpose string, action string, path stri
 		if pList[i].Short == purpose {
 			for j := 0; j < len(pList[i].Access); j++ {
 				if pList[i].Access[j].Path == path {
-					if action == "set" && pList[i].Access[j].Permission == "read-only" {
+					if (action == "set" || action == "history-control") && pList[i].Access[j].Permission == "read-only" {
 						return 61
 					} else {
 						return 0
@@ -459,11 +465,15 @@ func tokenValidationResponse(input string) string {
 	var atValidatePayload AtValidatePayload
 	extractAtValidatePayloadLevel1(inputMap, &atValidatePayload)
 	atValidatePayload.Token = getCompleteToken(atValidatePayload.Token)
-	err = utils.VerifyTokenSignature(atValidatePayload.Token, theAtSecret)
+	err = verifyAtSignature(atValidatePayload.Token)
 	if err != nil {
 		utils.Info.Printf("tokenValidationResponse:invalid signature, error= %s, token=%s", err, atValidatePayload.Token)
 		return `{"validation":"5"}`
 	}
+	if isRevoked(atValidatePayload.Token) {
+		utils.Info.Printf("tokenValidationResponse:token revoked, token=%s", atValidatePayload.Token)
+		return `{"validation":"30"}`
+	}
 	purpose := utils.ExtractFromToken(atValidatePayload.Token, "scp")
 	res := validateRequestAccess(purpose, atValidatePayload.Action, atValidatePayload.Paths)
 	if res != 0 {
@@ -613,8 +623,11 @@ func accessTokenResponse(request string, ecfSendChan chan string, ecfAvailable b
 				return `{"action": "at-request", "error":"consent framework not accessible"}`
 			}
 		} else {
-			at := generateAt(payload)
-			writeToActiveList(gatingId, at)
+			at, err := generateAt(payload)
+			if err != nil {
+				return `{"action": "at-request", "error":"Access token could not be generated"}`
+			}
+			writeToActiveList(gatingId, at, payload.Agt.PayloadClaims["pub"])
 			return `{"action": "at-request", "aToken":"` + at + `"}`
 		}
 	}
@@ -673,8 +686,11 @@ func consentInquiryResponse(input string) string {
 				return `{"action": "at-inquiry", "consent":"NO"}`
 			} else { // YES or IN_VEHICLE
 				atGenData := removeFromPendingList(i)
-				at := generateAt(atGenData)
-				writeToActiveList(gatingId, at)
+				at, err := generateAt(atGenData)
+				if err != nil {
+					return `{"action": "at-inquiry", "error":"Access token could not be generated"}`
+				}
+				writeToActiveList(gatingId, at, atGenData.Agt.PayloadClaims["pub"])
 				return `{"action": "at-inquiry", "aToken":"` + at + `", "consent":"` + pendingList[i].Consent + `"}`
 			}
 		}
@@ -775,35 +791,13 @@ func checkVin(vin string) bool {
 	return true // TODO:should be checked with VIN in tree
 }
 
-// Checks if jwt id exist in cache, if it does, return false. If not, it adds it and automatically clear it from cache when it expires
+// Checks if jwt id exist in the replay store, if it does, return false. If not, it adds it until the PoP expires
 func addCheckJti(jti string) bool {
-	if jtiCache == nil { // If map is empty (first time), it doesnt even check, initializes and add
-		jtiCache = make(map[string]struct{})
-		jtiCache[jti] = struct{}{}
-		go deleteJti(jti)
-		return true
-	}
-	if _, ok := jtiCache[jti]; ok { // Check if jti exist in cache
-		return false
-	}
-	// If we get here, it does not exist in cache
-	jtiCache[jti] = struct{}{}
-	go deleteJti(jti)
-	return true
-}
-
-func deleteJti(jti string) {
-	time.Sleep((GAP + LIFETIME + 5) * time.Second)
-	delete(jtiCache, jti)
+	return utils.CheckReplay(replayStore, jti, (GAP+LIFETIME+5)*time.Second, "ats")
 }
 
 // Validates the Proof of Possession of the client key
 func validatePop(payload AtGenPayload) (bool, string) {
-	// Check jti
-	if !addCheckJti(payload.PopTk.PayloadClaims["jti"]) {
-		utils.Error.Printf("validatePop: JTI used")
-		return false, `{"error": "Repeated JTI"}`
-	}
 	// Check signature
 	if err := payload.PopTk.CheckSignature(); err != nil {
 		utils.Info.Printf("validatePop: Invalid POP signature: %s", err)
@@ -825,6 +819,11 @@ func validatePop(payload AtGenPayload) (bool, string) {
 		utils.Info.Printf("validatePop: Aud in POP not valid")
 		return false, `{"error": "Invalid aud"}`
 	}
+	// Check jti, after the other checks so that only valid PoPs are stored
+	if !addCheckJti(payload.PopTk.PayloadClaims["jti"]) {
+		utils.Error.Printf("validatePop: JTI used")
+		return false, `{"error": "Repeated JTI"}`
+	}
 	//utils.Info.Printf("validatePop:Proof of possession of key pair failed")
 	//return false, `{"error": "Proof of possession of key pair failed"}`
 	return true, ""
@@ -866,16 +865,15 @@ func validateRequest(payload AtGenPayload) (bool, string) {
 	return true, ""
 }
 
-func generateAt(payload AtGenPayload) string {
+func generateAt(payload AtGenPayload) (string, error) {
 	unparsedId, err := uuid.NewRandom()
 	if err != nil { // Better way to generate uuid than calling an ext program
 		utils.Error.Printf("generateAgt:Error generating uuid, err=%s", err)
-		return `{"error": "Internal error"}`
+		return "", err
 	}
 	iat := int(time.Now().Unix())
 	exp := iat + AT_DURATION // 1 hour
 	var jwtoken utils.JsonWebToken
-	jwtoken.SetHeader("HS256")
 	//jwtoken.AddClaim("vin", AtGenPayload.Agt.Vin)
 	jwtoken.AddClaim("iat", strconv.Itoa(iat))
 	jwtoken.AddClaim("exp", strconv.Itoa(exp))
@@ -883,17 +881,19 @@ func generateAt(payload AtGenPayload) string {
 	jwtoken.AddClaim("clx", payload.Agt.PayloadClaims["clx"])
 	jwtoken.AddClaim("aud", "w3org/gen2")
 	jwtoken.AddClaim("jti", unparsedId.String())
+	if err = signAt(&jwtoken); err != nil {
+		utils.Error.Printf("generateAt:Error signing token, err=%s", err)
+		return "", err
+	}
 	utils.Info.Printf("generateAt:jwtHeader=%s", jwtoken.GetHeader())
 	utils.Info.Printf("generateAt:jwtPayload=%s", jwtoken.GetPayload())
-	jwtoken.Encode()
-	jwtoken.SymmSign(theAtSecret)
-	return jwtoken.GetFullToken()
+	return jwtoken.GetFullToken(), nil
 }
 
 func initPurposelist() {
-	data, err := os.ReadFile("atServer/purposelist.json")
+	data, err := os.ReadFile(PurposeListFile)
 	if err != nil {
-		utils.Error.Printf("Error reading purposelist.json\n")
+		utils.Error.Printf("Error reading %s\n", PurposeListFile)
 		os.Exit(-1)
 	}
 	err = json.Unmarshal([]byte(data), &purposeList)
@@ -1052,9 +1052,9 @@ func extractPurposeElementsL4SignalAccessL2(k int, index int, accessElem map[str
 }
 
 func initScopeList() {
-	data, err := os.ReadFile("atServer/scopelist.json")
+	data, err := os.ReadFile(ScopeListFile)
 	if err != nil {
-		utils.Info.Printf("scopelist.json not found")
+		utils.Info.Printf("%s not found", ScopeListFile)
 		return
 	}
 	err = json.Unmarshal([]byte(data), &scopeList)
@@ -1214,13 +1214,14 @@ func writeToPendingList(gatingId int, payload AtGenPayload) {
 	utils.Error.Printf("writeToPendingList: No empty element found")
 }
 
-func writeToActiveList(gatingId int, at string) {
+func writeToActiveList(gatingId int, at string, popKey string) {
 	for i := 0; i < LISTSIZE; i++ {
 		if activeList[i].GatingId == -1 {
 			activeList[i].GatingId = gatingId
 			activeList[i].Atoken = at
 			activeList[i].AtokenHandle = extractSignature(activeList[i].Atoken)
 			activeList[i].AtExpiryTime = utils.ExtractFromToken(at, "exp")
+			activeList[i].PopKey = popKey
 			setExpiryTicker()
 			return
 		}
@@ -1236,6 +1237,9 @@ func removeFromPendingList(index int) AtGenPayload {
 
 func removeFromActiveList(index int) {
 	activeList[index].GatingId = -1
+	activeList[index].Atoken = "" // a removed token must no longer validate
+	activeList[index].AtokenHandle = ""
+	activeList[index].PopKey = ""
 }
 
 func purgeLists() string {
@@ -1326,14 +1330,30 @@ func AtServerInit(viss2Chan chan string, viss2CancelChan chan string, vssRootRef
 	ecfReceiveChan := make(chan string)
 	ecfSendChan := make(chan string)
 	ecfAvailable := false
+	reloadChan := make(chan chan ReloadResult)
+	tokenChan := make(chan tokenRequest) // token revocation and introspection
+	hangupChan := make(chan os.Signal, 1)
+	signal.Notify(hangupChan, syscall.SIGHUP) // reloads the access control policy
 
 	initPurposelist()
 	initScopeList()
 	initAgtKey()
 	initLists()
 	initGatingId()
+	initRevocationList()
+	var err error
+	if replayStore, err = utils.OpenReplayStore(ReplayStoreConfig); err != nil {
+		utils.Error.Printf("Error opening the %s replay store, err=%s", ReplayStoreConfig.Type, err)
+		os.Exit(-1)
+	}
+	if err = rotateSigningKeys(time.Now()); err != nil {
+		utils.Error.Printf("Error reading signing keys in %s, err=%s", SigningKeyDir, err)
+		os.Exit(-1)
+	}
 	expiryTicker = time.NewTicker(24 * time.Hour)
+	keyTicker := time.NewTicker(time.Hour) // signing key rotation check
 
+	initAdminComm(reloadChan, tokenChan, muxServer[0])
 	go initClientComm(clientChan, muxServer[0]) //HTTP to client
 	if consentSupport {
 		go initEcfComm(ecfReceiveChan, ecfSendChan, muxServer[1]) // websocket client to ECF
@@ -1363,6 +1383,18 @@ func AtServerInit(viss2Chan chan string, viss2CancelChan chan string, vssRootRef
 				utils.Info.Printf("atServer ECF response=%s", response)
 				ecfSendChan <- response
 			}
+		case <-hangupChan:
+			utils.Info.Printf("atServer SIGHUP received")
+			reloadPolicy(viss2CancelChan)
+		case reply := <-reloadChan:
+			reply <- reloadPolicy(viss2CancelChan)
+		case request := <-tokenChan:
+			utils.Info.Printf("atServer token %s request", request.action)
+			request.reply <- generateTokenResponse(request, viss2CancelChan)
+		case <-keyTicker.C:
+			if err := rotateSigningKeys(time.Now()); err != nil {
+				utils.Error.Printf("atServer signing key rotation failed, err=%s", err)
+			}
 		case <-expiryTicker.C:
 			utils.Info.Printf("atServer expiryTicker triggered")
 			gatingId := purgeLists()
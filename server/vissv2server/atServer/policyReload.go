@@ -0,0 +1,215 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package atServer
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// The file with the bearer token of the admin endpoint, set from the server configuration. The endpoint is disabled if it is empty.
+var AdminTokenFile = ""
+
+const adminReloadPath = "/ats/admin/reload"
+
+// The access control policy, which is validated as a whole before it replaces the active policy.
+type accessPolicy struct {
+	purposes []PurposeElement
+	scopes   []ScopeElement
+	agtKey   *rsa.PublicKey
+}
+
+type ReloadResult struct {
+	Purposes int    `json:"purposes"`
+	Scopes   int    `json:"scopes"`
+	Revoked  int    `json:"revoked"` // active tokens whose purpose is no longer allowed
+	Error    string `json:"error,omitempty"`
+}
+
+// Reads the purpose list, scope list, and AGT key files, without changing the active policy.
+func loadPolicy() (policy accessPolicy, err error) {
+	activePurposes, activeScopes := pList, sList
+	defer func() { // the extract functions write to pList and sList, and panic on some malformed elements
+		pList, sList = activePurposes, activeScopes
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed policy, %v", r)
+		}
+	}()
+	pList, sList = nil, nil
+	data, err := os.ReadFile(PurposeListFile)
+	if err != nil {
+		return policy, err
+	}
+	var purposes map[string]interface{}
+	if err = json.Unmarshal(data, &purposes); err != nil {
+		return policy, fmt.Errorf("%s: %s", PurposeListFile, err)
+	}
+	extractPurposeElementsLevel1(purposes)
+	policy.purposes = pList
+	data, err = os.ReadFile(ScopeListFile)
+	if err == nil {
+		var scopes map[string]interface{}
+		if err = json.Unmarshal(data, &scopes); err != nil {
+			return policy, fmt.Errorf("%s: %s", ScopeListFile, err)
+		}
+		extractScopeElementsLevel1(scopes)
+		policy.scopes = sList
+	} else if !errors.Is(err, os.ErrNotExist) { // the scope list is optional
+		return policy, err
+	}
+	if err = utils.ImportRsaPubKey(AgtPublicKeyFile, &policy.agtKey); err != nil {
+		return policy, fmt.Errorf("%s: %s", AgtPublicKeyFile, err)
+	}
+	return policy, validatePolicy(policy)
+}
+
+func validatePolicy(policy accessPolicy) error {
+	if len(policy.purposes) == 0 {
+		return fmt.Errorf("%s: no purposes", PurposeListFile)
+	}
+	shortNames := map[string]bool{}
+	for _, purpose := range policy.purposes {
+		if len(purpose.Short) == 0 {
+			return fmt.Errorf("%s: purpose without short name", PurposeListFile)
+		}
+		if shortNames[purpose.Short] {
+			return fmt.Errorf("%s: purpose %s defined twice", PurposeListFile, purpose.Short)
+		}
+		shortNames[purpose.Short] = true
+		if err := validateContexts(purpose.Context); err != nil {
+			return fmt.Errorf("%s: purpose %s, %s", PurposeListFile, purpose.Short, err)
+		}
+		for _, access := range purpose.Access {
+			if len(access.Path) == 0 || (access.Permission != "read-only" && access.Permission != "read-write") {
+				return fmt.Errorf("%s: purpose %s, signal access must have a path, and access_mode read-only or read-write", PurposeListFile, purpose.Short)
+			}
+		}
+	}
+	for i, scope := range policy.scopes {
+		if err := validateContexts(scope.Context); err != nil {
+			return fmt.Errorf("%s: scope %d, %s", ScopeListFile, i, err)
+		}
+		if len(scope.NoAccess) == 0 {
+			return fmt.Errorf("%s: scope %d, no_access is missing", ScopeListFile, i)
+		}
+	}
+	return nil
+}
+
+// Each context must have roles of the user, app, and device, as it can never match otherwise.
+func validateContexts(contexts []ContextElement) error {
+	if len(contexts) == 0 {
+		return errors.New("contexts are missing")
+	}
+	for _, context := range contexts {
+		for j, actor := range []string{"user", "app", "device"} {
+			if len(context.Actor[j].Role) == 0 {
+				return fmt.Errorf("context without %s role", actor)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+* Replaces the active policy, and revokes the active tokens whose purpose is no longer allowed in their context.
+* Pending consent requests for such purposes are dropped, and the subscriptions of revoked tokens are cancelled.
+ */
+func applyPolicy(policy accessPolicy, viss2CancelChan chan string) int {
+	pList, sList, agtKey = policy.purposes, policy.scopes, policy.agtKey
+	var revoked []string
+	for i := 0; i < LISTSIZE; i++ {
+		if pendingList[i].GatingId != -1 && !validatePurpose(pendingList[i].AtGenData.Purpose, pendingList[i].AtGenData.Agt.PayloadClaims["clx"]) {
+			removeFromPendingList(i)
+		}
+		if activeList[i].GatingId != -1 && !validatePurpose(utils.ExtractFromToken(activeList[i].Atoken, "scp"), utils.ExtractFromToken(activeList[i].Atoken, "clx")) {
+			revoked = append(revoked, strconv.Itoa(activeList[i].GatingId))
+			removeFromActiveList(i)
+		}
+	}
+	setExpiryTicker()
+	go func() { // the server core may wait for a token validation by the atServer loop
+		for _, gatingId := range revoked {
+			viss2CancelChan <- gatingId
+		}
+	}()
+	return len(revoked)
+}
+
+// Reloads the policy files. The active policy is kept if they are not valid.
+func reloadPolicy(viss2CancelChan chan string) ReloadResult {
+	policy, err := loadPolicy()
+	if err != nil {
+		utils.Error.Printf("reloadPolicy:policy not changed, err=%s", err)
+		return ReloadResult{Error: err.Error()}
+	}
+	result := ReloadResult{Purposes: len(policy.purposes), Scopes: len(policy.scopes), Revoked: applyPolicy(policy, viss2CancelChan)}
+	utils.Info.Printf("reloadPolicy:%d purposes, %d scopes, %d tokens revoked", result.Purposes, result.Scopes, result.Revoked)
+	return result
+}
+
+// Registers the admin endpoints, if there is an admin token, and the token revocation endpoint
+func initAdminComm(reloadChan chan chan ReloadResult, tokenChan chan tokenRequest, muxServer *http.ServeMux) {
+	adminToken := readAdminToken()
+	muxServer.HandleFunc(revokePath, makeTokenHandler("revoke", adminToken, tokenChan))
+	if len(adminToken) == 0 {
+		return
+	}
+	muxServer.HandleFunc(adminReloadPath, makeAdminHandler(adminToken, reloadChan))
+	muxServer.HandleFunc(introspectPath, makeTokenHandler("introspect", adminToken, tokenChan))
+}
+
+func readAdminToken() string {
+	if len(AdminTokenFile) == 0 {
+		return ""
+	}
+	data, err := os.ReadFile(AdminTokenFile)
+	adminToken := strings.TrimSpace(string(data))
+	if err != nil || len(adminToken) == 0 {
+		utils.Error.Printf("readAdminToken:admin endpoints disabled, no token in %s, err=%v", AdminTokenFile, err)
+		return ""
+	}
+	return adminToken
+}
+
+func isAdminRequest(req *http.Request, adminToken string) bool {
+	return len(adminToken) > 0 && subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+adminToken)) == 1
+}
+
+func makeAdminHandler(adminToken string, reloadChan chan chan ReloadResult) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			http.Error(w, "400 bad request method.", 400)
+			return
+		}
+		if !isAdminRequest(req, adminToken) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "401 unauthorized.", 401)
+			return
+		}
+		reply := make(chan ReloadResult)
+		reloadChan <- reply
+		result := <-reply
+		response, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		if len(result.Error) > 0 {
+			w.WriteHeader(422) // the policy files are not valid
+		}
+		w.Write(response)
+	}
+}
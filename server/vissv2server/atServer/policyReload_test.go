@@ -0,0 +1,159 @@
+package atServer
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+const testPurposes = `{"purposes":[
+	{"short":"pay-as-you-drive", "contexts":{"user":"Independent", "app":"OEM", "device":"Cloud"},
+	 "signal_access":{"path":"Vehicle.TraveledDistance", "access_mode":"read-only"}},
+	{"short":"fuel-status", "contexts":{"user":"Independent", "app":"OEM", "device":"Cloud"},
+	 "signal_access":{"path":"Vehicle.Powertrain.FuelSystem", "access_mode":"read-only"}}]}`
+
+// Writes the policy files to a temporary directory, and initializes the access token server lists.
+func initTestPolicy(t *testing.T, purposes string) string {
+	utils.InitLog("atserver-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	var privKey *rsa.PrivateKey
+	if err := utils.GenRsaKey(2048, &privKey); err != nil {
+		t.Fatalf("GenRsaKey failed, err=%s", err)
+	}
+	AgtPublicKeyFile = filepath.Join(dir, "agt_public_key.rsa")
+	if err := utils.ExportKeyPair(privKey, filepath.Join(dir, "agt_private_key.rsa"), AgtPublicKeyFile); err != nil {
+		t.Fatalf("ExportKeyPair failed, err=%s", err)
+	}
+	PurposeListFile = filepath.Join(dir, "purposelist.json")
+	ScopeListFile = filepath.Join(dir, "scopelist.json")
+	os.WriteFile(PurposeListFile, []byte(purposes), 0644)
+	pList, sList = nil, nil
+	initLists()
+	RevocationListFile = filepath.Join(dir, "revocationlist.json")
+	initRevocationList()
+	replayStore, _ = utils.OpenReplayStore(utils.ReplayStoreConfig{Type: "memory", MaxEntries: 100})
+	SigningKeyDir, keyRing = filepath.Join(dir, "signing_keys"), nil
+	if err := rotateSigningKeys(time.Now()); err != nil {
+		t.Fatalf("rotateSigningKeys failed, err=%s", err)
+	}
+	expiryTicker = time.NewTicker(time.Hour)
+	t.Cleanup(expiryTicker.Stop)
+	if result := reloadPolicy(make(chan string)); len(result.Error) > 0 {
+		t.Fatalf("Initial policy rejected, err=%s", result.Error)
+	}
+	return dir
+}
+
+func TestReloadPolicy(t *testing.T) {
+	initTestPolicy(t, testPurposes)
+	agt := utils.ExtendedJwt{PayloadClaims: map[string]string{"clx": "Independent+OEM+Cloud"}}
+	revokedAt, _ := generateAt(AtGenPayload{Purpose: "fuel-status", Agt: agt})
+	keptAt, _ := generateAt(AtGenPayload{Purpose: "pay-as-you-drive", Agt: agt})
+	writeToActiveList(7, revokedAt, "")
+	writeToActiveList(8, keptAt, "")
+	writeToPendingList(9, AtGenPayload{Token: revokedAt, Purpose: "fuel-status", Agt: agt}) // any token with exp
+
+	os.WriteFile(PurposeListFile, []byte(strings.Replace(testPurposes, "fuel-status", "fuel-level", 1)), 0644)
+	cancelChan := make(chan string, 10)
+	result := reloadPolicy(cancelChan)
+	if len(result.Error) > 0 || result.Purposes != 2 || result.Scopes != 0 || result.Revoked != 1 {
+		t.Fatalf("Unexpected reload result %+v", result)
+	}
+	select {
+	case gatingId := <-cancelChan:
+		if gatingId != "7" {
+			t.Errorf("Expected the subscriptions of gating id 7 to be cancelled, got %s", gatingId)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Subscriptions of the revoked token not cancelled")
+	}
+	if getCompleteToken(revokedAt) != "" || getCompleteToken(keptAt) != keptAt {
+		t.Errorf("Expected only the token with the removed purpose to be revoked")
+	}
+	if pendingList[0].GatingId != -1 {
+		t.Errorf("Pending request with the removed purpose not dropped")
+	}
+}
+
+func TestReloadInvalidPolicy(t *testing.T) {
+	dir := initTestPolicy(t, testPurposes)
+	activeKey := agtKey
+	tests := []struct {
+		name     string
+		purposes string
+		scopes   string
+	}{
+		{"json", `{"purposes":[`, ""},
+		{"empty", `{"purposes":[]}`, ""},
+		{"duplicate", strings.Replace(testPurposes, "fuel-status", "pay-as-you-drive", 1), ""},
+		{"role", strings.Replace(testPurposes, `"device":"Cloud"`, `"device":[]`, 1), ""},
+		{"mode", strings.Replace(testPurposes, "read-only", "write-only", 1), ""},
+		{"scope", testPurposes, `{"scope":[{"contexts":{"user":"Driver", "app":"OEM", "device":"Vehicle"}}]}`},
+	}
+	for _, test := range tests {
+		os.WriteFile(PurposeListFile, []byte(test.purposes), 0644)
+		os.Remove(ScopeListFile)
+		if len(test.scopes) > 0 {
+			os.WriteFile(ScopeListFile, []byte(test.scopes), 0644)
+		}
+		if result := reloadPolicy(make(chan string)); len(result.Error) == 0 {
+			t.Errorf("%s: invalid policy accepted", test.name)
+		}
+		if len(pList) != 2 || pList[1].Short != "fuel-status" || len(sList) != 0 || agtKey != activeKey {
+			t.Errorf("%s: active policy changed by an invalid policy", test.name)
+		}
+	}
+	os.WriteFile(PurposeListFile, []byte(testPurposes), 0644)
+	AgtPublicKeyFile = filepath.Join(dir, "missing.rsa")
+	if result := reloadPolicy(make(chan string)); len(result.Error) == 0 || agtKey != activeKey {
+		t.Errorf("Policy without AGT key accepted")
+	}
+}
+
+func TestAdminReload(t *testing.T) {
+	initTestPolicy(t, testPurposes)
+	reloadChan := make(chan chan ReloadResult)
+	go func() {
+		for reply := range reloadChan {
+			reply <- reloadPolicy(make(chan string, LISTSIZE))
+		}
+	}()
+	defer close(reloadChan)
+	server := httptest.NewServer(http.HandlerFunc(makeAdminHandler("s3cret", reloadChan)))
+	defer server.Close()
+	tests := []struct {
+		authorization string
+		status        int
+	}{
+		{"", 401},
+		{"Bearer wrong", 401},
+		{"Bearer s3cret", 200},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", server.URL+adminReloadPath, nil)
+		if len(test.authorization) > 0 {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed, err=%s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("Authorization %q: expected status %d, got %d", test.authorization, test.status, resp.StatusCode)
+		}
+	}
+	os.WriteFile(PurposeListFile, []byte(`{"purposes":[]}`), 0644)
+	req, _ := http.NewRequest("POST", server.URL+adminReloadPath, nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != 422 {
+		t.Errorf("Expected invalid policy to be rejected with status 422, got %v, err=%v", resp, err)
+	}
+}
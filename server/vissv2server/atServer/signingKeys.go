@@ -0,0 +1,254 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package atServer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* The access tokens are signed with the private keys in the signing key directory, and the public keys are published as a JWKS.
+* The newest key of the signing algorithm signs, and is replaced by a new key when it is older than the key rotation period, where 0 disables rotation.
+* A replaced key is kept for validation, and in the JWKS, until the tokens that it signed have expired. The file modification time is the key creation time.
+ */
+var SigningAlgorithm = "RS256"
+var SigningKeyDir = "atServer/signing_keys"
+var KeyRotationPeriod time.Duration
+
+const jwksPath = "/ats/jwks"
+
+func SigningAlgorithms() []string {
+	return []string{"RS256", "ES256"}
+}
+
+type signingKey struct {
+	kid     string // the JWK thumbprint of the public key
+	alg     string
+	privKey crypto.PrivateKey
+	pubKey  crypto.PublicKey
+	created time.Time
+}
+
+var keyRing []signingKey // newest first
+var keyRingMutex sync.RWMutex
+
+func keyFileExtension(alg string) string {
+	if alg == "ES256" {
+		return ".ec"
+	}
+	return ".rsa"
+}
+
+func newSigningKey(alg string, privKey crypto.PrivateKey, created time.Time) (signingKey, error) {
+	key := signingKey{alg: alg, privKey: privKey, created: created}
+	switch k := privKey.(type) {
+	case *rsa.PrivateKey:
+		key.pubKey = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		key.pubKey = &k.PublicKey
+	}
+	var jwk utils.JsonWebKey
+	if err := jwk.Initialize(key.pubKey, "sig"); err != nil {
+		return key, err
+	}
+	key.kid = jwk.Thumb
+	return key, nil
+}
+
+// Reads the private keys in the signing key directory. Files of other types are ignored.
+func readSigningKeys(dir string) ([]signingKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var keys []signingKey
+	for _, entry := range entries {
+		fileName := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		var privKey crypto.PrivateKey
+		var alg string
+		switch filepath.Ext(fileName) {
+		case ".rsa":
+			var rsaKey *rsa.PrivateKey
+			err = utils.ImportRsaKey(fileName, &rsaKey)
+			privKey, alg = rsaKey, "RS256"
+		case ".ec":
+			var ecdsaKey *ecdsa.PrivateKey
+			err = utils.ImportEcdsaKey(fileName, &ecdsaKey)
+			privKey, alg = ecdsaKey, "ES256"
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fileName, err)
+		}
+		key, err := newSigningKey(alg, privKey, info.ModTime())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fileName, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].created.After(keys[j].created) })
+	return keys, nil
+}
+
+// Generates a key of the signing algorithm, and saves it in the signing key directory.
+func generateSigningKey(now time.Time) (signingKey, error) {
+	var privKey crypto.PrivateKey
+	var err error
+	if SigningAlgorithm == "ES256" {
+		var ecdsaKey *ecdsa.PrivateKey
+		err = utils.GenEcdsaKey(elliptic.P256(), &ecdsaKey)
+		privKey = ecdsaKey
+	} else {
+		var rsaKey *rsa.PrivateKey
+		err = utils.GenRsaKey(2048, &rsaKey)
+		privKey = rsaKey
+	}
+	if err != nil {
+		return signingKey{}, err
+	}
+	key, err := newSigningKey(SigningAlgorithm, privKey, now)
+	if err != nil {
+		return key, err
+	}
+	if err = os.MkdirAll(SigningKeyDir, 0700); err != nil {
+		return key, err
+	}
+	fileName := filepath.Join(SigningKeyDir, key.kid+keyFileExtension(SigningAlgorithm))
+	if err = utils.ExportKeyPair(privKey, fileName, ""); err != nil {
+		return key, err
+	}
+	if err = os.Chmod(fileName, 0600); err != nil {
+		return key, err
+	}
+	return key, os.Chtimes(fileName, now, now)
+}
+
+// Removes the replaced keys that have expired, i.e. when the newest token that they signed has expired.
+func pruneSigningKeys(keys []signingKey, now time.Time) []signingKey {
+	var pruned []signingKey
+	for i, key := range keys {
+		if i > 0 {
+			if !now.Before(keys[i-1].created.Add(AT_DURATION * time.Second)) {
+				os.Remove(filepath.Join(SigningKeyDir, key.kid+keyFileExtension(key.alg)))
+				utils.Info.Printf("pruneSigningKeys:key %s removed", key.kid)
+				continue
+			}
+		}
+		pruned = append(pruned, key)
+	}
+	return pruned
+}
+
+/*
+* Reads the signing keys at startup, and is called periodically thereafter.
+* A key is generated if there is no key of the signing algorithm, or the active key is older than the key rotation period.
+ */
+func rotateSigningKeys(now time.Time) error {
+	keyRingMutex.Lock()
+	defer keyRingMutex.Unlock()
+	if keyRing == nil {
+		keys, err := readSigningKeys(SigningKeyDir)
+		if err != nil {
+			return err
+		}
+		keyRing = keys
+	}
+	if len(keyRing) == 0 || keyRing[0].alg != SigningAlgorithm || (KeyRotationPeriod > 0 && now.Sub(keyRing[0].created) >= KeyRotationPeriod) {
+		key, err := generateSigningKey(now)
+		if err != nil {
+			return err
+		}
+		keyRing = append([]signingKey{key}, keyRing...)
+		utils.Info.Printf("rotateSigningKeys:key %s generated", key.kid)
+	}
+	keyRing = pruneSigningKeys(keyRing, now)
+	return nil
+}
+
+func signAt(jwtoken *utils.JsonWebToken) error {
+	keyRingMutex.RLock()
+	defer keyRingMutex.RUnlock()
+	if len(keyRing) == 0 {
+		return errors.New("no signing key")
+	}
+	jwtoken.SetHeader(keyRing[0].alg)
+	jwtoken.AddHeader("kid", keyRing[0].kid)
+	return jwtoken.AssymSign(keyRing[0].privKey)
+}
+
+// Verifies the signature with the key that the kid header refers to, which must be of the algorithm in the alg header.
+func verifyAtSignature(token string) error {
+	var jwtoken utils.JsonWebToken
+	if err := jwtoken.DecodeFromFull(token); err != nil {
+		return err
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal([]byte(jwtoken.Header), &header); err != nil {
+		return err
+	}
+	keyRingMutex.RLock()
+	defer keyRingMutex.RUnlock()
+	for _, key := range keyRing {
+		if key.kid == header.Kid {
+			if key.alg != header.Alg {
+				return fmt.Errorf("alg %s does not match key %s", header.Alg, header.Kid)
+			}
+			return jwtoken.CheckAssymSignature(key.pubKey)
+		}
+	}
+	return fmt.Errorf("unknown key %s", header.Kid)
+}
+
+// Returns the public keys of the key ring as a JWK set, RFC7517 section 5
+func getJwks() string {
+	keyRingMutex.RLock()
+	defer keyRingMutex.RUnlock()
+	var jwks []string
+	for _, key := range keyRing {
+		var jwk utils.JsonWebKey
+		jwk.Initialize(key.pubKey, "sig")
+		jwk.KeyId = key.kid
+		jwk.Alg = key.alg
+		jwks = append(jwks, jwk.Marshal())
+	}
+	return `{"keys":[` + strings.Join(jwks, ",") + `]}`
+}
+
+func jwksHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		http.Error(w, "400 bad request method.", 400)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Write([]byte(getJwks()))
+}
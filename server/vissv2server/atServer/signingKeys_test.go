@@ -0,0 +1,91 @@
+package atServer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func TestSigningKeyRotation(t *testing.T) {
+	for _, alg := range SigningAlgorithms() {
+		utils.InitLog("atserver-log.txt", t.TempDir(), false, "error")
+		SigningAlgorithm, SigningKeyDir, KeyRotationPeriod, keyRing = alg, filepath.Join(t.TempDir(), "signing_keys"), 24*time.Hour, nil
+		start := time.Now()
+		if err := rotateSigningKeys(start); err != nil {
+			t.Fatalf("%s: rotateSigningKeys failed, err=%s", alg, err)
+		}
+		agt := utils.ExtendedJwt{PayloadClaims: map[string]string{"clx": "Independent+OEM+Cloud"}}
+		firstAt, _ := generateAt(AtGenPayload{Purpose: "pay-as-you-drive", Agt: agt})
+		if utils.ExtractFromToken(firstAt, "alg") != alg {
+			t.Errorf("%s: token signed with %s", alg, utils.ExtractFromToken(firstAt, "alg"))
+		}
+		for i := 0; i < 20; i++ { // ES256 signatures vary in length if not padded
+			at, _ := generateAt(AtGenPayload{Purpose: "pay-as-you-drive", Agt: agt})
+			if err := verifyAtSignature(at); err != nil {
+				t.Fatalf("%s: signature not verified, err=%s", alg, err)
+			}
+		}
+		tampered := firstAt[:strings.LastIndex(firstAt, ".")+1] + strings.Repeat("A", len(firstAt)-strings.LastIndex(firstAt, ".")-1)
+		if verifyAtSignature(tampered) == nil {
+			t.Errorf("%s: tampered signature verified", alg)
+		}
+
+		keyRing = nil // restart
+		if err := rotateSigningKeys(start.Add(time.Hour)); err != nil || len(keyRing) != 1 {
+			t.Fatalf("%s: expected the saved key to be read, got %d keys, err=%v", alg, len(keyRing), err)
+		}
+		rotation := start.Add(KeyRotationPeriod)
+		rotateSigningKeys(rotation)
+		secondAt, _ := generateAt(AtGenPayload{Purpose: "pay-as-you-drive", Agt: agt})
+		if len(keyRing) != 2 || utils.ExtractFromToken(secondAt, "kid") == utils.ExtractFromToken(firstAt, "kid") {
+			t.Fatalf("%s: key not rotated", alg)
+		}
+		if verifyAtSignature(firstAt) != nil || verifyAtSignature(secondAt) != nil {
+			t.Errorf("%s: tokens not verified during the key overlap", alg)
+		}
+		rotateSigningKeys(rotation.Add(AT_DURATION * time.Second))
+		if len(keyRing) != 1 || verifyAtSignature(firstAt) == nil || verifyAtSignature(secondAt) != nil {
+			t.Errorf("%s: expected only the replaced key to be removed, got %d keys", alg, len(keyRing))
+		}
+		if files, _ := os.ReadDir(SigningKeyDir); len(files) != 1 {
+			t.Errorf("%s: expected one key file, got %d", alg, len(files))
+		}
+	}
+	SigningAlgorithm, KeyRotationPeriod = "RS256", 0
+}
+
+func TestJwks(t *testing.T) {
+	utils.InitLog("atserver-log.txt", t.TempDir(), false, "error")
+	SigningAlgorithm, SigningKeyDir, keyRing = "ES256", filepath.Join(t.TempDir(), "signing_keys"), nil
+	defer func() { SigningAlgorithm = "RS256" }()
+	now := time.Now()
+	rotateSigningKeys(now)
+	SigningAlgorithm = "RS256" // an algorithm change replaces the key
+	rotateSigningKeys(now.Add(time.Minute))
+	server := httptest.NewServer(http.HandlerFunc(jwksHandler))
+	defer server.Close()
+	resp, err := http.Get(server.URL + jwksPath)
+	if err != nil {
+		t.Fatalf("JWKS request failed, err=%s", err)
+	}
+	defer resp.Body.Close()
+	var jwks struct {
+		Keys []utils.JsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&jwks); err != nil || len(jwks.Keys) != 2 {
+		t.Fatalf("Expected two keys, got %+v, err=%v", jwks, err)
+	}
+	for i, expected := range []string{"RS256", "ES256"} {
+		key := jwks.Keys[i]
+		if key.Alg != expected || key.Use != "sig" || key.KeyId != keyRing[i].kid || key.GenThumbprint() != key.KeyId {
+			t.Errorf("Unexpected JWK %+v", key)
+		}
+	}
+}
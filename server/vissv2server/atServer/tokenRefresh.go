@@ -0,0 +1,93 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package atServer
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// An AT refresh request, {"action":"at-refresh", "agToken":..., "aToken":..., "pop":...}, where aToken is the token, or token handle, to be refreshed
+type AtRefreshPayload struct {
+	AtGenPayload
+	AToken string `json:"aToken"`
+}
+
+/*
+* Issues a new access token with the purpose of the refreshed token, to a client that presents a long term AGT, and a PoP of the AGT key.
+* If the refreshed token is active, the new token replaces it with the same gating id, so that the subscriptions created with it continue, and the refreshed token is revoked.
+* The refreshed token may then only be refreshed with an AGT of the key that it was issued to. A token that is no longer active may be refreshed if its purpose does not require consent.
+ */
+func refreshResponse(request string) string {
+	var payload AtRefreshPayload
+	if err := json.Unmarshal([]byte(request), &payload); err != nil {
+		utils.Error.Printf("refreshResponse:error request=%s", request)
+		return `{"action": "at-refresh", "error": "Client request malformed"}`
+	}
+	if err := payload.Agt.DecodeFromFull(payload.Token); err != nil {
+		utils.Error.Printf("refreshResponse: error decoding token=%s", payload.Token)
+		return `{"action": "at-refresh", "error":"AGT Malformed"}`
+	}
+	if payload.Agt.PayloadClaims["pub"] == "" || payload.Pop == "" {
+		return `{"action": "at-refresh", "error":"Refresh requires a long term AG token and a POP"}`
+	}
+	if err := payload.PopTk.Unmarshal(payload.Pop); err != nil {
+		utils.Error.Printf("refreshResponse: error decoding pop, error=%s, pop=%s", err, payload.Pop)
+		return `{"action": "at-refresh", "error":"POP malformed"}`
+	}
+	token := payload.AToken
+	if completeToken := getCompleteToken(token); len(completeToken) > 0 {
+		token = completeToken
+	}
+	if err := verifyAtSignature(token); err != nil || isRevoked(token) {
+		utils.Info.Printf("refreshResponse:invalid access token, err=%v", err)
+		return `{"action": "at-refresh", "error":"Access token not valid"}`
+	}
+	if utils.ExtractFromToken(token, "clx") != payload.Agt.PayloadClaims["clx"] {
+		return `{"action": "at-refresh", "error":"Access token context does not match AG token"}`
+	}
+	payload.Purpose = utils.ExtractFromToken(token, "scp")
+	if valid, errResponse := validateRequest(payload.AtGenPayload); !valid {
+		return `{"action": "at-refresh", ` + errResponse[1:]
+	}
+	gatingId := -1
+	index := -1
+	for i := 0; i < LISTSIZE; i++ {
+		if activeList[i].GatingId != -1 && activeList[i].Atoken == token {
+			if activeList[i].PopKey != payload.Agt.PayloadClaims["pub"] {
+				utils.Info.Printf("refreshResponse:access token not issued to the AGT key")
+				return `{"action": "at-refresh", "error":"Access token not bound to the AG token key"}`
+			}
+			gatingId = activeList[i].GatingId
+			index = i
+			break
+		}
+	}
+	if gatingId == -1 {
+		if checkifConsent(payload.Purpose) {
+			return `{"action": "at-refresh", "error":"Consent required, a new access token must be requested"}`
+		}
+		gatingId = newGatingId()
+	}
+	at, err := generateAt(payload.AtGenPayload)
+	if err != nil { // the old token stays valid
+		return `{"action": "at-refresh", "error":"Access token could not be generated"}`
+	}
+	if index != -1 {
+		exp, _ := strconv.ParseInt(activeList[index].AtExpiryTime, 10, 64)
+		removeFromActiveList(index)
+		revokedList[utils.ExtractFromToken(token, "jti")] = exp
+		saveRevocationList()
+	}
+	writeToActiveList(gatingId, at, payload.Agt.PayloadClaims["pub"])
+	utils.Info.Printf("refreshResponse:access token refreshed, gatingId=%d", gatingId)
+	return `{"action": "at-refresh", "aToken":"` + at + `"}`
+}
@@ -0,0 +1,119 @@
+package atServer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// Returns a long term AGT for the client key, signed with the AGT server key of initTestPolicy()
+func generateTestLtAgt(t *testing.T, dir string, clientKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	var agtPrivKey *rsa.PrivateKey
+	if err := utils.ImportRsaKey(filepath.Join(dir, "agt_private_key.rsa"), &agtPrivKey); err != nil {
+		t.Fatalf("ImportRsaKey failed, err=%s", err)
+	}
+	var jwk utils.JsonWebKey
+	jwk.Initialize(&clientKey.PublicKey, "sign")
+	iat := int(time.Now().Unix())
+	var jwtoken utils.JsonWebToken
+	jwtoken.SetHeader("RS256")
+	jwtoken.AddClaim("iat", strconv.Itoa(iat))
+	jwtoken.AddClaim("exp", strconv.Itoa(iat+4*24*60*60))
+	jwtoken.AddClaim("clx", "Independent+OEM+Cloud")
+	jwtoken.AddClaim("aud", "w3org/gen2")
+	jwtoken.AddClaim("jti", "agt-"+strconv.Itoa(iat))
+	jwtoken.AddClaim("pub", jwk.Thumb)
+	if err := jwtoken.AssymSign(agtPrivKey); err != nil {
+		t.Fatalf("AssymSign failed, err=%s", err)
+	}
+	return jwtoken.GetFullToken()
+}
+
+func refreshRequest(t *testing.T, agt string, clientKey *ecdsa.PrivateKey, at string) string {
+	t.Helper()
+	var pop utils.PopToken
+	popToken, err := pop.GenerateToken(clientKey)
+	if err != nil {
+		t.Fatalf("GenerateToken failed, err=%s", err)
+	}
+	return `{"action":"at-refresh", "agToken":"` + agt + `", "aToken":"` + at + `", "pop":"` + popToken + `"}`
+}
+
+func TestRefreshToken(t *testing.T) {
+	dir := initTestPolicy(t, testPurposes)
+	var clientKey, otherKey *ecdsa.PrivateKey
+	utils.GenEcdsaKey(elliptic.P256(), &clientKey)
+	utils.GenEcdsaKey(elliptic.P256(), &otherKey)
+	agt := generateTestLtAgt(t, dir, clientKey)
+	var agtToken utils.ExtendedJwt
+	agtToken.DecodeFromFull(agt)
+	oldAt, _ := generateAt(AtGenPayload{Purpose: "fuel-status", Agt: agtToken})
+	writeToActiveList(7, oldAt, agtToken.PayloadClaims["pub"])
+
+	otherAgt := generateTestLtAgt(t, dir, otherKey)
+	if response := refreshResponse(refreshRequest(t, otherAgt, otherKey, oldAt)); !strings.Contains(response, "not bound") {
+		t.Errorf("Token refreshed with the AGT of another key, response=%s", response)
+	}
+	if response := refreshResponse(refreshRequest(t, agt, otherKey, oldAt)); strings.Contains(response, "aToken") {
+		t.Errorf("Token refreshed with the PoP of another key, response=%s", response)
+	}
+
+	response := refreshResponse(refreshRequest(t, agt, clientKey, activeList[0].AtokenHandle))
+	newAt := extractKeyValue("aToken", response)
+	if len(newAt) == 0 {
+		t.Fatalf("Token not refreshed, response=%s", response)
+	}
+	if utils.ExtractFromToken(newAt, "scp") != "fuel-status" {
+		t.Errorf("Refreshed token has purpose %s", utils.ExtractFromToken(newAt, "scp"))
+	}
+	gatingId, _ := getGatingIdAndTokenHandle(newAt)
+	if gatingId != "7" {
+		t.Errorf("Expected the refreshed token to keep gating id 7, got %s", gatingId)
+	}
+	validation := `{"token":"` + oldAt + `", "action":"get", "paths":["Vehicle.Powertrain.FuelSystem"]}`
+	if response := tokenValidationResponse(validation); strings.Contains(response, `"validation":"0"`) {
+		t.Errorf("Replaced token still valid, response=%s", response)
+	}
+	if response := refreshResponse(refreshRequest(t, agt, clientKey, oldAt)); strings.Contains(response, "aToken") {
+		t.Errorf("Replaced token refreshed again, response=%s", response)
+	}
+}
+
+func TestRefreshWithoutSigningKey(t *testing.T) {
+	dir := initTestPolicy(t, testPurposes)
+	var clientKey *ecdsa.PrivateKey
+	utils.GenEcdsaKey(elliptic.P256(), &clientKey)
+	agt := generateTestLtAgt(t, dir, clientKey)
+	var agtToken utils.ExtendedJwt
+	agtToken.DecodeFromFull(agt)
+	oldAt, _ := generateAt(AtGenPayload{Purpose: "fuel-status", Agt: agtToken})
+	writeToActiveList(7, oldAt, agtToken.PayloadClaims["pub"])
+	pendingList[0] = PendingListElem{GatingId: 8, Consent: "YES", AtGenData: AtGenPayload{Purpose: "fuel-status", Agt: agtToken}}
+
+	keyRing = nil // signing fails
+	if _, err := generateAt(AtGenPayload{Purpose: "fuel-status", Agt: agtToken}); err == nil {
+		t.Errorf("Access token generated without a signing key")
+	}
+	if response := refreshResponse(refreshRequest(t, agt, clientKey, oldAt)); extractKeyValue("error", response) == "" {
+		t.Errorf("Expected an error response, got %s", response)
+	}
+	if response := consentInquiryResponse(`{"action":"at-inquiry", "sessionId":"8"}`); extractKeyValue("error", response) == "" {
+		t.Errorf("Expected an error response, got %s", response)
+	}
+	for i := 0; i < LISTSIZE; i++ {
+		if activeList[i].GatingId == 8 || (activeList[i].GatingId == 7 && activeList[i].Atoken != oldAt) {
+			t.Errorf("Unexpected active list element %+v", activeList[i])
+		}
+	}
+	if isRevoked(oldAt) {
+		t.Errorf("Access token revoked although it was not replaced")
+	}
+}
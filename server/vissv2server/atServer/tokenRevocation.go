@@ -0,0 +1,189 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package atServer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// The file that the revoked token ids are saved in, set from the server configuration. They are only kept in memory if it is empty.
+var RevocationListFile = "atServer/revocationlist.json"
+
+const revokePath = "/ats/revoke"
+const introspectPath = "/ats/introspect"
+
+var revokedList map[string]int64 // jti -> expiry time of the token, after which it no longer needs to be listed
+
+// A request from the revocation and introspection handlers to the atServer loop
+type tokenRequest struct {
+	action string // revoke or introspect
+	token  string // token or token handle
+	jti    string
+	admin  bool // authenticated with the admin token
+	reply  chan string
+}
+
+func initRevocationList() {
+	revokedList = map[string]int64{}
+	if len(RevocationListFile) == 0 {
+		return
+	}
+	data, err := os.ReadFile(RevocationListFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Error.Printf("initRevocationList:error reading %s, err=%s", RevocationListFile, err)
+		}
+		return
+	}
+	if err = json.Unmarshal(data, &revokedList); err != nil {
+		utils.Error.Printf("initRevocationList:error data=%s, err=%s", data, err)
+		revokedList = map[string]int64{}
+	}
+	utils.Info.Printf("initRevocationList:%d revoked tokens", len(revokedList))
+}
+
+// Removes the tokens that have expired from the list, and saves it.
+func saveRevocationList() {
+	now := time.Now().Unix()
+	for jti, exp := range revokedList {
+		if exp < now {
+			delete(revokedList, jti)
+		}
+	}
+	if len(RevocationListFile) == 0 {
+		return
+	}
+	data, _ := json.Marshal(revokedList)
+	tmpFile := RevocationListFile + ".tmp"
+	err := os.WriteFile(tmpFile, data, 0600)
+	if err == nil {
+		err = os.Rename(tmpFile, RevocationListFile) // the list is never left half written
+	}
+	if err != nil {
+		utils.Error.Printf("saveRevocationList:error writing %s, err=%s", RevocationListFile, err)
+	}
+}
+
+func isRevoked(token string) bool {
+	_, revoked := revokedList[utils.ExtractFromToken(token, "jti")]
+	return revoked
+}
+
+/*
+* Revokes a token, and cancels the subscriptions that it gates. A token that is not active, e.g. issued before a restart, is revoked if its signature is valid.
+* A token id that is not found is revoked until the longest living token issued now would expire.
+ */
+func revokeToken(token string, jti string, viss2CancelChan chan string) bool {
+	exp := time.Now().Unix() + AT_DURATION
+	if len(token) > 0 {
+		if completeToken := getCompleteToken(token); len(completeToken) > 0 {
+			token = completeToken
+		}
+		if verifyAtSignature(token) != nil {
+			return false
+		}
+		jti = utils.ExtractFromToken(token, "jti")
+		exp, _ = strconv.ParseInt(utils.ExtractFromToken(token, "exp"), 10, 64)
+	}
+	if len(jti) == 0 {
+		return false
+	}
+	for i := 0; i < LISTSIZE; i++ {
+		if activeList[i].GatingId != -1 && utils.ExtractFromToken(activeList[i].Atoken, "jti") == jti {
+			exp, _ = strconv.ParseInt(activeList[i].AtExpiryTime, 10, 64)
+			gatingId := strconv.Itoa(activeList[i].GatingId)
+			removeFromActiveList(i)
+			setExpiryTicker()
+			go func() { viss2CancelChan <- gatingId }() // the server core may wait for a token validation by the atServer loop
+			break
+		}
+	}
+	revokedList[jti] = exp
+	saveRevocationList()
+	utils.Info.Printf("revokeToken:jti=%s revoked", jti)
+	return true
+}
+
+// Returns the introspection response of RFC7662. A token is active if its signature is valid, and it has not expired or been revoked.
+func introspectToken(token string) string {
+	if completeToken := getCompleteToken(token); len(completeToken) > 0 {
+		token = completeToken
+	}
+	if verifyAtSignature(token) != nil || validateTokenExpiry(token) != 0 || isRevoked(token) {
+		return `{"active":false}`
+	}
+	introspection := map[string]interface{}{"active": true, "token_type": "Bearer"}
+	for _, claim := range []string{"scp", "clx", "aud", "jti"} {
+		introspection[claim] = utils.ExtractFromToken(token, claim)
+	}
+	introspection["scope"] = introspection["scp"]
+	delete(introspection, "scp")
+	for _, claim := range []string{"iat", "exp"} {
+		introspection[claim], _ = strconv.Atoi(utils.ExtractFromToken(token, claim))
+	}
+	response, _ := json.Marshal(introspection)
+	return string(response)
+}
+
+func generateTokenResponse(request tokenRequest, viss2CancelChan chan string) string {
+	switch request.action {
+	case "revoke":
+		if len(request.jti) > 0 && !request.admin {
+			return `{"error":"unauthorized"}`
+		}
+		if !revokeToken(request.token, request.jti, viss2CancelChan) {
+			utils.Info.Printf("generateTokenResponse:invalid token not revoked")
+		}
+		return `{}` // also for invalid tokens, RFC7009 section 2.2
+	case "introspect":
+		return introspectToken(request.token)
+	}
+	return `{"error":"invalid_request"}`
+}
+
+/*
+* Token revocation, RFC7009, where the token parameter is a token or token handle, which its holder may revoke.
+* With the admin token as bearer token, a token can also be revoked by its id, in the jti parameter.
+* Token introspection, RFC7662, requires the admin token.
+ */
+func makeTokenHandler(action string, adminToken string, tokenChan chan tokenRequest) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			http.Error(w, "400 bad request method.", 400)
+			return
+		}
+		admin := isAdminRequest(req, adminToken)
+		if (action == "introspect" && !admin) || (len(req.Header.Get("Authorization")) > 0 && !admin) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "401 unauthorized.", 401)
+			return
+		}
+		request := tokenRequest{action: action, token: req.PostFormValue("token"), jti: req.PostFormValue("jti"), admin: admin, reply: make(chan string)}
+		if len(request.token) == 0 && (action == "introspect" || len(request.jti) == 0) {
+			http.Error(w, "400 token parameter missing.", 400)
+			return
+		}
+		tokenChan <- request
+		response := <-request.reply
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if response == `{"error":"unauthorized"}` {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(401)
+		}
+		w.Write([]byte(response))
+	}
+}
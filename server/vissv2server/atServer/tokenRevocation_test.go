@@ -0,0 +1,108 @@
+package atServer
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func TestRevokeToken(t *testing.T) {
+	initTestPolicy(t, testPurposes)
+	agt := utils.ExtendedJwt{PayloadClaims: map[string]string{"clx": "Independent+OEM+Cloud"}}
+	token, _ := generateAt(AtGenPayload{Purpose: "fuel-status", Agt: agt})
+	writeToActiveList(7, token, "")
+	validation := `{"token":"` + token + `", "action":"get", "paths":["Vehicle.Powertrain.FuelSystem"]}`
+	if response := tokenValidationResponse(validation); !strings.Contains(response, `"validation":"0"`) {
+		t.Fatalf("Token not valid before revocation, response=%s", response)
+	}
+
+	cancelChan := make(chan string, 1)
+	if !revokeToken(activeList[0].AtokenHandle, "", cancelChan) {
+		t.Fatalf("Token not revoked by its handle")
+	}
+	select {
+	case gatingId := <-cancelChan:
+		if gatingId != "7" {
+			t.Errorf("Expected the subscriptions of gating id 7 to be cancelled, got %s", gatingId)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Subscriptions of the revoked token not cancelled")
+	}
+	if response := tokenValidationResponse(validation); strings.Contains(response, `"validation":"0"`) {
+		t.Errorf("Revoked token still valid, response=%s", response)
+	}
+	if response := introspectToken(token); response != `{"active":false}` {
+		t.Errorf("Revoked token introspected as %s", response)
+	}
+	if revokeToken(token[:len(token)-4]+"AAAA", "", cancelChan) {
+		t.Errorf("Token with an invalid signature revoked")
+	}
+
+	initRevocationList() // as after a restart
+	if !isRevoked(token) {
+		t.Errorf("Revocation not persisted in %s", RevocationListFile)
+	}
+}
+
+func TestTokenEndpoints(t *testing.T) {
+	initTestPolicy(t, testPurposes)
+	agt := utils.ExtendedJwt{PayloadClaims: map[string]string{"clx": "Independent+OEM+Cloud"}}
+	token, _ := generateAt(AtGenPayload{Purpose: "pay-as-you-drive", Agt: agt})
+	writeToActiveList(8, token, "")
+	tokenChan := make(chan tokenRequest)
+	go func() {
+		for request := range tokenChan {
+			request.reply <- generateTokenResponse(request, make(chan string, LISTSIZE))
+		}
+	}()
+	defer close(tokenChan)
+	mux := http.NewServeMux()
+	mux.HandleFunc(revokePath, makeTokenHandler("revoke", "s3cret", tokenChan))
+	mux.HandleFunc(introspectPath, makeTokenHandler("introspect", "s3cret", tokenChan))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	post := func(path string, authorization string, form url.Values) (int, string) {
+		req, _ := http.NewRequest("POST", server.URL+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(authorization) > 0 {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed, err=%s", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, _ := post(introspectPath, "", url.Values{"token": {token}}); status != 401 {
+		t.Errorf("Introspection without admin token: expected status 401, got %d", status)
+	}
+	status, body := post(introspectPath, "Bearer s3cret", url.Values{"token": {token}})
+	var introspection map[string]interface{}
+	json.Unmarshal([]byte(body), &introspection)
+	if status != 200 || introspection["active"] != true || introspection["scope"] != "pay-as-you-drive" || introspection["clx"] != "Independent+OEM+Cloud" {
+		t.Errorf("Unexpected introspection response %d %s", status, body)
+	}
+	jti := introspection["jti"].(string)
+	if status, _ := post(revokePath, "", url.Values{"jti": {jti}}); status != 401 {
+		t.Errorf("Revocation by jti without admin token: expected status 401, got %d", status)
+	}
+	if status, _ := post(revokePath, "", url.Values{"token": {"unknown"}}); status != 200 {
+		t.Errorf("Revocation of an unknown token: expected status 200, got %d", status)
+	}
+	if status, _ := post(revokePath, "Bearer s3cret", url.Values{"jti": {jti}}); status != 200 {
+		t.Errorf("Revocation by jti: expected status 200, got %d", status)
+	}
+	if status, body := post(introspectPath, "Bearer s3cret", url.Values{"token": {token}}); status != 200 || body != `{"active":false}` {
+		t.Errorf("Revoked token introspected as %d %s", status, body)
+	}
+}
@@ -15,7 +15,7 @@ import (
 	utils "github.com/w3c/automotive-viss2/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"net"
+	"google.golang.org/grpc/keepalive"
 	"strings"
 )
 
@@ -172,7 +172,14 @@ func getSubscriptionId(resp string) string {
 
 func initGrpcServer() {
 	var server *grpc.Server
-	var portNo string
+	readTimeout, _, idleTimeout := utils.ServerConfiguration.Grpc.Timeouts() // the read timeout limits the connection setup
+	var opts []grpc.ServerOption
+	if readTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(readTimeout))
+	}
+	if idleTimeout > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: idleTimeout}))
+	}
 	if utils.SecureConfiguration.TransportSec == "yes" {
 		cert, err := tls.LoadX509KeyPair(utils.TrSecConfigPath+utils.SecureConfiguration.ServerSecPath+"server.crt", utils.TrSecConfigPath+utils.SecureConfiguration.ServerSecPath+"server.key")
 		if err != nil {
@@ -184,21 +191,17 @@ func initGrpcServer() {
 			tls.ClientAuthType(utils.CertOptToInt(utils.SecureConfiguration.ServerCertOpt)), &cert)
 		tlsCredentials := credentials.NewTLS(config)
 
-		opts := []grpc.ServerOption{
+		opts = append(opts,
 			//		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
 			grpc.Creds(tlsCredentials),
-		}
-		server = grpc.NewServer(opts...)
-		portNo = utils.SecureConfiguration.GrpcSecPort
-		utils.Info.Printf("initGrpcServer:port number=%s", portNo)
-	} else {
-		server = grpc.NewServer()
-		portNo = "8887"
-		utils.Info.Printf("portNo =%s", portNo)
+		)
 	}
+	server = grpc.NewServer(opts...)
+	address := utils.ServerConfiguration.Grpc.ListenAddress(utils.SecureConfiguration.GrpcSecPort)
+	utils.Info.Printf("initGrpcServer:address=%s", address)
 	pb.RegisterVISSv2Server(server, &Server{})
 	for {
-		lis, err := net.Listen("tcp", "0.0.0.0:"+portNo)
+		lis, err := utils.Listen(address)
 		if err != nil {
 			utils.Error.Printf("failed to listen: " + err.Error())
 			break
@@ -223,6 +226,9 @@ func (s *Server) GetRequest(ctx context.Context, in *pb.GetRequestMessage) (*pb.
 }
 
 func (s *Server) SetRequest(ctx context.Context, in *pb.SetRequestMessage) (*pb.SetResponseMessage, error) {
+	if isMultiPathSet(in.GetValue()) {
+		return utils.SetResponseJsonToPb(multiPathSetErrorResponse(in), grpcCompression), nil
+	}
 	vssReq := utils.SetRequestPbToJson(in, grpcCompression)
 	grpcResponseChan := make(chan string)
 	var grpcRequestMessage = GrpcRequestMessage{vssReq, grpcResponseChan}
@@ -232,6 +238,22 @@ func (s *Server) SetRequest(ctx context.Context, in *pb.SetRequestMessage) (*pb.
 	return pbResp, nil
 }
 
+/*
+* A multi-path set cannot be encoded in a SetRequestMessage, as its Value is the value of the single Path.
+* A JSON array of path/value pairs in Value is therefore rejected, instead of being set as the value of Path.
+ */
+func isMultiPathSet(value string) bool {
+	var valueList []utils.PathValue
+	return json.Unmarshal([]byte(value), &valueList) == nil && len(valueList) > 0 && len(valueList[0].Path) > 0
+}
+
+func multiPathSetErrorResponse(in *pb.SetRequestMessage) string {
+	requestMap := map[string]interface{}{"action": "set", "requestId": in.GetRequestId()}
+	errorResponseMap := map[string]interface{}{}
+	utils.SetErrorResponse(requestMap, errorResponseMap, 0, "Multi-path set is not supported over gRPC.") //bad_request
+	return utils.FinalizeMessage(errorResponseMap)
+}
+
 func (s *Server) UnsubscribeRequest(ctx context.Context, in *pb.UnsubscribeRequestMessage) (*pb.UnsubscribeResponseMessage, error) {
 	vssReq := utils.UnsubscribeRequestPbToJson(in, grpcCompression)
 	grpcResponseChan := make(chan string)
@@ -242,6 +264,16 @@ func (s *Server) UnsubscribeRequest(ctx context.Context, in *pb.UnsubscribeReque
 	return pbResp, nil
 }
 
+func (s *Server) HistoryControlRequest(ctx context.Context, in *pb.HistoryControlRequestMessage) (*pb.HistoryControlResponseMessage, error) {
+	vssReq := utils.HistoryControlRequestPbToJson(in, grpcCompression)
+	grpcResponseChan := make(chan string)
+	var grpcRequestMessage = GrpcRequestMessage{vssReq, grpcResponseChan}
+	grpcClientChan[0] <- grpcRequestMessage // forward to mgr hub,
+	vssResp := <-grpcResponseChan           //  and wait for response
+	pbResp := utils.HistoryControlResponseJsonToPb(vssResp, grpcCompression)
+	return pbResp, nil
+}
+
 func (s *Server) SubscribeRequest(in *pb.SubscribeRequestMessage, stream pb.VISSv2_SubscribeRequestServer) error {
 	vssReq := utils.SubscribeRequestPbToJson(in, grpcCompression)
 	grpcResponseChan := make(chan string)
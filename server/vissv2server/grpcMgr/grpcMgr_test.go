@@ -0,0 +1,38 @@
+package grpcMgr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/w3c/automotive-viss2/grpc_pb"
+	utils "github.com/w3c/automotive-viss2/utils"
+)
+
+func TestMultiPathSetRejected(t *testing.T) {
+	utils.InitLog("grpcmgr-log.txt", t.TempDir(), false, "error")
+	requestId := "235"
+	request := &pb.SetRequestMessage{Path: "Vehicle.Cabin.HVAC", Value: `[{"path":"Station.Row1.Driver.FanSpeed", "value":"40"}]`, RequestId: &requestId}
+	responseChan := make(chan *pb.SetResponseMessage, 1)
+	go func() {
+		response, _ := (&Server{}).SetRequest(context.Background(), request)
+		responseChan <- response
+	}()
+	select {
+	case response := <-responseChan:
+		if response.GetStatus() != pb.ResponseStatus_ERROR || response.GetErrorResponse().GetReason() != "bad_request" || response.GetRequestId() != requestId {
+			t.Errorf("Expected a bad_request error response, got %s", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Multi-path set forwarded to the server core")
+	}
+	arrayRequest := &pb.SetRequestMessage{Path: "Vehicle.Cabin.Seat.Row1.DriverSide.Massage", Value: `["1", "2"]`, RequestId: &requestId}
+	if _, err := utils.ParseVissRequest(utils.SetRequestPbToJson(arrayRequest, grpcCompression)); err != nil {
+		t.Errorf("Array value not forwarded as a string, err=%s", err)
+	}
+	for value, multiPathSet := range map[string]bool{`["1", "2"]`: false, `40`: false, `[]`: false, request.Value: true} {
+		if isMultiPathSet(value) != multiPathSet {
+			t.Errorf("isMultiPathSet(%s): expected %t", value, multiPathSet)
+		}
+	}
+}
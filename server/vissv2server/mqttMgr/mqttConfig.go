@@ -0,0 +1,187 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+package mqttMgr
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// The broker connection configuration, read from the mqtt-config.json file. Members that are left out get the default values.
+type MqttConfig struct {
+	BrokerAddr   string `json:"broker_addr"`   // default test.mosquitto.org
+	BrokerPort   string `json:"broker_port"`   // default 1883, or 8883 with TLS
+	ClientId     string `json:"client_id"`     // default vissv2server-VIN
+	Username     string `json:"username"`      // no authentication if empty
+	Password     string `json:"password"`      // or the MQTT_PASSWORD environment variable
+	Qos          byte   `json:"qos"`           // of the request topic subscription and the response publications, 0, 1, or 2
+	Retain       bool   `json:"retain"`        // of the response publications
+	CleanSession bool   `json:"clean_session"` // false keeps the session in the broker over a reconnect
+	Tls          bool   `json:"tls"`
+	CaFile       string `json:"ca_file"`   // CA certificate of the broker, default is the system CAs
+	CertFile     string `json:"cert_file"` // client certificate, if the broker requires one
+	KeyFile      string `json:"key_file"`
+	Reconnect    string `json:"reconnect_delay"` // delay between reconnect attempts, Go duration, default 10s
+	// notifications are published to the response topic of the subscribe request, with /subscriptionId appended if true
+	SubscriptionTopics bool `json:"subscription_topics"`
+	// the vehicles served, each with the request topic /VIN/Vehicle, and a partition of the state storage. Default is the vehicle of the server
+	Vins []string `json:"vins"`
+}
+
+// The configuration file, set from the server configuration
+var ConfigFile = "mqtt-config.json"
+
+// Reads the configuration. A missing file gives the default configuration.
+func readMqttConfig(fileName string) (MqttConfig, error) {
+	config := MqttConfig{BrokerAddr: "test.mosquitto.org"}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return config, err
+		}
+		utils.Info.Printf("readMqttConfig: %s not found, using default configuration", fileName)
+	} else if err = json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	if len(config.BrokerPort) == 0 {
+		config.BrokerPort = "1883"
+		if config.Tls {
+			config.BrokerPort = "8883"
+		}
+	}
+	if len(config.Password) == 0 {
+		config.Password = os.Getenv("MQTT_PASSWORD")
+	}
+	if config.Qos > 2 {
+		return config, errors.New("qos must be 0, 1, or 2")
+	}
+	if _, err = config.reconnectDelay(); err != nil {
+		return config, errors.New("reconnect_delay malformed, " + err.Error())
+	}
+	for _, vin := range config.Vins {
+		if err = validateVin(vin); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+// Returns the protocol and port of the configured broker, which is what the clients connect to.
+func BrokerTransport() (string, string) {
+	config, err := readMqttConfig(ConfigFile)
+	if err != nil {
+		utils.Warning.Printf("BrokerTransport: %s", err)
+	}
+	if config.Tls {
+		return "mqtts", config.BrokerPort
+	}
+	return "mqtt", config.BrokerPort
+}
+
+// Reads a configuration file, and returns the error if it is malformed.
+func CheckConfig(fileName string) error {
+	_, err := readMqttConfig(fileName)
+	return err
+}
+
+func (config MqttConfig) reconnectDelay() (time.Duration, error) {
+	if len(config.Reconnect) == 0 {
+		return 10 * time.Second, nil
+	}
+	return time.ParseDuration(config.Reconnect)
+}
+
+func getBrokerSocket(config MqttConfig) string {
+	if config.Tls {
+		return "ssl://" + config.BrokerAddr + ":" + config.BrokerPort
+	}
+	return "tcp://" + config.BrokerAddr + ":" + config.BrokerPort
+}
+
+func getTlsConfig(config MqttConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: config.BrokerAddr, MinVersion: tls.VersionTLS12}
+	if len(config.CaFile) > 0 {
+		caCert, err := os.ReadFile(config.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("no certificate found in " + config.CaFile)
+		}
+	}
+	if len(config.CertFile) > 0 {
+		clientCert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return tlsConfig, nil
+}
+
+/*
+* Returns the client configuration of the long-lived MQTT v5 broker connection. The connection is reestablished when it is lost,
+* and the request topics are subscribed to each time it is connected. Requests are passed to handleRequest.
+ */
+func getClientConfig(config MqttConfig, topics []string, handleRequest func(*paho.Publish)) (autopaho.ClientConfig, error) {
+	brokerUrl, err := url.Parse(getBrokerSocket(config))
+	if err != nil {
+		return autopaho.ClientConfig{}, err
+	}
+	clientConfig := autopaho.ClientConfig{BrokerUrls: []*url.URL{brokerUrl}, KeepAlive: 30}
+	if config.Tls {
+		if clientConfig.TlsCfg, err = getTlsConfig(config); err != nil {
+			return autopaho.ClientConfig{}, err
+		}
+	}
+	clientConfig.ConnectRetryDelay, _ = config.reconnectDelay()
+	clientConfig.ClientID = config.ClientId
+	if len(config.Username) > 0 {
+		clientConfig.SetUsernamePassword(config.Username, []byte(config.Password))
+	}
+	clientConfig.SetConnectPacketConfigurator(func(connect *paho.Connect) *paho.Connect {
+		connect.CleanStart = config.CleanSession
+		if !config.CleanSession {
+			sessionExpiry := uint32(3600) // seconds the broker keeps the session after the connection is lost
+			connect.Properties = &paho.ConnectProperties{SessionExpiryInterval: &sessionExpiry}
+		}
+		return connect
+	})
+	clientConfig.Router = paho.NewSingleHandlerRouter(handleRequest)
+	clientConfig.OnConnectionUp = func(connection *autopaho.ConnectionManager, connack *paho.Connack) {
+		utils.Info.Printf("MQTT client connected to %s, subscribing to %s", getBrokerSocket(config), strings.Join(topics, ", "))
+		subscribe := &paho.Subscribe{}
+		for _, topic := range topics {
+			subscribe.Subscriptions = append(subscribe.Subscriptions, paho.SubscribeOptions{Topic: topic, QoS: config.Qos})
+		}
+		if _, err := connection.Subscribe(context.Background(), subscribe); err != nil {
+			utils.Error.Printf("Subscribe to %s failed, err=%s", strings.Join(topics, ", "), err)
+		}
+	}
+	clientConfig.OnConnectError = func(err error) {
+		utils.Warning.Printf("MQTT connection to %s failed, err=%s. Reconnecting...", getBrokerSocket(config), err)
+	}
+	warning := utils.Warning // the callback is run by a goroutine that is not joined when the connection is closed
+	clientConfig.OnClientError = func(err error) {
+		warning.Printf("MQTT connection to %s lost, err=%s. Reconnecting...", getBrokerSocket(config), err)
+	}
+	return clientConfig, nil
+}
@@ -8,36 +8,36 @@
 package mqttMgr
 
 import (
+	"context"
 	"encoding/json"
-	"os"
+	"errors"
 	"strconv"
 	"strings"
 	"time"
 
-	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
 	"github.com/w3c/automotive-viss2/utils"
 	//	"github.com/gorilla/websocket"
 )
 
-var mqttChannel chan string
+var mqttChannel chan *paho.Publish
 
-type NodeValue struct {
-	topicId int
-	topic   string
+// The response routing of a request. The route of a subscribe request is kept for the notifications.
+type mqttRoute struct {
+	topic           string
+	correlationData []byte
+	subscriptionId  string // set when the subscribe request is granted
 }
 
-type Node struct {
-	value NodeValue
-	next  *Node
-}
+var routeList map[int]*mqttRoute // key is the client id of the RouterId
 
-type TopicList struct {
-	head  *Node
-	nodes int
+type mqttResponse struct {
+	topic           string
+	correlationData []byte
+	payload         string
 }
 
-var topicList TopicList
-
 func vissV2Receiver(transportMgrChan chan string, vissv2Channel chan string) {
 	//	defer dataConn.Close()
 	for {
@@ -52,198 +52,259 @@ func vissV2Receiver(transportMgrChan chan string, vissv2Channel chan string) {
 	}
 }
 
-//TODO add conf file
-func getBrokerSocket(isSecure bool) string {
-	//	FVTAddr := os.Getenv("MQTT_BROKER_ADDR")
+func requestHandler(publication *paho.Publish) {
+	utils.Info.Printf("requestHandler:payload=%s", string(publication.Payload))
+	mqttChannel <- publication
+}
 
-	FVTAddr := "test.mosquitto.org"
-	if FVTAddr == "" {
-		FVTAddr = "127.0.0.1"
+// Connects to the broker, and subscribes to the request topics. The connection is closed when the context is cancelled.
+func mqttConnect(ctx context.Context, config MqttConfig, topics []string) (*autopaho.ConnectionManager, error) {
+	clientConfig, err := getClientConfig(config, topics, requestHandler)
+	if err != nil {
+		return nil, err
 	}
-
-	if isSecure == true {
-		return "ssl://" + FVTAddr + ":8883"
+	connection, err := autopaho.NewConnection(ctx, clientConfig)
+	if err != nil {
+		return nil, err
 	}
-	return "tcp://" + FVTAddr + ":1883"
-}
-
-var publishHandler MQTT.MessageHandler = func(client MQTT.Client, msg MQTT.Message) {
-	//    mqttChannel <- msg.Topic()
-	utils.Info.Printf("publishHandler:payload=%s", string(msg.Payload()))
-	mqttChannel <- string(msg.Payload())
+	return connection, connection.AwaitConnection(ctx) // retried until connected
 }
 
-func mqttSubscribe(brokerSocket string, topic string) MQTT.Client {
-	utils.Info.Printf("mqttSubscribe:Topic=%s", topic)
-	opts := MQTT.NewClientOptions().AddBroker(brokerSocket)
-	//    opts.SetClientID("VIN001")
-	opts.SetDefaultPublishHandler(publishHandler)
-
-	c := MQTT.NewClient(opts)
-	if token := c.Connect(); token.Wait() && token.Error() != nil {
-		panic(token.Error())
+/*
+* Returns the route of the response, and the request. The response topic, and correlation data, are MQTT v5 properties of the request.
+* Requests without a response topic are from MQTT v3 clients, which wrap the request as {"topic":"X", "request":{...}}.
+ */
+func getRequestRoute(publication *paho.Publish) (*mqttRoute, string, error) {
+	if publication.Properties != nil && len(publication.Properties.ResponseTopic) > 0 {
+		return &mqttRoute{topic: publication.Properties.ResponseTopic, correlationData: publication.Properties.CorrelationData}, string(publication.Payload), nil
 	}
-	if token := c.Subscribe(topic, 0, nil); token.Wait() && token.Error() != nil {
-		utils.Error.Println(token.Error())
-		os.Exit(1)
+	topic, request, err := decomposeMqttPayload(string(publication.Payload))
+	if err != nil {
+		return nil, "", err
 	}
-	return c
+	return &mqttRoute{topic: topic}, request, nil
 }
 
-func getTopic(topicId int) string {
-	iterator := topicList.head
-	for i := 0; i < topicList.nodes; i++ {
-		if iterator.value.topicId == topicId {
-			return iterator.value.topic
-		}
-		iterator = iterator.next
+/*
+* Returns the publication of a response, or notification, from the server core. The route of it is removed when it is no longer needed,
+* that is after the response to a get, set, or unsubscribe request, or an error response. The unsubscribe also removes the route of the subscription.
+ */
+func routeResponse(config MqttConfig, vissv2Message string) (mqttResponse, bool) {
+	payload, clientId := utils.RemoveInternalData(vissv2Message)
+	route := routeList[clientId]
+	if route == nil {
+		utils.Warning.Printf("routeResponse: no route for client id %d, response dropped", clientId)
+		return mqttResponse{}, false
 	}
-	return ""
-}
-
-func pushTopic(topic string, topicId int) {
-	var newNode Node
-	newNode.value.topic = topic
-	newNode.value.topicId = topicId
-	newNode.next = nil
-
-	if topicList.nodes == 0 {
-		topicList.head = &newNode
-		topicList.nodes++
-		return
+	var responseMap struct {
+		Action         string           `json:"action"`
+		SubscriptionId string           `json:"subscriptionId"`
+		Error          *json.RawMessage `json:"error"`
 	}
-	iterator := topicList.head
-	for i := 0; i < topicList.nodes; i++ {
-		if iterator.next == nil {
-			iterator.next = &newNode
-			break
+	json.Unmarshal([]byte(payload), &responseMap)
+	response := mqttResponse{route.topic, route.correlationData, payload}
+	switch {
+	case responseMap.Action == "subscription":
+		if config.SubscriptionTopics && len(route.subscriptionId) > 0 {
+			response.topic += "/" + route.subscriptionId
 		}
-		iterator = iterator.next
+	case responseMap.Action == "subscribe" && responseMap.Error == nil:
+		route.subscriptionId = responseMap.SubscriptionId
+	case responseMap.Action == "unsubscribe" && responseMap.Error == nil:
+		for id, subscriptionRoute := range routeList {
+			if subscriptionRoute.subscriptionId == responseMap.SubscriptionId {
+				delete(routeList, id)
+			}
+		}
+		delete(routeList, clientId)
+	default:
+		delete(routeList, clientId)
 	}
-	topicList.nodes++
+	return response, true
 }
 
-func popTopic(topicId int) { //TODO: to be used at unsubscribe, get, set responses from VISSv2
-	if topicList.nodes > 0 && topicList.head.value.topicId == topicId {
-		if topicList.nodes > 1 {
-			topicList.head = topicList.head.next
-		} else {
-			topicList.head = nil
+// Publishes the responses in order on the long-lived connection. While the connection is down, the publishing waits for it to be reestablished.
+func publisher(connection *autopaho.ConnectionManager, config MqttConfig, publishChan chan mqttResponse) {
+	for response := range publishChan {
+		utils.Info.Printf("publisher:Topic=%s, Payload=%s", response.topic, response.payload)
+		publication := &paho.Publish{Topic: response.topic, QoS: config.Qos, Retain: config.Retain, Payload: []byte(response.payload)}
+		if len(response.correlationData) > 0 {
+			publication.Properties = &paho.PublishProperties{CorrelationData: response.correlationData}
 		}
-		topicList.nodes--
-	}
-	iterator := topicList.head
-	var previousNode *Node
-	i := 0
-	for i = 0; i < topicList.nodes; i++ {
-		if iterator.value.topicId == topicId {
-			break
+		connection.AwaitConnection(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if _, err := connection.Publish(ctx, publication); err != nil {
+			utils.Error.Printf("publisher: Publish to %s failed, err=%s", response.topic, err)
 		}
-		previousNode = iterator
-		iterator = iterator.next
-	}
-	if i == topicList.nodes {
-		return
+		cancel()
 	}
-	previousNode.next = iterator.next
-	topicList.nodes--
 }
 
-func publishMessage(brokerSocket string, topic string, payload string) {
-	utils.Info.Printf("publishMessage:Topic=%s, Payload=%s", topic, payload)
-	opts := MQTT.NewClientOptions().AddBroker(brokerSocket)
-	//    opts.SetClientID("VIN001")
-
-	c := MQTT.NewClient(opts)
-	if token := c.Connect(); token.Wait() && token.Error() != nil {
-		utils.Error.Println(token.Error())
-		os.Exit(1)
+// Queues the response for publishing, without blocking the hub. It is dropped if the queue is full.
+func publishMessage(publishChan chan mqttResponse, response mqttResponse) {
+	select {
+	case publishChan <- response:
+	default:
+		utils.Error.Printf("publishMessage: publish queue full, response to %s dropped", response.topic)
 	}
-	token := c.Publish(topic, 0, false, payload)
-	token.Wait()
-	c.Disconnect(250)
 }
 
-func getVissV2Topic(transportMgrChan chan string, mgrId int) string {
+// Returns the VIN of the vehicle of the server, read from the state storage.
+func getVin(transportMgrChan chan string, mgrId int) (string, error) {
 	vinRequest := "{\"RouterId\":\"" + strconv.Itoa(mgrId) + `?0", "action":"get", 
 	"path":"Vehicle.VehicleIdentification.VIN", "requestId":"570415", "origin":"internal"}`
-	/*	err := dataConn.WriteMessage(websocket.TextMessage, []byte(vinRequest))
-		if err != nil {
-			utils.Error.Println("Datachannel write error:" + err.Error())
-		}
-		_, response, err := dataConn.ReadMessage() // receive message from server core
-		if err != nil {
-			utils.Error.Println("Datachannel read error:" + err.Error())
-			os.Exit(1)
-		}*/
 	transportMgrChan <- vinRequest
 	response := <-transportMgrChan
-	vin := extractVin(string(response))
+	vin, err := extractVin(string(response))
+	if err != nil {
+		return "", err
+	}
 	utils.Info.Printf("VIN=%s", vin)
-	return "/" + vin + "/Vehicle"
+	return vin, nil
 }
 
-func extractVin(response string) string {
-	vinStartIndex := strings.Index(response, "value")
-	if vinStartIndex == -1 {
-		utils.Error.Printf("VIN cannot be extracted in %s", response)
-		os.Exit(1)
+func extractVin(response string) (string, error) {
+	var responseMap struct {
+		Data struct {
+			Dp struct {
+				Value string `json:"value"`
+			} `json:"dp"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(response), &responseMap); err != nil || len(responseMap.Data.Dp.Value) == 0 {
+		return "", errors.New("VIN cannot be extracted in " + response)
 	}
-	vinStartIndex += 8 // value”:”
-	vinEndIndex := utils.NextQuoteMark([]byte(response), vinStartIndex)
-	return response[vinStartIndex:vinEndIndex]
+	return responseMap.Data.Dp.Value, validateVin(responseMap.Data.Dp.Value)
 }
 
-func decomposeMqttPayload(mqttPayload string) (string, string) { // {"topic":"X", "request":"{...}"}
-	var payloadMap = make(map[string]interface{})
-	utils.MapRequest(mqttPayload, &payloadMap)
-	payload, err := json.Marshal(payloadMap["request"])
-	if err != nil {
-		utils.Error.Printf("decomposeMqttPayload: cannot marshal request in response=%s", mqttPayload)
-		os.Exit(1)
+// A VIN is a topic level, so it must not contain topic separators or wildcards.
+func validateVin(vin string) error {
+	if len(vin) == 0 || strings.ContainsAny(vin, "/+#") {
+		return errors.New("VIN " + vin + " cannot be used in a topic")
 	}
-	return payloadMap["topic"].(string), string(payload)
+	return nil
 }
 
-func AddRoutingInfoAndForward(reqMessage string, mgrId int, clientId int, transportMgrChan chan string) {
-	newPrefix := "{ \"RouterId\":\"" + strconv.Itoa(mgrId) + "?" + strconv.Itoa(clientId) + "\", "
-	request := strings.Replace(reqMessage, "{", newPrefix, 1)
-	transportMgrChan <- request
+func getVissV2Topic(vin string) string {
+	return "/" + vin + "/Vehicle"
+}
+
+// Returns the VIN of the request topic /VIN/Vehicle.
+func getTopicVin(topic string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(topic, "/"), "/Vehicle")
+}
+
+func decomposeMqttPayload(mqttPayload string) (string, string, error) { // {"topic":"X", "request":{...}}
+	var payloadMap struct {
+		Topic   string          `json:"topic"`
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(mqttPayload), &payloadMap); err != nil {
+		return "", "", err
+	}
+	if len(payloadMap.Topic) == 0 || len(payloadMap.Request) == 0 || payloadMap.Request[0] != '{' {
+		return "", "", errors.New("topic or request missing")
+	}
+	return payloadMap.Topic, string(payloadMap.Request), nil
+}
+
+/*
+* Adds the RouterId, and the vin of the vehicle if the server serves several vehicles. The topic selects the vehicle,
+* so a vin member of the client request is removed, as is a RouterId member, which must not redirect the responses.
+ */
+func AddRoutingInfoAndForward(reqMessage string, mgrId int, clientId int, vin string, transportMgrChan chan string) error {
+	var requestMap map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reqMessage), &requestMap); err != nil {
+		return err
+	}
+	for _, member := range []string{"vin", "RouterId"} {
+		if _, ok := requestMap[member]; ok {
+			utils.Warning.Printf("AddRoutingInfoAndForward:%s member of the client request removed", member)
+			delete(requestMap, member)
+		}
+	}
+	members, err := json.Marshal(requestMap)
+	if err != nil {
+		return err
+	}
+	newPrefix := "{ \"RouterId\":\"" + strconv.Itoa(mgrId) + "?" + strconv.Itoa(clientId) + "\""
+	if len(vin) > 0 {
+		newPrefix += ", \"vin\":\"" + vin + "\""
+	}
+	if len(requestMap) > 0 {
+		newPrefix += ", "
+	}
+	transportMgrChan <- newPrefix + string(members[1:]) // the RouterId is the first member, see getRouterId() of the server core
+	return nil
 }
 
 func MqttMgrInit(mgrId int, transportMgrChan chan string) {
-	mqttChannel = make(chan string)
+	mqttChannel = make(chan *paho.Publish)
 	vissv2Channel := make(chan string)
+	publishChan := make(chan mqttResponse, 100)
 
-	brokerSocket := getBrokerSocket(false)
-	serverSubscription := mqttSubscribe(brokerSocket, getVissV2Topic(transportMgrChan, mgrId))
+	config, err := readMqttConfig(ConfigFile)
+	if err != nil {
+		utils.Error.Printf("MQTT manager not started, %s malformed, err=%s", ConfigFile, err)
+		return
+	}
+	vins := config.Vins
+	if len(vins) == 0 { // the vehicle of the server
+		vin, err := getVin(transportMgrChan, mgrId)
+		if err != nil {
+			utils.Error.Printf("MQTT manager not started, err=%s", err)
+			return
+		}
+		vins = []string{vin}
+	}
+	topics := make([]string, len(vins))
+	for i, vin := range vins {
+		topics[i] = getVissV2Topic(vin)
+	}
+	if len(config.ClientId) == 0 {
+		config.ClientId = "vissv2server-" + vins[0] // a stable id, for the broker to keep the session
+	}
+	connection, err := mqttConnect(context.Background(), config, topics) // kept for the lifetime of the server
+	if err != nil {
+		utils.Error.Printf("MQTT manager not started, connect to %s failed, err=%s", getBrokerSocket(config), err)
+		return
+	}
 	topicId := 0
-	topicList.nodes = 0
+	routeList = make(map[int]*mqttRoute)
 
 	go vissV2Receiver(transportMgrChan, vissv2Channel) //message reception from server core
+	go publisher(connection, config, publishChan)
 
 	utils.Info.Println("**** MQTT manager hub entering server loop... ****")
 
 	for {
 		select {
 
-		case mqttPayload := <-mqttChannel:
-			topic, payload := decomposeMqttPayload(mqttPayload)
-			utils.Info.Printf("MQTT mgr hub: Message from broker:Topic=%s, Payload=%s\n", topic, payload)
-			pushTopic(topic, topicId)
-			AddRoutingInfoAndForward(payload, mgrId, topicId, transportMgrChan)
+		case publication := <-mqttChannel:
+			route, payload, err := getRequestRoute(publication)
+			if err != nil {
+				utils.Error.Printf("MQTT mgr hub: Malformed request %s dropped, err=%s", string(publication.Payload), err)
+				continue
+			}
+			utils.Info.Printf("MQTT mgr hub: Message from broker:Topic=%s, Payload=%s\n", route.topic, payload)
+			routeList[topicId] = route
+			vin := ""
+			if len(config.Vins) > 0 { // the state storage is partitioned per vehicle
+				vin = getTopicVin(publication.Topic)
+			}
+			if err = AddRoutingInfoAndForward(payload, mgrId, topicId, vin, transportMgrChan); err != nil {
+				utils.Error.Printf("MQTT mgr hub: Malformed request %s dropped, err=%s", payload, err)
+				delete(routeList, topicId)
+				continue
+			}
 			topicId++
 
 		case vissv2Message := <-vissv2Channel:
 			utils.Info.Printf("MQTT hub: Message from VISSv2 server:%s\n", vissv2Message)
 			// link routerId to topic, remove routerId from message, create mqtt message, send message to mqtt transport
-			payload, topicHandle := utils.RemoveInternalData(string(vissv2Message))
-			publishMessage(brokerSocket, getTopic(topicHandle), payload)
-
-		default:
-			time.Sleep(25 * time.Millisecond)
+			if response, ok := routeResponse(config, vissv2Message); ok {
+				publishMessage(publishChan, response)
+			}
 		}
 	}
-
-	serverSubscription.Disconnect(250)
 }
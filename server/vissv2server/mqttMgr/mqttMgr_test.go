@@ -0,0 +1,287 @@
+package mqttMgr
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// A broker stand-in that acknowledges the client packets, and passes them to the test.
+type testBroker struct {
+	listener net.Listener
+	conns    chan net.Conn
+	received chan packets.Packet
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed, err=%s", err)
+	}
+	broker := &testBroker{listener, make(chan net.Conn, 10), make(chan packets.Packet, 100)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			broker.conns <- conn
+			go broker.serve(conn)
+		}
+	}()
+	return broker
+}
+
+func (broker *testBroker) port() string {
+	return strconv.Itoa(broker.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (broker *testBroker) serve(conn net.Conn) {
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		broker.received <- packet.Content
+		var response *packets.ControlPacket
+		switch p := packet.Content.(type) {
+		case *packets.Connect:
+			response = packets.NewControlPacket(packets.CONNACK)
+		case *packets.Subscribe:
+			response = packets.NewControlPacket(packets.SUBACK)
+			suback := response.Content.(*packets.Suback)
+			suback.PacketID = p.PacketID
+			for _, subscription := range p.Subscriptions {
+				suback.Reasons = append(suback.Reasons, subscription.QoS)
+			}
+		case *packets.Publish:
+			if p.QoS == 1 {
+				response = packets.NewControlPacket(packets.PUBACK)
+				response.Content.(*packets.Puback).PacketID = p.PacketID
+			}
+		case *packets.Pingreq:
+			response = packets.NewControlPacket(packets.PINGRESP)
+		}
+		if response != nil {
+			response.WriteTo(conn)
+		}
+	}
+}
+
+// Sends a request publication to the client.
+func (broker *testBroker) publish(conn net.Conn, packetId uint16, properties *packets.Properties, payload string) {
+	packet := packets.NewControlPacket(packets.PUBLISH)
+	packet.Content = &packets.Publish{Topic: "/VIN001/Vehicle", QoS: 1, PacketID: packetId, Properties: properties, Payload: []byte(payload)}
+	packet.WriteTo(conn)
+}
+
+// Returns the next received packet of type T. Other packets are skipped.
+func expect[T packets.Packet](t *testing.T, broker *testBroker) T {
+	t.Helper()
+	for {
+		select {
+		case packet := <-broker.received:
+			if typed, ok := packet.(T); ok {
+				return typed
+			}
+		case <-time.After(5 * time.Second):
+			var none T
+			t.Fatalf("Expected packet %T not received", none)
+			return none
+		}
+	}
+}
+
+func TestReadMqttConfig(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	config, err := readMqttConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || config.BrokerAddr != "test.mosquitto.org" || config.BrokerPort != "1883" || config.Qos != 0 {
+		t.Errorf("Unexpected default configuration %+v, err=%v", config, err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+		socket  string
+	}{
+		{"tls", `{"broker_addr":"broker.example.com", "tls":true, "qos":2}`, true, "ssl://broker.example.com:8883"},
+		{"port", `{"broker_addr":"127.0.0.1", "broker_port":"1884"}`, true, "tcp://127.0.0.1:1884"},
+		{"qos", `{"qos":3}`, false, ""},
+		{"reconnect", `{"reconnect_delay":"often"}`, false, ""},
+		{"json", `{"qos":`, false, ""},
+		{"vins", `{"vins":["VIN001", "VIN002"]}`, true, "tcp://test.mosquitto.org:1883"},
+		{"vin", `{"vins":["VIN001", "VIN/002"]}`, false, ""},
+	}
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.name+".json")
+		os.WriteFile(fileName, []byte(test.content), 0644)
+		config, err := readMqttConfig(fileName)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid=%t, err=%v", test.name, test.valid, err)
+		} else if test.valid && getBrokerSocket(config) != test.socket {
+			t.Errorf("%s: expected broker socket %s, got %s", test.name, test.socket, getBrokerSocket(config))
+		}
+	}
+}
+
+func TestPersistentConnection(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	broker := newTestBroker(t)
+	mqttChannel = make(chan *paho.Publish, 10)
+	config := MqttConfig{BrokerAddr: "127.0.0.1", BrokerPort: broker.port(), ClientId: "vissv2server-VIN001", Username: "vissv2", Password: "secret",
+		Qos: 1, Retain: true, Reconnect: "100ms"}
+	ctx, cancel := context.WithCancel(context.Background())
+	connection, err := mqttConnect(ctx, config, []string{"/VIN001/Vehicle", "/VIN002/Vehicle"})
+	t.Cleanup(func() { // the connection must be closed before the next test replaces the loggers
+		cancel()
+		if connection != nil {
+			<-connection.Done()
+		}
+	})
+	if err != nil {
+		t.Fatalf("mqttConnect failed, err=%s", err)
+	}
+	connect := expect[*packets.Connect](t, broker)
+	if connect.ClientID != config.ClientId || connect.Username != config.Username || string(connect.Password) != config.Password || connect.CleanStart ||
+		connect.Properties.SessionExpiryInterval == nil {
+		t.Errorf("Unexpected connect %s", connect)
+	}
+	subscribe := expect[*packets.Subscribe](t, broker)
+	if len(subscribe.Subscriptions) != 2 || subscribe.Subscriptions[1].Topic != "/VIN002/Vehicle" || subscribe.Subscriptions[1].QoS != 1 {
+		t.Errorf("Expected subscriptions to /VIN001/Vehicle and /VIN002/Vehicle with QoS 1, got %s", subscribe)
+	}
+
+	conn := <-broker.conns
+	broker.publish(conn, 1, &packets.Properties{ResponseTopic: "response/1", CorrelationData: []byte("c1")}, `{"action":"get", "path":"Vehicle.Speed", "requestId":"1"}`)
+	select {
+	case publication := <-mqttChannel:
+		if route, _, err := getRequestRoute(publication); err != nil || route.topic != "response/1" || string(route.correlationData) != "c1" {
+			t.Errorf("Unexpected request route %+v, err=%v", route, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Request not forwarded")
+	}
+	if puback := expect[*packets.Puback](t, broker); puback.PacketID != 1 {
+		t.Errorf("Request acknowledged with packet id %d", puback.PacketID)
+	}
+
+	publishChan := make(chan mqttResponse, 10)
+	go publisher(connection, config, publishChan)
+	defer close(publishChan)
+	for i := 0; i < 3; i++ { // all on the same connection
+		publishMessage(publishChan, mqttResponse{"response/1", []byte("c1"), `{"action":"get", "requestId":"1"}`})
+		response := expect[*packets.Publish](t, broker)
+		if response.Topic != "response/1" || response.QoS != 1 || !response.Retain || string(response.Properties.CorrelationData) != "c1" {
+			t.Errorf("Expected response on response/1 with QoS 1, retain, and correlation data, got %s", response)
+		}
+	}
+	if len(broker.conns) != 0 {
+		t.Errorf("Responses published on new connections")
+	}
+
+	conn.Close() // the client shall reconnect, and subscribe again
+	expect[*packets.Connect](t, broker)
+	if subscribe = expect[*packets.Subscribe](t, broker); len(subscribe.Subscriptions) != 2 {
+		t.Errorf("Not subscribed again after reconnect, got %s", subscribe)
+	}
+	publishMessage(publishChan, mqttResponse{"response/1", nil, `{"action":"get", "requestId":"1"}`})
+	expect[*packets.Publish](t, broker)
+}
+
+func TestRequestRoute(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	legacy := &paho.Publish{Payload: []byte(`{"topic":"response/2", "request":{"action":"get", "path":"Vehicle.Speed", "requestId":"2"}}`)}
+	route, request, err := getRequestRoute(legacy)
+	if err != nil || route.topic != "response/2" || request != `{"action":"get", "path":"Vehicle.Speed", "requestId":"2"}` {
+		t.Errorf("Unexpected route %+v of MQTT v3 request %s, err=%v", route, request, err)
+	}
+	for _, payload := range []string{`{"action":"get"}`, `{"topic":"response/2"}`, `{"topic":"response/2", "request":"get"}`, `nonsense`} {
+		if _, _, err := getRequestRoute(&paho.Publish{Payload: []byte(payload)}); err == nil {
+			t.Errorf("Malformed request %s accepted", payload)
+		}
+	}
+}
+
+func TestRouteCleanup(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	config := MqttConfig{SubscriptionTopics: true}
+	routeList = map[int]*mqttRoute{
+		1: {topic: "get"},
+		2: {topic: "subscribe", correlationData: []byte("c2")},
+		3: {topic: "unsubscribe"},
+		4: {topic: "failed"},
+	}
+	tests := []struct {
+		message string
+		topic   string
+		routes  int
+	}{
+		{`{"RouterId":"2?1", "action":"get", "requestId":"1", "data":{}}`, "get", 3},
+		{`{"RouterId":"2?2", "action":"subscribe", "requestId":"2", "subscriptionId":"7"}`, "subscribe", 3},
+		{`{"RouterId":"2?2", "action":"subscription", "subscriptionId":"7", "data":{}}`, "subscribe/7", 3},
+		{`{"RouterId":"2?4", "action":"get", "requestId":"4", "error":{"number":"404"}}`, "failed", 2},
+		{`{"RouterId":"2?3", "action":"unsubscribe", "requestId":"3", "subscriptionId":"7"}`, "unsubscribe", 0},
+	}
+	for _, test := range tests {
+		response, ok := routeResponse(config, test.message)
+		if !ok || response.topic != test.topic || len(routeList) != test.routes {
+			t.Errorf("%s: expected topic %s, and %d routes, got %s, and %d routes", test.message, test.topic, test.routes, response.topic, len(routeList))
+		}
+	}
+	if _, ok := routeResponse(config, `{"RouterId":"2?2", "action":"subscription", "subscriptionId":"7", "data":{}}`); ok {
+		t.Errorf("Notification routed after unsubscribe")
+	}
+}
+
+func TestVehicleRouting(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	if vin, err := extractVin(`{"RouterId":"2?0", "action":"get", "data":{"path":"Vehicle.VehicleIdentification.VIN", "dp":{"value":"VIN001", "ts":"2023-05-01T12:00:00Z"}}}`); err != nil || vin != "VIN001" {
+		t.Errorf("Expected VIN001, got %s, err=%v", vin, err)
+	}
+	if _, err := extractVin(`{"RouterId":"2?0", "action":"get", "error":{"number":"404", "reason":"unavailable_data"}}`); err == nil {
+		t.Errorf("VIN extracted from an error response")
+	}
+	if vin := getTopicVin(getVissV2Topic("VIN002")); vin != "VIN002" {
+		t.Errorf("Expected VIN002 of the request topic, got %s", vin)
+	}
+	transportMgrChan := make(chan string, 4)
+	AddRoutingInfoAndForward(`{"action":"get", "path":"Vehicle.Speed"}`, 2, 5, "VIN002", transportMgrChan)
+	AddRoutingInfoAndForward(`{"action":"get", "path":"Vehicle.Speed"}`, 2, 6, "", transportMgrChan)
+	for _, expected := range []string{`{ "RouterId":"2?5", "vin":"VIN002", "action":"get","path":"Vehicle.Speed"}`, `{ "RouterId":"2?6", "action":"get","path":"Vehicle.Speed"}`} {
+		if request := <-transportMgrChan; request != expected {
+			t.Errorf("Expected %s, got %s", expected, request)
+		}
+	}
+}
+
+func TestPayloadVinIgnored(t *testing.T) {
+	utils.InitLog("mqttmgr-log.txt", t.TempDir(), false, "error")
+	transportMgrChan := make(chan string, 2)
+	for _, vin := range []string{"VIN001", ""} {
+		request := `{"vin":"VIN003", "action":"set", "path":"Vehicle.Body.Trunk.IsOpen", "value":"true", "vin":"VIN002", "RouterId":"0?1"}`
+		if err := AddRoutingInfoAndForward(request, 2, 7, vin, transportMgrChan); err != nil {
+			t.Fatalf("AddRoutingInfoAndForward failed, err=%s", err)
+		}
+		var requestMap map[string]interface{}
+		forwarded := <-transportMgrChan
+		if utils.MapRequest(forwarded, &requestMap) != 0 {
+			t.Fatalf("Forwarded request %s is not valid JSON", forwarded)
+		}
+		if (len(vin) == 0 && requestMap["vin"] != nil) || (len(vin) > 0 && requestMap["vin"] != vin) || requestMap["RouterId"] != "2?7" {
+			t.Errorf("Topic VIN %q: the client request overrides the routing info, forwarded %s", vin, forwarded)
+		}
+	}
+	if err := AddRoutingInfoAndForward(`{"action":`, 2, 8, "VIN001", transportMgrChan); err == nil {
+		t.Errorf("Malformed request forwarded")
+	}
+}
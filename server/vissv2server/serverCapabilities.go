@@ -0,0 +1,108 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/w3c/automotive-viss2/server/vissv2server/mqttMgr"
+	"github.com/w3c/automotive-viss2/server/vissv2server/wsMgr"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// The reply to a dynamic-metadata filter with the parameter "server_capabilities".
+type ServerCapabilities struct {
+	Filter            []string              `json:"filter"`
+	AccessCtrl        []string              `json:"access_ctrl"`
+	TransportProtocol []string              `json:"transport_protocol"`
+	Transports        []TransportCapability `json:"transports"`
+	StateStorage      string                `json:"state_storage"`
+	History           bool                  `json:"history"`
+	Consent           bool                  `json:"consent"`
+	Limits            CapabilityLimits      `json:"limits"`
+}
+
+type TransportCapability struct {
+	Protocol    string   `json:"protocol"`
+	Port        string   `json:"port"`
+	Compression []string `json:"compression,omitempty"` // WS subprotocols, or the protobuf level of gRPC
+}
+
+type CapabilityLimits struct {
+	MaxTimebasedSubscriptions int `json:"max_timebased_subscriptions"` // includes history captures, 0 means no limit
+	MaxWsClients              int `json:"max_ws_clients"`
+	MaxPathsPerRequest        int `json:"max_paths_per_request"`
+}
+
+var serverCapabilities string
+
+/*
+* Generates the server capabilities from the components that are started, and the command line, server, and transport security configurations.
+* The server and transport security configurations must have been read before it is called.
+ */
+func buildServerCapabilities(components []string, stateStorage string, history bool, consent bool, maxTimers int) string {
+	capabilities := ServerCapabilities{
+		Filter:       []string{},
+		AccessCtrl:   []string{},
+		Transports:   []TransportCapability{},
+		StateStorage: stateStorage,
+		History:      history,
+		Consent:      consent,
+		Limits:       CapabilityLimits{maxTimers, 0, MAXFOUNDNODES},
+	}
+	for _, filter := range utils.FilterTypes() {
+		if filter != "history" || history {
+			capabilities.Filter = append(capabilities.Filter, filter)
+		}
+	}
+	isSecure := utils.SecureConfiguration.TransportSec == "yes"
+	for _, component := range components {
+		var transport TransportCapability
+		switch component {
+		case "httpMgr":
+			transport = TransportCapability{"http", listenerPort(utils.ServerConfiguration.Http, utils.SecureConfiguration.HttpSecPort), nil}
+			if isSecure {
+				transport.Protocol = "https"
+			}
+		case "wsMgr":
+			transport = TransportCapability{"ws", listenerPort(utils.ServerConfiguration.Ws, utils.SecureConfiguration.WsSecPort),
+				[]string{"VISSv2", "VISSv2prop", "VISSv2pbl1", "VISSv2pbl2"}}
+			if isSecure {
+				transport.Protocol = "wss"
+			}
+			capabilities.Limits.MaxWsClients = wsMgr.MaxClients()
+		case "mqttMgr":
+			protocol, port := mqttMgr.BrokerTransport() // port of the broker
+			transport = TransportCapability{protocol, port, nil}
+		case "grpcMgr":
+			transport = TransportCapability{"grpc", listenerPort(utils.ServerConfiguration.Grpc, utils.SecureConfiguration.GrpcSecPort), []string{"protobuf_level1"}}
+			if isSecure {
+				transport.Protocol = "grpcs"
+			}
+		case "atServer":
+			capabilities.AccessCtrl = []string{"short_term", "long_term", "signalset_claim"}
+			continue
+		default:
+			continue
+		}
+		capabilities.TransportProtocol = append(capabilities.TransportProtocol, transport.Protocol)
+		capabilities.Transports = append(capabilities.Transports, transport)
+	}
+	capabilitiesJson, err := json.Marshal(capabilities)
+	if err != nil {
+		utils.Error.Printf("buildServerCapabilities: Marshal failed, err=%s", err)
+		return ""
+	}
+	return string(capabilitiesJson)
+}
+
+// The port of a listener, or its unix socket address
+func listenerPort(listener utils.ListenerConfig, secPort string) string {
+	return utils.AddressPort(listener.ListenAddress(secPort))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func TestBuildServerCapabilities(t *testing.T) {
+	utils.SecureConfiguration = utils.SecConfig{TransportSec: "yes", HttpSecPort: "443", WsSecPort: "6443", GrpcSecPort: "5443"}
+	utils.ServerConfiguration.Grpc.TlsAddress = "unix:/run/vissv2/grpcs.sock"
+	defer func() {
+		utils.SecureConfiguration, utils.ServerConfiguration = utils.SecConfig{}, utils.DefaultServerConfig()
+	}()
+	var capabilities ServerCapabilities
+	err := json.Unmarshal([]byte(buildServerCapabilities([]string{"serviceMgr", "httpMgr", "wsMgr", "grpcMgr"}, "sqlite", false, true, 100)), &capabilities)
+	if err != nil {
+		t.Fatalf("Unmarshal failed, err=%s", err)
+	}
+	expectedProtocols := []string{"https", "wss", "grpcs"}
+	if len(capabilities.TransportProtocol) != len(expectedProtocols) {
+		t.Fatalf("Expected transport protocols %v, got %v", expectedProtocols, capabilities.TransportProtocol)
+	}
+	for i := range expectedProtocols {
+		if capabilities.TransportProtocol[i] != expectedProtocols[i] {
+			t.Errorf("Expected transport protocols %v, got %v", expectedProtocols, capabilities.TransportProtocol)
+		}
+	}
+	if capabilities.Transports[1].Port != "6443" {
+		t.Errorf("Expected WSS port 6443, got %s", capabilities.Transports[1].Port)
+	}
+	if capabilities.Transports[2].Port != "unix:/run/vissv2/grpcs.sock" {
+		t.Errorf("Expected the configured gRPC socket, got %s", capabilities.Transports[2].Port)
+	}
+	for _, filter := range capabilities.Filter {
+		if filter == "history" {
+			t.Errorf("History filter listed without history support")
+		}
+	}
+	if len(capabilities.AccessCtrl) != 0 {
+		t.Errorf("Access control listed without atServer, got %v", capabilities.AccessCtrl)
+	}
+	if capabilities.StateStorage != "sqlite" || capabilities.History || !capabilities.Consent || capabilities.Limits.MaxTimebasedSubscriptions != 100 || capabilities.Limits.MaxWsClients == 0 {
+		t.Errorf("Unexpected capabilities %+v", capabilities)
+	}
+}
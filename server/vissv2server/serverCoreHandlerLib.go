@@ -13,6 +13,7 @@ package main
 
 import (
 	"encoding/json"
+	"github.com/w3c/automotive-viss2/server/vissv2server/wsMgr"
 	"github.com/w3c/automotive-viss2/utils"
 	"net/http"
 )
@@ -23,14 +24,29 @@ import (
 func (pathList *PathList) VssPathListHandler(w http.ResponseWriter, r *http.Request) {
 	bytes, err := json.Marshal(pathList)
 	if err != nil {
-		utils.Error.Printf("problems with json.Marshal, ", err)
+		utils.Error.Print("problems with json.Marshal, ", err)
 		http.Error(w, "Unable to fetch vsspathlist", http.StatusInternalServerError)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(bytes)
 	truncatedIndex := min(len(bytes), 101)
-	utils.Info.Printf("initVssPathListServer():Response=%s...(truncated to %d bytes)", truncatedIndex-1, bytes[0:truncatedIndex])
+	utils.Info.Printf("initVssPathListServer():Response=%s...(truncated to %d bytes)", bytes[0:truncatedIndex], truncatedIndex-1)
+}
+
+/*
+* Handler for the notification queue metrics of the WS app clients, the clients without a session are left out.
+ */
+func ClientQueuesHandler(w http.ResponseWriter, r *http.Request) {
+	metrics := []utils.QueueMetrics{}
+	for _, clientMetrics := range wsMgr.QueueMetrics() {
+		if clientMetrics.Active || clientMetrics.Overflows > 0 {
+			metrics = append(metrics, clientMetrics)
+		}
+	}
+	bytes, _ := json.Marshal(map[string][]utils.QueueMetrics{"ws": metrics})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
 }
 
 func min(a, b int) int {
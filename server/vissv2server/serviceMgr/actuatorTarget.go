@@ -0,0 +1,126 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* Keeps the latest set value of each actuator as its desired data point, which clients read via the "desired" dynamic metadata domain.
+* A set request with a timeout is kept pending until the current values of all its paths equal the values that were set,
+* which is checked when the state storage reports a change, or until its timer expires.
+ */
+type ActuatorTargets struct {
+	lock      sync.Mutex
+	desired   map[string]string // path -> {"value":"Y", "ts":"Z"}
+	pending   []PendingSet
+	pendingId int
+}
+
+type PendingSet struct {
+	Id         int
+	RequestMap map[string]interface{}
+	Targets    []utils.PathValue // targets not yet reached
+	Timer      *time.Timer
+}
+
+var actuatorTargets *ActuatorTargets
+
+func NewActuatorTargets() *ActuatorTargets {
+	return &ActuatorTargets{desired: make(map[string]string)}
+}
+
+func (targets *ActuatorTargets) SetDesired(batch []utils.PathValue, ts string) {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+	for _, pathValue := range batch {
+		targets.desired[pathValue.Path] = `{"value":"` + pathValue.Value + `", "ts":"` + ts + `"}`
+	}
+}
+
+func (targets *ActuatorTargets) GetDesired(path string) string {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+	if dataPoint, ok := targets.desired[path]; ok {
+		return dataPoint
+	}
+	return `{"value":"Data-not-available", "ts":"` + utils.GetRfcTime() + `"}`
+}
+
+// Adds a set that waits for its targets. The id of the set is sent on timeoutChan when the timeout expires.
+func (targets *ActuatorTargets) AddPending(requestMap map[string]interface{}, batch []utils.PathValue, timeout time.Duration, timeoutChan chan int) {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+	targets.pendingId++
+	id := targets.pendingId
+	timer := time.AfterFunc(timeout, func() { timeoutChan <- id })
+	targets.pending = append(targets.pending, PendingSet{id, requestMap, batch, timer})
+}
+
+// Removes a pending set, and returns its request, or nil if it has already been completed.
+func (targets *ActuatorTargets) RemovePending(id int) map[string]interface{} {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+	for i := range targets.pending {
+		if targets.pending[i].Id == id {
+			requestMap := targets.pending[i].RequestMap
+			targets.pending[i].Timer.Stop()
+			targets.pending = append(targets.pending[:i], targets.pending[i+1:]...)
+			return requestMap
+		}
+	}
+	return nil
+}
+
+// Returns the requests of the pending sets whose targets have all been reached after a change of changedPath, and removes them.
+func (targets *ActuatorTargets) CheckPending(changedPath string) []map[string]interface{} {
+	targets.lock.Lock()
+	defer targets.lock.Unlock()
+	var completed []map[string]interface{}
+	for i := 0; i < len(targets.pending); i++ {
+		targets.pending[i].Targets = unreachedTargets(targets.pending[i].Targets, changedPath)
+		if len(targets.pending[i].Targets) == 0 {
+			targets.pending[i].Timer.Stop()
+			completed = append(completed, targets.pending[i].RequestMap)
+			targets.pending = append(targets.pending[:i], targets.pending[i+1:]...)
+			i--
+		}
+	}
+	return completed
+}
+
+// Returns the targets on changedPath, or on any path if AllPathsChanged, whose current value differs from the target value, and all other targets.
+func unreachedTargets(batch []utils.PathValue, changedPath string) []utils.PathValue {
+	var unreached []utils.PathValue
+	for _, pathValue := range batch {
+		if (changedPath == AllPathsChanged || pathValue.Path == changedPath) && getCurrentValue(pathValue.Path) == pathValue.Value {
+			continue
+		}
+		unreached = append(unreached, pathValue)
+	}
+	return unreached
+}
+
+func getCurrentValue(path string) string {
+	var dataPoint map[string]interface{}
+	if err := json.Unmarshal([]byte(getVehicleData(path)), &dataPoint); err != nil {
+		return ""
+	}
+	value, _ := dataPoint["value"].(string)
+	return value
+}
@@ -0,0 +1,67 @@
+package serviceMgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+type mapStorage map[string]string // path -> current value
+
+func (store mapStorage) Get(path string) string {
+	return `{"value":"` + store[path] + `", "ts":"2023-01-01T00:00:00Z"}`
+}
+func (store mapStorage) Set(path string, value string) string    { return "" }
+func (store mapStorage) SetBatch(batch []utils.PathValue) string { return "" }
+func (store mapStorage) Subscribe(changeChan chan string) error {
+	return ErrChangeSubscriptionNotSupported
+}
+func (store mapStorage) Close() {}
+
+func TestActuatorTargetsPending(t *testing.T) {
+	store := mapStorage{"Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature": "20", "Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed": "0"}
+	stateStorage = store
+	targets := NewActuatorTargets()
+	batch := []utils.PathValue{
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature", Value: "22"},
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed", Value: "40"},
+	}
+	targets.SetDesired(batch, "2023-01-01T00:00:01Z")
+	if dataPoint := targets.GetDesired(batch[0].Path); dataPoint != `{"value":"22", "ts":"2023-01-01T00:00:01Z"}` {
+		t.Errorf("Unexpected desired data point %s", dataPoint)
+	}
+	timeoutChan := make(chan int, 1)
+	targets.AddPending(map[string]interface{}{"requestId": "1"}, batch, time.Hour, timeoutChan)
+
+	store[batch[0].Path] = "22"
+	if completed := targets.CheckPending(batch[0].Path); len(completed) != 0 {
+		t.Fatalf("Set completed before all targets were reached")
+	}
+	store[batch[1].Path] = "40"
+	if completed := targets.CheckPending(batch[0].Path); len(completed) != 0 {
+		t.Fatalf("Set completed by a change of another path")
+	}
+	completed := targets.CheckPending(batch[1].Path)
+	if len(completed) != 1 || completed[0]["requestId"] != "1" {
+		t.Fatalf("Expected set 1 to complete, got %v", completed)
+	}
+	if targets.RemovePending(1) != nil {
+		t.Errorf("Completed set still pending")
+	}
+}
+
+func TestActuatorTargetsTimeout(t *testing.T) {
+	stateStorage = mapStorage{"Vehicle.Body.Trunk.Rear.IsOpen": "false"}
+	targets := NewActuatorTargets()
+	timeoutChan := make(chan int, 1)
+	targets.AddPending(map[string]interface{}{"requestId": "2"}, []utils.PathValue{{Path: "Vehicle.Body.Trunk.Rear.IsOpen", Value: "true"}}, 10*time.Millisecond, timeoutChan)
+	select {
+	case id := <-timeoutChan:
+		if requestMap := targets.RemovePending(id); requestMap == nil || requestMap["requestId"] != "2" {
+			t.Errorf("Expected timed out set 2, got %v", requestMap)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("No timeout received")
+	}
+}
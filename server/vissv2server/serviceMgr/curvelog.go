@@ -81,6 +81,9 @@ const MAXCLBUFSIZE = 240  // something large...
 const MAXCLSESSIONS = 100 // This value depends on the HW memory and performance
 var numOfClSessions int = 0
 
+// The signal dimension file, set from the server configuration
+var SignalDimensionFile = "signaldimension.json"
+
 func createRingBuffer(bufSize int) RingBuffer {
 	var aRingBuffer RingBuffer
 	aRingBuffer.bufSize = bufSize
@@ -228,7 +231,7 @@ func populateDimLists(paths []string) ([]string, []Dim2Elem, []Dim3Elem) {
 	var dim2List []Dim2Elem
 	var dim3List []Dim3Elem
 
-	signalDimensionList := readSignalDimensions("signaldimension.json")
+	signalDimensionList := readSignalDimensions(SignalDimensionFile)
 	pathDimList := analyzeSignalDimensions(paths, signalDimensionList)
 
 	for i := 0; i < len(paths); i++ {
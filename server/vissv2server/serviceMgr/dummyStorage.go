@@ -0,0 +1,91 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// Used when no DB is configured. Get returns a counter that counts from 0 to 999, wraps around, and is updated every 47 msec.
+// As the counter is shared by all paths, every update is reported as AllPathsChanged.
+type DummyStorage struct {
+	dummyValue int
+	valueLock  sync.Mutex
+	changeChan chan string
+	ticker     *time.Ticker
+	done       chan struct{}
+}
+
+func init() {
+	RegisterStateStorage("none", newDummyStorage)
+}
+
+func newDummyStorage(config StateStorageConfig) (StateStorage, error) {
+	store := &DummyStorage{ticker: time.NewTicker(47 * time.Millisecond), done: make(chan struct{})}
+	go store.dummyGenerator()
+	return store, nil
+}
+
+func (store *DummyStorage) dummyGenerator() {
+	for {
+		select {
+		case <-store.ticker.C:
+			store.valueLock.Lock()
+			store.dummyValue++
+			if store.dummyValue > 999 {
+				store.dummyValue = 0
+			}
+			changeChan := store.changeChan
+			store.valueLock.Unlock()
+			if changeChan != nil {
+				select {
+				case changeChan <- AllPathsChanged:
+				default: // previous notification not yet handled, it will see the latest value anyway
+				}
+			}
+		case <-store.done:
+			return
+		}
+	}
+}
+
+func (store *DummyStorage) Get(path string) string {
+	store.valueLock.Lock()
+	value := store.dummyValue
+	store.valueLock.Unlock()
+	return `{"value":"` + strconv.Itoa(value) + `", "ts":"` + utils.GetRfcTime() + `"}`
+}
+
+func (store *DummyStorage) Set(path string, value string) string {
+	return ""
+}
+
+func (store *DummyStorage) SetBatch(batch []utils.PathValue) string {
+	return ""
+}
+
+func (store *DummyStorage) Subscribe(changeChan chan string) error {
+	store.valueLock.Lock()
+	store.changeChan = changeChan
+	store.valueLock.Unlock()
+	return nil
+}
+
+func (store *DummyStorage) Close() {
+	store.ticker.Stop()
+	close(store.done)
+}
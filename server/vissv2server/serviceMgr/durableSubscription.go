@@ -0,0 +1,204 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* Durable subscriptions are detached instead of removed when the client session is lost, and are kept for the grace period.
+* The notifications of a detached subscription are buffered, up to MaxMissed per subscription, oldest dropped first.
+* A client resumes a subscription with a subscribe request containing the resume token of the subscribe response,
+* which reattaches it to the new session, and sends the buffered notifications.
+* If File is set, then the subscriptions are saved in it, and restored as detached when the server is restarted.
+* A zero Grace disables durable subscriptions.
+ */
+type DurableSubscriptionConfig struct {
+	Grace     time.Duration
+	MaxMissed int
+	File      string
+}
+
+// The saved state of a subscription.
+type DurableSubscription struct {
+	SubscriptionId  int                  `json:"subscriptionId"`
+	RouterId        string               `json:"routerId"`
+	Path            []string             `json:"path"`
+	FilterList      []utils.FilterObject `json:"filterList"`
+	LatestDataPoint string               `json:"latestDataPoint"`
+	GatingId        string               `json:"gatingId,omitempty"`
+	ResumeToken     string               `json:"resumeToken"`
+	Missed          []string             `json:"missed,omitempty"`
+	Vin             string               `json:"vin,omitempty"`
+}
+
+var durableConfig DurableSubscriptionConfig
+var durableDirty bool // the subscriptions have changed since they were saved
+
+func newResumeToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		utils.Error.Printf("newResumeToken: rand.Read failed, err=%s", err)
+		return ""
+	}
+	return hex.EncodeToString(token)
+}
+
+// Sends the notification, or buffers it if the subscription is detached.
+func deliverNotification(subscriptionState *SubscriptionState, notification string, backendChan chan string) {
+	if subscriptionState.DetachedAt.IsZero() {
+		backendChan <- notification
+		return
+	}
+	subscriptionState.Missed = append(subscriptionState.Missed, notification)
+	if durableConfig.MaxMissed > 0 && len(subscriptionState.Missed) > durableConfig.MaxMissed {
+		subscriptionState.Missed = subscriptionState.Missed[len(subscriptionState.Missed)-durableConfig.MaxMissed:]
+	}
+	durableDirty = true
+}
+
+// Detaches the subscriptions of a lost client session, and returns the number of them.
+func detachSubscriptions(subscriptionList []SubscriptionState, routerId string, now time.Time) int {
+	detached := 0
+	for i := range subscriptionList {
+		if subscriptionList[i].RouterId == routerId && subscriptionList[i].DetachedAt.IsZero() {
+			subscriptionList[i].DetachedAt = now
+			detached++
+		}
+	}
+	if detached > 0 {
+		durableDirty = true
+	}
+	return detached
+}
+
+/*
+* Reattaches the detached subscription with the resume token to the client session of routerId, and returns its index, and the buffered notifications
+* rerouted to the session. The index is -1 if there is no detached subscription with the token, as an attached subscription must not be taken over.
+ */
+func resumeSubscription(subscriptionList []SubscriptionState, resumeToken string, routerId string) (int, []string) {
+	for i := range subscriptionList {
+		if len(resumeToken) == 0 || subscriptionList[i].ResumeToken != resumeToken || subscriptionList[i].DetachedAt.IsZero() {
+			continue
+		}
+		missed := make([]string, len(subscriptionList[i].Missed))
+		for j, notification := range subscriptionList[i].Missed {
+			missed[j] = strings.Replace(notification, `"RouterId":"`+subscriptionList[i].RouterId+`"`, `"RouterId":"`+routerId+`"`, 1)
+		}
+		subscriptionList[i].RouterId = routerId
+		subscriptionList[i].DetachedAt = time.Time{}
+		subscriptionList[i].Missed = nil
+		durableDirty = true
+		return i, missed
+	}
+	return -1, nil
+}
+
+// Removes the subscriptions that have been detached for longer than the grace period.
+func expireDetachedSubscriptions(subscriptionList []SubscriptionState, now time.Time) []SubscriptionState {
+	for i := 0; i < len(subscriptionList); {
+		if !subscriptionList[i].DetachedAt.IsZero() && now.Sub(subscriptionList[i].DetachedAt) > durableConfig.Grace {
+			utils.Info.Printf("expireDetachedSubscriptions: subscription %d was not resumed", subscriptionList[i].SubscriptionId)
+			length := len(subscriptionList)
+			_, subscriptionList = deactivateSubscription(subscriptionList, strconv.Itoa(subscriptionList[i].SubscriptionId))
+			durableDirty = true
+			if len(subscriptionList) < length { // removed, the last element has been moved to index i
+				continue
+			}
+			subscriptionList[i].DetachedAt = time.Time{} // curvelog, removed when its threads have terminated
+			subscriptionList[i].ResumeToken = ""
+			subscriptionList[i].Missed = nil
+		}
+		i++
+	}
+	return subscriptionList
+}
+
+func saveDurableSubscriptions(subscriptionList []SubscriptionState) {
+	if len(durableConfig.File) == 0 || !durableDirty {
+		return
+	}
+	saved := make([]DurableSubscription, len(subscriptionList))
+	for i, state := range subscriptionList {
+		saved[i] = DurableSubscription{state.SubscriptionId, state.RouterId, state.Path, state.FilterList, state.LatestDataPoint, state.GatingId,
+			state.ResumeToken, state.Missed, state.Vin}
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		utils.Error.Printf("saveDurableSubscriptions: Marshal failed, err=%s", err)
+		return
+	}
+	tmpFile := durableConfig.File + ".tmp" // a crash while writing must not destroy the saved subscriptions
+	if err = os.WriteFile(tmpFile, data, 0600); err == nil {
+		err = os.Rename(tmpFile, durableConfig.File)
+	}
+	if err != nil {
+		utils.Error.Printf("saveDurableSubscriptions: Could not save to %s, err=%s", durableConfig.File, err)
+		return
+	}
+	durableDirty = false
+}
+
+// Reads the saved subscriptions. They are returned as detached at now, as their client sessions did not survive the restart.
+func loadDurableSubscriptions(now time.Time) []SubscriptionState {
+	if len(durableConfig.File) == 0 || !utils.FileExists(durableConfig.File) {
+		return nil
+	}
+	data, err := os.ReadFile(durableConfig.File)
+	if err != nil {
+		utils.Error.Printf("loadDurableSubscriptions: Could not read %s, err=%s", durableConfig.File, err)
+		return nil
+	}
+	var saved []DurableSubscription
+	if err = json.Unmarshal(data, &saved); err != nil {
+		utils.Error.Printf("loadDurableSubscriptions: Unmarshal of %s failed, err=%s", durableConfig.File, err)
+		return nil
+	}
+	subscriptionList := make([]SubscriptionState, 0, len(saved))
+	for _, subscription := range saved {
+		subscriptionList = append(subscriptionList, SubscriptionState{SubscriptionId: subscription.SubscriptionId, RouterId: subscription.RouterId,
+			Path: subscription.Path, FilterList: subscription.FilterList, LatestDataPoint: subscription.LatestDataPoint, GatingId: subscription.GatingId,
+			ResumeToken: subscription.ResumeToken, DetachedAt: now, Missed: subscription.Missed, Vin: subscription.Vin})
+	}
+	return subscriptionList
+}
+
+/*
+* Restores the saved subscriptions, and reactivates their timebased or curvelog filters.
+* Returns the restored subscriptions, and the subscription id to continue from.
+ */
+func restoreDurableSubscriptions(subscriptionChan chan int, nextSubscriptionId int) ([]SubscriptionState, int) {
+	restored := []SubscriptionState{}
+	for _, subscriptionState := range loadDurableSubscriptions(time.Now()) {
+		if activateIfIntervalOrCL(subscriptionState.FilterList, subscriptionChan, CLChannel, subscriptionState.SubscriptionId, subscriptionState.Path) != nil {
+			continue
+		}
+		restored = append(restored, subscriptionState)
+		if subscriptionState.SubscriptionId >= nextSubscriptionId {
+			nextSubscriptionId = subscriptionState.SubscriptionId + 1
+		}
+	}
+	if len(restored) > 0 {
+		utils.Info.Printf("restoreDurableSubscriptions: %d subscriptions restored", len(restored))
+	}
+	return restored, nextSubscriptionId
+}
@@ -0,0 +1,84 @@
+package serviceMgr
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func TestDurableSubscriptionResume(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	durableConfig = DurableSubscriptionConfig{Grace: time.Minute, MaxMissed: 2}
+	changeFilter := []utils.FilterObject{{Type: "change", Parameter: `{"logic-op":"ne", "diff":"0"}`}}
+	subscriptionList := []SubscriptionState{
+		{SubscriptionId: 1, RouterId: "1?3", Path: []string{"Vehicle.Speed"}, FilterList: changeFilter, ResumeToken: "token1"},
+		{SubscriptionId: 2, RouterId: "1?4", Path: []string{"Vehicle.Speed"}, FilterList: changeFilter, ResumeToken: "token2"},
+	}
+	detachedAt := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	if detached := detachSubscriptions(subscriptionList, "1?3", detachedAt); detached != 1 {
+		t.Fatalf("Expected one detached subscription, got %d", detached)
+	}
+	backendChan := make(chan string, 10)
+	for _, value := range []string{"1", "2", "3"} {
+		deliverNotification(&subscriptionList[0], `{"RouterId":"1?3","action":"subscription","subscriptionId":"1", "data":{"value":"`+value+`"}}`, backendChan)
+	}
+	deliverNotification(&subscriptionList[1], `{"RouterId":"1?4","action":"subscription","subscriptionId":"2"}`, backendChan)
+	if len(backendChan) != 1 {
+		t.Errorf("Expected only the notification of the attached subscription to be sent, %d sent", len(backendChan))
+	}
+
+	if index, _ := resumeSubscription(subscriptionList, "unknown", "1?5"); index != -1 {
+		t.Errorf("Resumed with an unknown token")
+	}
+	if index, _ := resumeSubscription(subscriptionList, "token2", "1?5"); index != -1 || subscriptionList[1].RouterId != "1?4" {
+		t.Errorf("Attached subscription taken over by another client session")
+	}
+	index, missed := resumeSubscription(subscriptionList, "token1", "1?5")
+	if index != 0 || len(missed) != 2 {
+		t.Fatalf("Expected index 0 and 2 buffered notifications, got %d and %d", index, len(missed))
+	}
+	if !strings.Contains(missed[0], `"RouterId":"1?5"`) || !strings.Contains(missed[0], `"value":"2"`) || !strings.Contains(missed[1], `"value":"3"`) {
+		t.Errorf("Buffered notifications not rerouted, or oldest not dropped: %v", missed)
+	}
+	if !subscriptionList[0].DetachedAt.IsZero() || subscriptionList[0].RouterId != "1?5" || len(subscriptionList[0].Missed) != 0 {
+		t.Errorf("Subscription not reattached: %+v", subscriptionList[0])
+	}
+	if index, _ := resumeSubscription(subscriptionList, "token1", "1?6"); index != -1 || subscriptionList[0].RouterId != "1?5" {
+		t.Errorf("Resumed subscription taken over by another client session")
+	}
+
+	detachSubscriptions(subscriptionList, "1?4", detachedAt)
+	subscriptionList = expireDetachedSubscriptions(subscriptionList, detachedAt.Add(30*time.Second))
+	if len(subscriptionList) != 2 {
+		t.Errorf("Subscription expired within the grace period")
+	}
+	subscriptionList = expireDetachedSubscriptions(subscriptionList, detachedAt.Add(2*time.Minute))
+	if len(subscriptionList) != 1 || subscriptionList[0].SubscriptionId != 1 {
+		t.Errorf("Expected only subscription 1 to remain, got %+v", subscriptionList)
+	}
+}
+
+func TestDurableSubscriptionSave(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	durableConfig = DurableSubscriptionConfig{Grace: time.Minute, File: filepath.Join(t.TempDir(), "subscriptions.json")}
+	changeFilter := []utils.FilterObject{{Type: "change", Parameter: `{"logic-op":"ne", "diff":"0"}`}}
+	subscriptionList := []SubscriptionState{{SubscriptionId: 7, RouterId: "1?3", Path: []string{"Vehicle.Speed"}, FilterList: changeFilter,
+		LatestDataPoint: `{"value":"50", "ts":"2023-05-01T12:00:00Z"}`, ResumeToken: "token7", Missed: []string{`{"RouterId":"1?3"}`}}}
+	durableDirty = true
+	saveDurableSubscriptions(subscriptionList)
+	if durableDirty {
+		t.Errorf("Subscriptions still dirty after save")
+	}
+	restored, nextSubscriptionId := restoreDurableSubscriptions(make(chan int), 1)
+	if len(restored) != 1 || nextSubscriptionId != 8 {
+		t.Fatalf("Expected one restored subscription, and next id 8, got %d and %d", len(restored), nextSubscriptionId)
+	}
+	subscription := restored[0]
+	if subscription.DetachedAt.IsZero() || subscription.ResumeToken != "token7" || subscription.LatestDataPoint != subscriptionList[0].LatestDataPoint ||
+		len(subscription.Missed) != 1 || subscription.FilterList[0] != changeFilter[0] {
+		t.Errorf("Subscription not restored as detached: %+v", subscription)
+	}
+}
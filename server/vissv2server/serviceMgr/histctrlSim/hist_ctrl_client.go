@@ -36,14 +36,17 @@ func main() {
 		switch command[0] {
 		case 'c':
 			fallthrough
-		case 'C': // {"action":"create", "path": X, "buf-size":"Y"}
+		case 'C': // {"action":"create", "path": X, "buf-size":"Y", "retention":"R"}
 			var path string
 			var bufSize string
+			var retention string
 			fmt.Printf("Path=")
 			fmt.Scanf("%s\n", &path)
 			fmt.Printf("Buffer size=")
 			fmt.Scanf("%s\n", &bufSize)
-			payLoad = `{"action": "create", "path":"` + path + `", "buf-size":"` + bufSize + `"}`
+			fmt.Printf("Retention (ISO 8601 duration, e.g. P7D)=")
+			fmt.Scanf("%s\n", &retention)
+			payLoad = `{"action": "create", "path":"` + path + `", "buf-size":"` + bufSize + `", "retention":"` + retention + `"}`
 		case 's':
 			fallthrough
 		case 'S': // {"action":"start", "path": X, "frequency":"Z"}
@@ -0,0 +1,164 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+* A history storage keeps the recording configurations set by the history control commands, and the samples captured by them.
+* The implementation is selected at startup by the --historystorage name it is registered under.
+* Query returns the samples of a path with a time within [start, end], oldest first, and Count returns the number of samples of a path.
+* Prune deletes the samples of a recording that are older than its retention, or that exceed its buffer size, oldest first.
+* DeleteRecording deletes both the configuration and the samples of a path.
+ */
+type HistoryStorage interface {
+	SaveRecording(recording HistoryRecording) error
+	LoadRecordings() ([]HistoryRecording, error)
+	DeleteRecording(path string) error
+	Append(path string, sample HistorySample) error
+	Query(path string, start time.Time, end time.Time) ([]HistorySample, error)
+	Count(path string) (int, error)
+	Prune(recording HistoryRecording, now time.Time) error
+	Close()
+}
+
+type HistoryRecording struct {
+	Path      string
+	Frequency int           // captures per hour, 0 if not started
+	BufSize   int           // max number of samples kept, 0 means no limit
+	Retention time.Duration // max age of samples kept, 0 means no limit
+	Active    bool
+}
+
+type HistorySample struct {
+	Value string
+	Ts    string    // as read from the state storage
+	Time  time.Time // Ts parsed, or the capture time if Ts could not be parsed
+}
+
+type HistoryStorageConfig struct {
+	DbFile string // only used by file based storages, e.g. sqlite
+}
+
+type HistoryStorageFactory func(config HistoryStorageConfig) (HistoryStorage, error)
+
+var historyStorageRegistry = map[string]HistoryStorageFactory{}
+var historyStorageRegistryLock sync.Mutex
+
+// Makes a history storage implementation selectable by name. Typically called from an init() function.
+func RegisterHistoryStorage(name string, factory HistoryStorageFactory) {
+	historyStorageRegistryLock.Lock()
+	defer historyStorageRegistryLock.Unlock()
+	if _, exists := historyStorageRegistry[name]; exists {
+		panic("RegisterHistoryStorage: called twice for " + name)
+	}
+	historyStorageRegistry[name] = factory
+}
+
+// Returns the sorted names of all registered history storage implementations.
+func HistoryStorageNames() []string {
+	historyStorageRegistryLock.Lock()
+	defer historyStorageRegistryLock.Unlock()
+	names := make([]string, 0, len(historyStorageRegistry))
+	for name := range historyStorageRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func openHistoryStorage(name string, config HistoryStorageConfig) (HistoryStorage, error) {
+	historyStorageRegistryLock.Lock()
+	factory, exists := historyStorageRegistry[name]
+	historyStorageRegistryLock.Unlock()
+	if !exists {
+		return nil, errors.New("unknown history storage type = " + name)
+	}
+	return factory(config)
+}
+
+// Parses the ts of a data point, which is RFC3339, or epoch msecs for Apache IoTDB.
+func parseDpTs(ts string) (time.Time, error) {
+	dpTime, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		msecs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.New("could not parse ts=" + ts)
+		}
+		dpTime = time.UnixMilli(msecs)
+	}
+	return dpTime, nil
+}
+
+/*
+* Reduces the samples to one per interval, where the intervals are aligned to start.
+* first/last select the first/last sample of an interval. min/max select the sample with the lowest/highest numeric value,
+* and avg returns the mean value with the ts of the interval start. If a value of an interval is not numeric, then the last sample is selected.
+ */
+func downsampleHistory(samples []HistorySample, start time.Time, interval time.Duration, aggregate string) []HistorySample {
+	var downsampled []HistorySample
+	for first := 0; first < len(samples); {
+		bucket := samples[first].Time.Sub(start) / interval
+		last := first
+		for last+1 < len(samples) && samples[last+1].Time.Sub(start)/interval == bucket {
+			last++
+		}
+		downsampled = append(downsampled, aggregateHistory(samples[first:last+1], start.Add(bucket*interval), aggregate))
+		first = last + 1
+	}
+	return downsampled
+}
+
+func aggregateHistory(bucket []HistorySample, bucketStart time.Time, aggregate string) HistorySample {
+	if aggregate == "first" {
+		return bucket[0]
+	}
+	if aggregate == "last" {
+		return bucket[len(bucket)-1]
+	}
+	values := make([]float64, len(bucket))
+	for i := range bucket {
+		value, err := strconv.ParseFloat(bucket[i].Value, 64)
+		if err != nil {
+			return bucket[len(bucket)-1]
+		}
+		values[i] = value
+	}
+	selected := 0
+	sum := 0.0
+	for i := range values {
+		sum += values[i]
+		if (aggregate == "min" && values[i] < values[selected]) || (aggregate == "max" && values[i] > values[selected]) {
+			selected = i
+		}
+	}
+	if aggregate == "avg" {
+		bucketStart = bucketStart.UTC()
+		return HistorySample{strconv.FormatFloat(sum/float64(len(values)), 'g', -1, 64), bucketStart.Format(time.RFC3339Nano), bucketStart}
+	}
+	return bucket[selected]
+}
+
+// Keeps the most recent bufSize samples, if bufSize is not zero.
+func limitHistory(samples []HistorySample, bufSize int) []HistorySample {
+	if bufSize > 0 && len(samples) > bufSize {
+		return samples[len(samples)-bufSize:]
+	}
+	return samples
+}
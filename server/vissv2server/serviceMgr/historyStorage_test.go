@@ -0,0 +1,257 @@
+package serviceMgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+const historyTestPath = "Vehicle.Powertrain.TractionBattery.StateOfCharge.Current"
+
+func historySamples(start time.Time, interval time.Duration, values ...string) []HistorySample {
+	samples := make([]HistorySample, len(values))
+	for i := range values {
+		sampleTime := start.Add(time.Duration(i) * interval)
+		samples[i] = HistorySample{values[i], sampleTime.Format(time.RFC3339), sampleTime}
+	}
+	return samples
+}
+
+func sampleValues(samples []HistorySample) []string {
+	values := make([]string, len(samples))
+	for i := range samples {
+		values[i] = samples[i].Value
+	}
+	return values
+}
+
+func equalValues(values []string, expected ...string) bool {
+	if len(values) != len(expected) {
+		return false
+	}
+	for i := range values {
+		if values[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHistoryStorage(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	dbFile := filepath.Join(t.TempDir(), "history.db")
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range HistoryStorageNames() {
+		store, err := openHistoryStorage(name, HistoryStorageConfig{DbFile: dbFile})
+		if err != nil {
+			t.Fatalf("%s: could not open history storage, err=%s", name, err)
+		}
+		recording := HistoryRecording{Path: historyTestPath, Frequency: 60, BufSize: 4, Retention: 3 * time.Hour, Active: true}
+		if err = store.SaveRecording(recording); err != nil {
+			t.Fatalf("%s: SaveRecording failed, err=%s", name, err)
+		}
+		for _, sample := range historySamples(start, time.Hour, "80", "79", "78", "77", "76") {
+			if err = store.Append(historyTestPath, sample); err != nil {
+				t.Fatalf("%s: Append failed, err=%s", name, err)
+			}
+		}
+		samples, _ := store.Query(historyTestPath, start.Add(time.Hour), start.Add(3*time.Hour))
+		if values := sampleValues(samples); !equalValues(values, "79", "78", "77") {
+			t.Errorf("%s: Query returned %v", name, values)
+		}
+
+		store.Prune(HistoryRecording{Path: historyTestPath, BufSize: 4}, start.Add(4*time.Hour))
+		samples, _ = store.Query(historyTestPath, start, start.Add(4*time.Hour))
+		if values := sampleValues(samples); !equalValues(values, "79", "78", "77", "76") {
+			t.Errorf("%s: after buf-size pruning %v", name, values)
+		}
+		store.Prune(recording, start.Add(5*time.Hour)) // keeps samples of the last three hours
+		samples, _ = store.Query(historyTestPath, start, start.Add(4*time.Hour))
+		if values := sampleValues(samples); !equalValues(values, "78", "77", "76") {
+			t.Errorf("%s: after retention pruning %v", name, values)
+		}
+
+		if name == "sqlite" { // survives a restart
+			store.Close()
+			if store, err = openHistoryStorage(name, HistoryStorageConfig{DbFile: dbFile}); err != nil {
+				t.Fatalf("%s: could not reopen history storage, err=%s", name, err)
+			}
+			samples, _ = store.Query(historyTestPath, start, start.Add(4*time.Hour))
+			if values := sampleValues(samples); !equalValues(values, "78", "77", "76") {
+				t.Errorf("%s: after reopen %v", name, values)
+			}
+		}
+		recordings, _ := store.LoadRecordings()
+		if len(recordings) != 1 || recordings[0] != recording {
+			t.Errorf("%s: LoadRecordings returned %+v", name, recordings)
+		}
+
+		store.DeleteRecording(historyTestPath)
+		samples, _ = store.Query(historyTestPath, start, start.Add(4*time.Hour))
+		recordings, _ = store.LoadRecordings()
+		if len(samples) != 0 || len(recordings) != 0 {
+			t.Errorf("%s: %d samples and %d recordings left after DeleteRecording", name, len(samples), len(recordings))
+		}
+		store.Close()
+	}
+}
+
+func TestDownsampleHistory(t *testing.T) {
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	samples := historySamples(start.Add(10*time.Minute), 20*time.Minute, "1", "4", "7", "2", "6")
+	tests := []struct {
+		aggregate string
+		expected  []string
+	}{
+		{"first", []string{"1", "7", "6"}},
+		{"last", []string{"4", "2", "6"}},
+		{"min", []string{"1", "2", "6"}},
+		{"max", []string{"4", "7", "6"}},
+		{"avg", []string{"2.5", "4.5", "6"}},
+	}
+	for _, test := range tests {
+		downsampled := downsampleHistory(samples, start, 40*time.Minute, test.aggregate)
+		if values := sampleValues(downsampled); !equalValues(values, test.expected...) {
+			t.Errorf("%s: expected %v, got %v", test.aggregate, test.expected, values)
+		}
+	}
+	avg := downsampleHistory(samples, start, 40*time.Minute, "avg")
+	for i := range avg {
+		if expectedTs := start.Add(time.Duration(i) * 40 * time.Minute).Format(time.RFC3339); avg[i].Ts != expectedTs {
+			t.Errorf("avg: bucket %d has ts %s, expected %s", i, avg[i].Ts, expectedTs)
+		}
+	}
+	nonNumeric := historySamples(start, time.Minute, "OPEN", "CLOSED")
+	if values := sampleValues(downsampleHistory(nonNumeric, start, time.Hour, "avg")); !equalValues(values, "CLOSED") {
+		t.Errorf("avg of non-numeric values: %v", values)
+	}
+}
+
+func TestHistoryControl(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	historyStorage, _ = openHistoryStorage("memory", HistoryStorageConfig{})
+	scheduler = NewScheduler(0)
+	defer scheduler.Stop()
+	createHistoryList([]byte(`{"LeafPaths":["` + historyTestPath + `"]}`))
+	historyChan := make(chan int, 10)
+	control := func(command string) string {
+		return processHistoryCtrl(command, historyChan, true)
+	}
+	if response := control(`{"action":"list","path":"` + historyTestPath + `"}`); response != "404 Not Found" {
+		t.Errorf("list before create: %s", response)
+	}
+	if response := control(`{"action":"start","path":"` + historyTestPath + `","frequency":"60"}`); response != "409 Conflict" {
+		t.Errorf("start before create: %s", response)
+	}
+	if response := control(`{"action":"create","path":"Vehicle.Unknown"}`); response != "404 Not Found" {
+		t.Errorf("create of unknown path: %s", response)
+	}
+	control(`{"action":"create","path":"` + historyTestPath + `","buf-size":"4","retention":"PT3H"}`)
+	control(`{"action":"start","path":"` + historyTestPath + `","frequency":"60"}`)
+	historyStorage.Append(historyTestPath, historySamples(time.Now(), time.Second, "80")[0])
+	var capture HistoryCapture
+	json.Unmarshal([]byte(control(`{"action":"list","path":"`+historyTestPath+`"}`)), &capture)
+	expected := HistoryCapture{historyTestPath, "recording", "60", "4", "PT3H", "1", "25%"}
+	if capture != expected {
+		t.Errorf("list while recording: expected %+v, got %+v", expected, capture)
+	}
+	if response := control(`{"action":"delete","path":"` + historyTestPath + `"}`); response != "409 Conflict" {
+		t.Errorf("delete while recording: %s", response)
+	}
+	control(`{"action":"stop","path":"` + historyTestPath + `"}`)
+	json.Unmarshal([]byte(control(`{"action":"list","path":"`+historyTestPath+`"}`)), &capture)
+	if capture.Status != "stopped" {
+		t.Errorf("list after stop: status=%s", capture.Status)
+	}
+	if response := control(`{"action":"delete","path":"` + historyTestPath + `"}`); response != "200 OK" {
+		t.Errorf("delete: %s", response)
+	}
+	if count, _ := historyStorage.Count(historyTestPath); count != 0 {
+		t.Errorf("%d samples left after delete", count)
+	}
+}
+
+func TestConcurrentHistoryControl(t *testing.T) {
+	controlChannel := make(chan historyCtrlRequest)
+	historyControlChannel = controlChannel
+	done := make(chan struct{})
+	defer close(done)
+	go func() { // the history server, which echoes the requests
+		for {
+			select {
+			case histCtrlReq := <-controlChannel:
+				histCtrlReq.reply <- "response to " + histCtrlReq.request
+			case <-done:
+				return
+			}
+		}
+	}()
+	errs := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		go func(request string) {
+			if response := requestHistoryControl(request); response != "response to "+request {
+				errs <- request + " got " + response
+				return
+			}
+			errs <- ""
+		}(strconv.Itoa(i))
+	}
+	for i := 0; i < 20; i++ {
+		if err := <-errs; len(err) > 0 {
+			t.Errorf("Request %s", err)
+		}
+	}
+}
+
+type countingHistoryStorage struct {
+	HistoryStorage
+	prunes int
+}
+
+func (store *countingHistoryStorage) Prune(recording HistoryRecording, now time.Time) error {
+	store.prunes++
+	return store.HistoryStorage.Prune(recording, now)
+}
+
+type tickingStorage struct {
+	mapStorage
+	ticks int
+}
+
+func (store *tickingStorage) Get(path string) string { // a new sample at each get
+	store.ticks++
+	return `{"value":"` + strconv.Itoa(store.ticks) + `", "ts":"2023-01-01T00:00:` + fmt.Sprintf("%02d", store.ticks) + `Z"}`
+}
+
+func TestHistoryPruneInterval(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	memoryStorage, _ := openHistoryStorage("memory", HistoryStorageConfig{})
+	store := &countingHistoryStorage{HistoryStorage: memoryStorage}
+	historyStorage = store
+	stateStorage = &tickingStorage{}
+	createHistoryList([]byte(`{"LeafPaths":["` + historyTestPath + `"]}`))
+	historyList[0].Created = true
+	historyList[0].BufSize = 50
+	for i := 0; i < 20; i++ {
+		captureHistoryValue(0)
+	}
+	if store.prunes != 4 { // at the first capture, and after each five
+		t.Errorf("Expected 4 prunes of 20 captures with buf-size 50, got %d", store.prunes)
+	}
+	historyList[0].BufSize = 10
+	request, _ := json.Marshal(HistoryGetRequest{historyTestPath, `{"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:01:00Z"}`})
+	if samples := processHistoryGet(string(request)); strings.Count(samples, "value") != 10 {
+		t.Errorf("History not pruned before it is read, got %s", samples)
+	}
+	for bufSize, interval := range map[int]int{0: 100, 5: 1, 50: 5, 5000: 100} {
+		if historyPruneInterval(bufSize) != interval {
+			t.Errorf("Prune interval of buf-size %d: expected %d, got %d", bufSize, interval, historyPruneInterval(bufSize))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/iotdb-client-go/client"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// Apache IoTDB
+var IoTDbPrefixPath string = "root.test2.dev1" // DB prefix used for get/set
+var IoTDbTimeout int64 = 3000
+var IoTDBconfig = &client.Config{
+	//	Host:     "127.0.0.1",
+	Host:     "iotdb-service",
+	Port:     "6667",
+	UserName: "root",
+	Password: "root",
+}
+
+type IoTDBStorage struct {
+	session client.Session
+}
+
+func init() {
+	RegisterStateStorage("apache-iotdb", newIoTDBStorage)
+}
+
+func newIoTDBStorage(config StateStorageConfig) (StateStorage, error) {
+	utils.Info.Printf("IoTDB: creating new session with host:%v port:%v user:%v pass:%v", IoTDBconfig.Host, IoTDBconfig.Port, IoTDBconfig.UserName, IoTDBconfig.Password)
+	session := client.NewSession(IoTDBconfig)
+	if err := session.Open(false, 0); err != nil {
+		return nil, err
+	}
+	return &IoTDBStorage{session: session}, nil
+}
+
+func (store *IoTDBStorage) Get(path string) string {
+	var (
+		// Back-quote the VSS node for the DB query, e.g. `Vehicle.CurrentLocation.Longitude`
+		selectLastSQL = fmt.Sprintf("select last `%v` from %v", path, IoTDbPrefixPath)
+		value         = ""
+		ts            = ""
+	)
+	//		utils.Info.Printf("IoTDB: query using: %v", selectLastSQL)
+	sessionDataSet, err := store.session.ExecuteQueryStatement(selectLastSQL, &IoTDbTimeout)
+	if err == nil {
+		var success bool
+		success, err = sessionDataSet.Next()
+		if err == nil && success {
+			value = sessionDataSet.GetText("Value")
+			ts = sessionDataSet.GetText(client.TimestampColumnName)
+			//				utils.Info.Printf("IoTDB: get returned: ts=%v, Value=%v", ts, value)
+		}
+		sessionDataSet.Close()
+	} else {
+		utils.Error.Printf("IoTDB: Query failed with error=%s", err)
+		return `{"value":"Data-not-found", "ts":"` + utils.GetRfcTime() + `"}`
+	}
+	return `{"value":"` + value + `", "ts":"` + ts + `"}`
+}
+
+func (store *IoTDBStorage) Set(path string, value string) string {
+	return store.insertRecord([]string{"`" + path + "`"}, []string{value}) // Back-quote the VSS node for the DB insert, e.g. `Vehicle.CurrentLocation.Longitude`
+}
+
+// The batch is inserted as one record, which IoTDB writes atomically.
+func (store *IoTDBStorage) SetBatch(batch []utils.PathValue) string {
+	vssKey := make([]string, len(batch))
+	vssValue := make([]string, len(batch))
+	for i, pathValue := range batch {
+		vssKey[i] = "`" + pathValue.Path + "`"
+		vssValue[i] = pathValue.Value
+	}
+	return store.insertRecord(vssKey, vssValue)
+}
+
+func (store *IoTDBStorage) insertRecord(vssKey []string, vssValue []string) string {
+	ts := utils.GetRfcTime()
+	IoTDBts := time.Now().UTC().UnixNano() / 1000000
+
+	// IoTDB will automatically convert the value string to the native data type in the timeseries schema for basic types
+	//		utils.Info.Printf("IoTDB: DB insert with prefixPath: %v vssKey: %v, vssValue: %v, ts: %v", IoTDbPrefixPath, vssKey, vssValue, IoTDBts)
+	if status, err := store.session.InsertStringRecord(IoTDbPrefixPath, vssKey, vssValue, IoTDBts); err != nil {
+		utils.Error.Printf("IoTDB: DB insert using InsertStringRecord failed with: %v", err)
+		return ""
+	} else {
+		if status != nil {
+			if err = client.VerifySuccess(status); err != nil {
+				utils.Error.Printf("IoTDB: DB insert Verify failed with: %v", err)
+				return ""
+			}
+		}
+	}
+	return ts
+}
+
+func (store *IoTDBStorage) Subscribe(changeChan chan string) error {
+	return ErrChangeSubscriptionNotSupported
+}
+
+func (store *IoTDBStorage) Close() {
+	store.session.Close()
+}
@@ -0,0 +1,104 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Keeps the history in memory, so it does not survive a restart of the server.
+type MemoryHistoryStorage struct {
+	lock       sync.Mutex
+	recordings map[string]HistoryRecording
+	samples    map[string][]HistorySample // oldest first
+}
+
+func init() {
+	RegisterHistoryStorage("memory", newMemoryHistoryStorage)
+}
+
+func newMemoryHistoryStorage(config HistoryStorageConfig) (HistoryStorage, error) {
+	return &MemoryHistoryStorage{recordings: make(map[string]HistoryRecording), samples: make(map[string][]HistorySample)}, nil
+}
+
+func (store *MemoryHistoryStorage) SaveRecording(recording HistoryRecording) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.recordings[recording.Path] = recording
+	return nil
+}
+
+func (store *MemoryHistoryStorage) LoadRecordings() ([]HistoryRecording, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	recordings := make([]HistoryRecording, 0, len(store.recordings))
+	for _, recording := range store.recordings {
+		recordings = append(recordings, recording)
+	}
+	return recordings, nil
+}
+
+func (store *MemoryHistoryStorage) DeleteRecording(path string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.recordings, path)
+	delete(store.samples, path)
+	return nil
+}
+
+func (store *MemoryHistoryStorage) Append(path string, sample HistorySample) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	samples := store.samples[path]
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Time.After(sample.Time) }) // captures are normally in time order
+	samples = append(samples, HistorySample{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = sample
+	store.samples[path] = samples
+	return nil
+}
+
+func (store *MemoryHistoryStorage) Query(path string, start time.Time, end time.Time) ([]HistorySample, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	samples := store.samples[path]
+	first := sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(start) })
+	last := sort.Search(len(samples), func(i int) bool { return samples[i].Time.After(end) })
+	if first >= last {
+		return nil, nil
+	}
+	return append([]HistorySample{}, samples[first:last]...), nil
+}
+
+func (store *MemoryHistoryStorage) Count(path string) (int, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return len(store.samples[path]), nil
+}
+
+func (store *MemoryHistoryStorage) Prune(recording HistoryRecording, now time.Time) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	samples := store.samples[recording.Path]
+	if recording.Retention > 0 {
+		oldest := now.Add(-recording.Retention)
+		samples = samples[sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(oldest) }):]
+	}
+	store.samples[recording.Path] = limitHistory(samples, recording.BufSize)
+	return nil
+}
+
+func (store *MemoryHistoryStorage) Close() {
+}
@@ -0,0 +1,165 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+type RedisStorage struct {
+	redisClient *redis.Client
+	feederConn  net.Conn // the feeder accepts a single connection, so it is kept open
+	pubSub      *redis.PubSub
+}
+
+const redisStateDb = 1
+
+func init() {
+	RegisterStateStorage("redis", newRedisStorage)
+}
+
+func newRedisStorage(config StateStorageConfig) (StateStorage, error) {
+	addr := utils.GetUdsPath("Vehicle", "redis")
+	utils.Info.Printf(addr)
+	redisClient := redis.NewClient(&redis.Options{
+		Network:  "unix",
+		Addr:     addr, //TODO replace with check and exit if not defined.
+		Password: "",
+		DB:       redisStateDb,
+	})
+	err := redisClient.Ping().Err()
+	if err != nil {
+		utils.Error.Printf("redis-server ,ping err = %s", err)
+		if utils.FileExists("redis.log") {
+			os.Remove("redis.log")
+		}
+		cmd := exec.Command("/usr/bin/bash", "redisNativeInit.sh")
+		err := cmd.Run()
+		if err != nil {
+			utils.Error.Printf("redis-server startup failed, err=%s", err)
+			return nil, err
+		}
+	} else {
+		utils.Info.Printf("Redis state ping is ok")
+	}
+	utils.Info.Printf("Redis state storage initialised.")
+	return &RedisStorage{redisClient: redisClient}, nil
+}
+
+func (store *RedisStorage) Get(path string) string {
+	utils.Info.Printf(path)
+	dp, err := store.redisClient.Get(path).Result()
+	if err != nil {
+		if err.Error() != "redis: nil" {
+			utils.Error.Printf("Job failed. Error()=%s\n", err.Error())
+			return `{"value":"Database-error", "ts":"` + utils.GetRfcTime() + `"}`
+		} else {
+			utils.Warning.Printf("Data not found.\n")
+			return `{"value":"Data-not-found", "ts":"` + utils.GetRfcTime() + `"}`
+		}
+	}
+	return dp
+}
+
+/*
+* Set requests are forwarded to the feeder, which after actuation updates the current value in Redis.
+* The path of a vehicle partition is sent as the VSS path, with the VIN in a member of its own, {"path":"Vehicle.X", "vin":"VIN001", "dp":{...}},
+* and the feeder is expected to write the value to the key VIN001:Vehicle.X.
+ */
+func (store *RedisStorage) Set(path string, value string) string {
+	ts := utils.GetRfcTime()
+	return store.writeToFeeder(path, feederSetMessage(path, value, ts), ts)
+}
+
+// The batch is forwarded to the feeder in one message, a JSON array of the messages that Set would send for each path.
+func (store *RedisStorage) SetBatch(batch []utils.PathValue) string {
+	ts := utils.GetRfcTime()
+	data := "["
+	for i, pathValue := range batch {
+		if i > 0 {
+			data += ", "
+		}
+		data += feederSetMessage(pathValue.Path, pathValue.Value, ts)
+	}
+	data += "]"
+	return store.writeToFeeder(batch[0].Path, data, ts)
+}
+
+func feederSetMessage(storagePath string, value string, ts string) string {
+	vin, path := splitVinPath(storagePath)
+	vinMember := ""
+	if len(vin) > 0 {
+		vinMember = `, "vin":"` + vin + `"`
+	}
+	return `{"path":"` + path + `"` + vinMember + `, "dp":{"value":"` + value + `", "ts":"` + ts + `"}}`
+}
+
+// The feeder is looked up on the VSS path, as the feeders are registered on the root node name.
+func (store *RedisStorage) writeToFeeder(storagePath string, data string, ts string) string {
+	if store.feederConn == nil {
+		_, path := splitVinPath(storagePath)
+		store.feederConn = utils.GetUdsConn(path, "serverFeeder")
+		if store.feederConn == nil {
+			utils.Error.Printf("setVehicleData:Failed to UDS connect to feeder for path = %s", path)
+			return ""
+		}
+	}
+	_, err := store.feederConn.Write([]byte(data))
+	if err != nil {
+		utils.Error.Printf("setVehicleData:Write failed, err = %s", err)
+		store.feederConn.Close()
+		store.feederConn = nil // reconnect on next set
+		return ""
+	}
+	return ts
+}
+
+// Uses Redis keyspace notifications, where the channel name of a notification is the key prefixed by __keyspace@<db>__:
+func (store *RedisStorage) Subscribe(changeChan chan string) error {
+	err := store.redisClient.ConfigSet("notify-keyspace-events", "K$").Err() // keyspace events for string commands
+	if err != nil {
+		return err
+	}
+	keyspacePrefix := "__keyspace@" + strconv.Itoa(redisStateDb) + "__:"
+	store.pubSub = store.redisClient.PSubscribe(keyspacePrefix + "*")
+	if _, err = store.pubSub.Receive(); err != nil { // wait for the subscription confirmation
+		store.pubSub.Close()
+		store.pubSub = nil
+		return err
+	}
+	go func() {
+		for msg := range store.pubSub.Channel() {
+			if msg.Payload == "set" {
+				changeChan <- strings.TrimPrefix(msg.Channel, keyspacePrefix)
+			}
+		}
+	}()
+	return nil
+}
+
+func (store *RedisStorage) Close() {
+	if store.pubSub != nil {
+		store.pubSub.Close()
+	}
+	if store.feederConn != nil {
+		store.feederConn.Close()
+	}
+	store.redisClient.Close()
+}
@@ -0,0 +1,65 @@
+package serviceMgr
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// A set of a partition path must be routed on the VSS path, also as the first set when the feeder connection is not yet open.
+func TestRedisSetPartitionPath(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	feederSocket := filepath.Join(dir, "serverFeeder.sock")
+	listener, err := net.Listen("unix", feederSocket)
+	if err != nil {
+		t.Fatalf("Could not listen on %s, err=%s", feederSocket, err)
+	}
+	defer listener.Close()
+	regFile := filepath.Join(dir, "uds-registration.json")
+	os.WriteFile(regFile, []byte(`[{"root":"Vehicle", "serverFeeder":"`+feederSocket+`"}]`), 0644)
+	utils.ReadUdsRegistrations(regFile)
+	defer func() {
+		os.WriteFile(regFile, []byte(`[]`), 0644)
+		utils.ReadUdsRegistrations(regFile)
+	}()
+
+	store := &RedisStorage{} // no Redis server is needed, as a set is only forwarded to the feeder
+	defer func() {
+		if store.feederConn != nil {
+			store.feederConn.Close()
+		}
+	}()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 512)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+	}()
+	ts := store.Set(vinPath("VIN001", "Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed"), "40")
+	if len(ts) == 0 {
+		t.Fatalf("Set of a partition path failed")
+	}
+	select {
+	case message := <-received:
+		expected := `{"path":"Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed", "vin":"VIN001", "dp":{"value":"40", "ts":"` + ts + `"}}`
+		if message != expected {
+			t.Errorf("Expected feeder message %s, got %s", expected, message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("No message received by the feeder")
+	}
+	if message := feederSetMessage("Vehicle.Speed", "0", ts); strings.Contains(message, "vin") {
+		t.Errorf("vin member in message of the vehicle of the server, %s", message)
+	}
+}
@@ -0,0 +1,207 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+* The scheduler triggers timebased subscriptions and history captures from one shared timer.
+* Jobs are kept in a min-heap ordered on their next expiry time, and the timer is always set to expire at the top of the heap.
+* When a job expires, its id is sent on the job's channel, and the job is rescheduled one period later.
+* The id is sent by a goroutine of the job, so that a slow receiver does not delay the other jobs. An expiry while the previous send
+* of the job is still pending is dropped, the receiver is then notified once for both.
+ */
+
+const (
+	subscriptionJob = iota
+	historyJob
+)
+
+type schedulerJobKey struct {
+	Kind int // subscriptionJob or historyJob
+	Id   int
+}
+
+type schedulerJob struct {
+	key        schedulerJobKey
+	period     time.Duration
+	nextExpiry time.Time
+	notifyChan chan int
+	heapIndex  int
+	sending    atomic.Bool // a send of the id is pending
+}
+
+type jobHeap []*schedulerJob
+
+func (h jobHeap) Len() int           { return len(h) }
+func (h jobHeap) Less(i, j int) bool { return h[i].nextExpiry.Before(h[j].nextExpiry) }
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	job := x.(*schedulerJob)
+	job.heapIndex = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	job.heapIndex = -1
+	*h = old[:n-1]
+	return job
+}
+
+var ErrSchedulerFull = errors.New("max number of scheduled jobs reached")
+var ErrInvalidPeriod = errors.New("job period must be positive")
+
+type Scheduler struct {
+	jobs    jobHeap
+	jobMap  map[schedulerJobKey]*schedulerJob
+	maxJobs int // zero or negative means no limit
+	lock    sync.Mutex
+	wakeUp  chan struct{}
+	stop    chan struct{}
+}
+
+func NewScheduler(maxJobs int) *Scheduler {
+	scheduler := &Scheduler{
+		jobMap:  make(map[schedulerJobKey]*schedulerJob),
+		maxJobs: maxJobs,
+		wakeUp:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	go scheduler.run()
+	return scheduler
+}
+
+func (scheduler *Scheduler) Add(key schedulerJobKey, period time.Duration, notifyChan chan int) error {
+	if period <= 0 {
+		return ErrInvalidPeriod
+	}
+	scheduler.lock.Lock()
+	if _, exists := scheduler.jobMap[key]; exists {
+		scheduler.removeJob(key)
+	} else if scheduler.maxJobs > 0 && len(scheduler.jobs) >= scheduler.maxJobs {
+		scheduler.lock.Unlock()
+		return ErrSchedulerFull
+	}
+	job := &schedulerJob{key: key, period: period, nextExpiry: time.Now().Add(period), notifyChan: notifyChan}
+	heap.Push(&scheduler.jobs, job)
+	scheduler.jobMap[key] = job
+	scheduler.lock.Unlock()
+	scheduler.signalWakeUp()
+	return nil
+}
+
+// Returns false if the job was not scheduled.
+func (scheduler *Scheduler) Remove(key schedulerJobKey) bool {
+	scheduler.lock.Lock()
+	removed := scheduler.removeJob(key)
+	scheduler.lock.Unlock()
+	if removed {
+		scheduler.signalWakeUp()
+	}
+	return removed
+}
+
+func (scheduler *Scheduler) Len() int {
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	return len(scheduler.jobs)
+}
+
+// Stops the scheduler, and its pending sends. The scheduler must not be used after it is stopped.
+func (scheduler *Scheduler) Stop() {
+	close(scheduler.stop)
+}
+
+func (scheduler *Scheduler) removeJob(key schedulerJobKey) bool {
+	job, exists := scheduler.jobMap[key]
+	if !exists {
+		return false
+	}
+	heap.Remove(&scheduler.jobs, job.heapIndex)
+	delete(scheduler.jobMap, key)
+	return true
+}
+
+func (scheduler *Scheduler) signalWakeUp() {
+	select {
+	case scheduler.wakeUp <- struct{}{}:
+	default: // a wake up is already pending
+	}
+}
+
+func (scheduler *Scheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	for {
+		scheduler.lock.Lock()
+		sleepTime := time.Hour
+		if len(scheduler.jobs) > 0 {
+			sleepTime = time.Until(scheduler.jobs[0].nextExpiry)
+		}
+		scheduler.lock.Unlock()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(sleepTime)
+		select {
+		case <-timer.C:
+			scheduler.dispatchExpiredJobs()
+		case <-scheduler.wakeUp:
+		case <-scheduler.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (scheduler *Scheduler) dispatchExpiredJobs() {
+	now := time.Now()
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	for len(scheduler.jobs) > 0 && !scheduler.jobs[0].nextExpiry.After(now) {
+		job := scheduler.jobs[0]
+		if job.sending.CompareAndSwap(false, true) {
+			go scheduler.notify(job.key.Id, job.notifyChan, &job.sending) // sent without holding the lock, as the receiver may call Add or Remove
+		}
+		job.nextExpiry = job.nextExpiry.Add(job.period)
+		if job.nextExpiry.Before(now) { // lagging behind, skip the missed expiries like a time.Ticker does
+			job.nextExpiry = now.Add(job.period)
+		}
+		heap.Fix(&scheduler.jobs, 0)
+	}
+}
+
+func (scheduler *Scheduler) notify(id int, notifyChan chan int, sending *atomic.Bool) {
+	select {
+	case notifyChan <- id:
+	case <-scheduler.stop:
+	}
+	sending.Store(false)
+}
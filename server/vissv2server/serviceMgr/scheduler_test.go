@@ -0,0 +1,107 @@
+package serviceMgr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerNotifies(t *testing.T) {
+	scheduler := NewScheduler(0)
+	defer scheduler.Stop()
+	notifyChan := make(chan int, 10)
+	if err := scheduler.Add(schedulerJobKey{subscriptionJob, 7}, 10*time.Millisecond, notifyChan); err != nil {
+		t.Fatalf("Add failed, err=%s", err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-notifyChan:
+			if id != 7 {
+				t.Errorf("Expected id 7, got %d", id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("No notification received")
+		}
+	}
+	if !scheduler.Remove(schedulerJobKey{subscriptionJob, 7}) {
+		t.Errorf("Remove of scheduled job failed")
+	}
+	if scheduler.Remove(schedulerJobKey{subscriptionJob, 7}) {
+		t.Errorf("Remove of unscheduled job succeeded")
+	}
+}
+
+func TestSchedulerLimit(t *testing.T) {
+	scheduler := NewScheduler(2)
+	defer scheduler.Stop()
+	notifyChan := make(chan int, 10)
+	scheduler.Add(schedulerJobKey{subscriptionJob, 1}, time.Hour, notifyChan)
+	scheduler.Add(schedulerJobKey{historyJob, 1}, time.Hour, notifyChan) // same id, other kind
+	if err := scheduler.Add(schedulerJobKey{subscriptionJob, 2}, time.Hour, notifyChan); err != ErrSchedulerFull {
+		t.Errorf("Expected ErrSchedulerFull, got %v", err)
+	}
+	if err := scheduler.Add(schedulerJobKey{subscriptionJob, 1}, time.Minute, notifyChan); err != nil {
+		t.Errorf("Rescheduling an existing job failed, err=%s", err)
+	}
+	scheduler.Remove(schedulerJobKey{historyJob, 1})
+	if err := scheduler.Add(schedulerJobKey{subscriptionJob, 2}, time.Hour, notifyChan); err != nil {
+		t.Errorf("Add after Remove failed, err=%s", err)
+	}
+}
+
+// A receiver that does not read its channel must neither delay the other jobs, nor get more than one pending notification.
+func TestSchedulerSlowReceiver(t *testing.T) {
+	scheduler := NewScheduler(0)
+	defer scheduler.Stop()
+	blockedChan := make(chan int)
+	notifyChan := make(chan int, 10)
+	scheduler.Add(schedulerJobKey{subscriptionJob, 1}, 5*time.Millisecond, blockedChan)
+	scheduler.Add(schedulerJobKey{subscriptionJob, 2}, 10*time.Millisecond, notifyChan)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-notifyChan:
+		case <-time.After(time.Second):
+			t.Fatalf("Notification delayed by a blocked receiver")
+		}
+	}
+	scheduler.Remove(schedulerJobKey{subscriptionJob, 1})
+	<-blockedChan
+	select {
+	case <-blockedChan:
+		t.Errorf("Missed expiries not coalesced")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func BenchmarkSchedulerAddRemove(b *testing.B) {
+	scheduler := NewScheduler(0)
+	defer scheduler.Stop()
+	notifyChan := make(chan int)
+	for i := 0; i < 10000; i++ {
+		scheduler.Add(schedulerJobKey{subscriptionJob, i}, time.Hour, notifyChan)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := schedulerJobKey{historyJob, i}
+		scheduler.Add(key, time.Hour, notifyChan)
+		scheduler.Remove(key)
+	}
+}
+
+// Measures the notification throughput with thousands of concurrently active timebased subscriptions.
+func BenchmarkSchedulerThousandsOfSubscriptions(b *testing.B) {
+	const numOfSubscriptions = 5000
+	scheduler := NewScheduler(0)
+	defer scheduler.Stop()
+	notifyChan := make(chan int, numOfSubscriptions)
+	for i := 0; i < numOfSubscriptions; i++ {
+		scheduler.Add(schedulerJobKey{subscriptionJob, i}, time.Duration(10+i%50)*time.Millisecond, notifyChan)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		<-notifyChan
+	}
+	b.StopTimer()
+	for i := 0; i < numOfSubscriptions; i++ {
+		scheduler.Remove(schedulerJobKey{subscriptionJob, i})
+	}
+}
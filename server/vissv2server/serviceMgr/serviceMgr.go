@@ -13,18 +13,12 @@
 package serviceMgr
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
-	"github.com/apache/iotdb-client-go/client"
-	"github.com/go-redis/redis"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/w3c/automotive-viss2/utils"
 	"io"
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -42,21 +36,55 @@ type SubscriptionState struct {
 	FilterList          []utils.FilterObject
 	LatestDataPoint     string
 	GatingId            string
+	ResumeToken         string    // only issued if durable subscriptions are enabled
+	DetachedAt          time.Time // zero if the subscription is attached to a client session
+	Missed              []string  // notifications buffered while detached
+	Vin                 string    // state storage partition, empty for the vehicle of the server
 }
 
 var subscriptionId int
 
 type HistoryList struct {
-	Path      string
-	Frequency int
-	BufSize   int
-	Status    int
-	BufIndex  int // points to next empty buffer element
-	Buffer    []string
+	HistoryRecording
+	Created  bool
+	LatestTs string // ts of the latest captured sample
+	Unpruned int       // samples appended since the latest prune
+	PrunedAt time.Time // time of the latest prune
+}
+
+// A get request with a history filter, as sent to the history server.
+type HistoryGetRequest struct {
+	Path      string `json:"path"`
+	Parameter string `json:"parameter"` // FilterObject.Parameter of the history filter
+}
+
+// The status of a recording, as reported by the history control list command.
+type HistoryCapture struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"` // created, recording, or stopped
+	Frequency string `json:"frequency"`
+	BufSize   string `json:"buf-size"`
+	Retention string `json:"retention,omitempty"`
+	Samples   string `json:"samples"`
+	FillLevel string `json:"fill-level,omitempty"` // samples in percent of buf-size, if it is not zero
 }
 
 var historyList []HistoryList
 var historyAccessChannel chan string
+var historyControlChannel chan historyCtrlRequest // history control from the Unix domain socket server and from history-control requests
+
+// A history control request, and the channel that the history server sends the response of it on
+type historyCtrlRequest struct {
+	request string
+	reply   chan string
+}
+
+func requestHistoryControl(request string) string {
+	reply := make(chan string, 1)
+	historyControlChannel <- historyCtrlRequest{request, reply}
+	return <-reply
+}
+var historyStorage HistoryStorage
 
 //var feederConn net.Conn
 //var hostIp string
@@ -69,25 +97,11 @@ var errorResponseMap = map[string]interface{}{
 	"ts":        "yy",
 }
 
-var dbHandle *sql.DB
-var dbErr error
-var redisClient *redis.Client
-var stateDbType string
+var stateStorage StateStorage
 var historySupport bool
 
-// Apache IoTDB
-var IoTDBsession client.Session
-var IoTDbPrefixPath string = "root.test2.dev1" // DB prefix used for get/set
-var IoTDbTimeout int64 = 3000
-var IoTDBconfig = &client.Config{
-	//	Host:     "127.0.0.1",
-	Host:     "iotdb-service",
-	Port:     "6667",
-	UserName: "root",
-	Password: "root",
-}
-
-var dummyValue int // dummy value returned when DB configured to none. Counts from 0 to 999, wrap around, updated every 47 msec
+// The UDS registration file, set from the server configuration
+var UdsRegistrationFile = "uds-registration.json"
 
 func initDataServer(serviceMgrChan chan string, clientChannel chan string, backendChannel chan string) {
 	for {
@@ -98,6 +112,9 @@ func initDataServer(serviceMgrChan chan string, clientChannel chan string, backe
 			clientChannel <- request                                              // forward to mgr hub,
 			if strings.Contains(request, "internal-killsubscriptions") == false { // no response on kill sub
 				response := <-clientChannel //  and wait for response
+				if len(response) == 0 {     // set waiting for actuator, response is sent as a notification
+					continue
+				}
 				utils.Info.Printf("Service mgr response: %s", response)
 				serviceMgrChan <- response
 			}
@@ -108,65 +125,30 @@ func initDataServer(serviceMgrChan chan string, clientChannel chan string, backe
 	}
 }
 
-const MAXTICKERS = 255 // total number of active subscription and history tickers
-var subscriptionTicker [MAXTICKERS]*time.Ticker
-var historyTicker [MAXTICKERS]*time.Ticker
-var tickerIndexList [MAXTICKERS]int
-
-func allocateTicker(subscriptionId int) int {
-	for i := 0; i < len(tickerIndexList); i++ {
-		if tickerIndexList[i] == 0 {
-			tickerIndexList[i] = subscriptionId
-			return i
-		}
-	}
-	return -1
-}
-
-func deallocateTicker(subscriptionId int) int {
-	for i := 0; i < len(tickerIndexList); i++ {
-		if tickerIndexList[i] == subscriptionId {
-			tickerIndexList[i] = 0
-			return i
-		}
-	}
-	return -1
-}
+var scheduler *Scheduler
 
-func activateInterval(subscriptionChannel chan int, subscriptionId int, interval int) {
-	index := allocateTicker(subscriptionId)
-	if index == -1 {
-		utils.Error.Printf("activateInterval: No available ticker.")
-		return
+func activateInterval(subscriptionChannel chan int, subscriptionId int, interval int) error {
+	err := scheduler.Add(schedulerJobKey{subscriptionJob, subscriptionId}, time.Duration(interval)*time.Millisecond, subscriptionChannel) // interval in milliseconds
+	if err != nil {
+		utils.Error.Printf("activateInterval: Could not schedule subscription %d, err=%s", subscriptionId, err)
 	}
-	subscriptionTicker[index] = time.NewTicker(time.Duration(interval) * time.Millisecond) // interval in milliseconds
-	go func() {
-		for range subscriptionTicker[index].C {
-			subscriptionChannel <- subscriptionId
-		}
-	}()
+	return err
 }
 
 func deactivateInterval(subscriptionId int) {
-	subscriptionTicker[deallocateTicker(subscriptionId)].Stop()
+	scheduler.Remove(schedulerJobKey{subscriptionJob, subscriptionId})
 }
 
-func activateHistory(historyChannel chan int, signalId int, frequency int) {
-	index := allocateTicker(signalId)
-	if index == -1 {
-		utils.Error.Printf("activateHistory: No available ticker.")
-		return
+func activateHistory(historyChannel chan int, signalId int, frequency int) error {
+	err := scheduler.Add(schedulerJobKey{historyJob, signalId}, time.Duration((3600*1000)/frequency)*time.Millisecond, historyChannel) // freq in cycles per hour
+	if err != nil {
+		utils.Error.Printf("activateHistory: Could not schedule history capture for signal %d, err=%s", signalId, err)
 	}
-	historyTicker[index] = time.NewTicker(time.Duration((3600*1000)/frequency) * time.Millisecond) // freq in cycles per hour
-	go func() {
-		for range historyTicker[index].C {
-			historyChannel <- signalId
-		}
-	}()
+	return err
 }
 
 func deactivateHistory(signalId int) {
-	historyTicker[deallocateTicker(signalId)].Stop()
+	scheduler.Remove(schedulerJobKey{historyJob, signalId})
 }
 
 func getSubcriptionStateIndex(subscriptionId int, subscriptionList []SubscriptionState) int {
@@ -202,6 +184,15 @@ func checkRangeChangeFilter(filterList []utils.FilterObject, latestDataPoint str
 	return false, false, ""
 }
 
+func hasFilter(filterList []utils.FilterObject, filterType string) bool {
+	for i := 0; i < len(filterList); i++ {
+		if filterList[i].Type == filterType {
+			return true
+		}
+	}
+	return false
+}
+
 func getDPValue(dp string) string {
 	value, _ := unpackDataPoint(dp)
 	return value
@@ -410,7 +401,7 @@ func getIntervalPeriod(opValue string) int { // {"period":"X"}
 	}
 	period, err := strconv.Atoi(intervalData.Period)
 	if err != nil {
-		utils.Error.Printf("getIntervalPeriod: Invalid period=%s", period)
+		utils.Error.Printf("getIntervalPeriod: Invalid period=%s", intervalData.Period)
 		return -1
 	}
 	return period
@@ -440,13 +431,13 @@ func getCurveLoggingParams(opValue string) (float64, int) { // {"maxerr": "X", "
 	return maxErr, bufSize
 }
 
-func activateIfIntervalOrCL(filterList []utils.FilterObject, subscriptionChan chan int, CLChan chan CLPack, subscriptionId int, paths []string) {
+func activateIfIntervalOrCL(filterList []utils.FilterObject, subscriptionChan chan int, CLChan chan CLPack, subscriptionId int, paths []string) error {
 	for i := 0; i < len(filterList); i++ {
 		if filterList[i].Type == "timebased" {
 			interval := getIntervalPeriod(filterList[i].Parameter)
 			utils.Info.Printf("interval activated, period=%d", interval)
 			if interval > 0 {
-				activateInterval(subscriptionChan, subscriptionId, interval)
+				return activateInterval(subscriptionChan, subscriptionId, interval)
 			}
 			break
 		}
@@ -455,131 +446,55 @@ func activateIfIntervalOrCL(filterList []utils.FilterObject, subscriptionChan ch
 			break
 		}
 	}
+	return nil
 }
 
-func getVehicleData(path string) string { // returns {"value":"Y", "ts":"Z"}
-	switch stateDbType {
-	case "sqlite":
-
-		rows, err := dbHandle.Query("SELECT `c_value`, `c_ts` FROM VSS_MAP WHERE `path`=?", path)
-		if err != nil {
-			return `{"value":"Data-error", "ts":"` + utils.GetRfcTime() + `"}`
-		}
-		defer rows.Close()
-		value := ""
-		timestamp := ""
+/*
+* Returns the state storage key of a path. A server serving several vehicles keeps the signals of each in a partition of the state storage,
+* where the path is prefixed by the VIN, e.g. VIN001:Vehicle.Speed. The vehicle of the server itself, with an empty vin, is not partitioned.
+ */
+func vinPath(vin string, path string) string {
+	if len(vin) == 0 {
+		return path
+	}
+	return vin + ":" + path
+}
 
-		rows.Next()
-		err = rows.Scan(&value, &timestamp)
-		if err != nil {
-			utils.Warning.Printf("Data not found: %s for path=%s\n", err, path)
-			return `{"value":"Data-not-available", "ts":"` + utils.GetRfcTime() + `"}`
-		}
-		return `{"value":"` + value + `", "ts":"` + timestamp + `"}`
-	case "redis":
-		utils.Info.Printf(path)
-		dp, err := redisClient.Get(path).Result()
-		if err != nil {
-			if err.Error() != "redis: nil" {
-				utils.Error.Printf("Job failed. Error()=%s\n", err.Error())
-				return `{"value":"Database-error", "ts":"` + utils.GetRfcTime() + `"}`
-			} else {
-				utils.Warning.Printf("Data not found.\n")
-				return `{"value":"Data-not-found", "ts":"` + utils.GetRfcTime() + `"}`
-			}
-		} else {
-			return dp
-		}
-	case "apache-iotdb":
-		var (
-			// Back-quote the VSS node for the DB query, e.g. `Vehicle.CurrentLocation.Longitude`
-			selectLastSQL = fmt.Sprintf("select last `%v` from %v", path, IoTDbPrefixPath)
-			value         = ""
-			ts            = ""
-		)
-		//		utils.Info.Printf("IoTDB: query using: %v", selectLastSQL)
-		sessionDataSet, err := IoTDBsession.ExecuteQueryStatement(selectLastSQL, &IoTDbTimeout)
-		if err == nil {
-			var success bool
-			success, err = sessionDataSet.Next()
-			if err == nil && success {
-				value = sessionDataSet.GetText("Value")
-				ts = sessionDataSet.GetText(client.TimestampColumnName)
-				//				utils.Info.Printf("IoTDB: get returned: ts=%v, Value=%v", ts, value)
-				//				resultStr := `{"value":"` + value + `", "ts":"` + ts + `"}`
-				//				utils.Info.Printf("IoTDB: returning get result=%v", resultStr)
-			}
-			sessionDataSet.Close()
-		} else {
-			utils.Error.Printf("IoTDB: Query failed with error=%s", err)
-			return `{"value":"Data-not-found", "ts":"` + utils.GetRfcTime() + `"}`
-		}
-		return `{"value":"` + value + `", "ts":"` + ts + `"}`
-	case "none":
-		return `{"value":"` + strconv.Itoa(dummyValue) + `", "ts":"` + utils.GetRfcTime() + `"}`
+// Returns the VIN, and the VSS path, of a state storage key, see vinPath().
+func splitVinPath(storagePath string) (string, string) {
+	if vinEnd := strings.Index(storagePath, ":"); vinEnd != -1 {
+		return storagePath[:vinEnd], storagePath[vinEnd+1:]
 	}
-	return ""
+	return "", storagePath
+}
+
+func getVehicleData(path string) string { // returns {"value":"Y", "ts":"Z"}
+	return stateStorage.Get(path)
 }
 
 func setVehicleData(path string, value string) string {
-	ts := utils.GetRfcTime()
-	switch stateDbType {
-	case "sqlite":
-		stmt, err := dbHandle.Prepare("UPDATE VSS_MAP SET d_value=?, d_ts=? WHERE `path`=?")
-		if err != nil {
-			utils.Error.Printf("Could not prepare for statestorage updating, err = %s", err)
-			return ""
-		}
-		defer stmt.Close()
+	return stateStorage.Set(path, value)
+}
 
-		_, err = stmt.Exec(value, ts, path[1:len(path)-1]) // remove quotes surrounding path
-		if err != nil {
-			utils.Error.Printf("Could not update statestorage, err = %s", err)
-			return ""
-		}
-		return ts
-	case "redis":
-		/*		dp := `{"val":"` + value + `", "ts":"` + ts + `"}`
-				dPath := path + ".D" // path to "desired" dp. Must be created identically by feeder reading it.
-				err := redisClient.Set(dPath, dp, time.Duration(0)).Err()
-				if err != nil {
-					utils.Error.Printf("Could not update statestorage. Err=%s\n", err)
-					return ""
-				}
-				return ts*/
-		udsConn := utils.GetUdsConn(path, "serverFeeder")
-		if udsConn == nil {
-			utils.Error.Printf("setVehicleData:Failed to UDS connect to feeder for path = %s", path)
-			return ""
+func setVehicleDataBatch(batch []utils.PathValue) string {
+	return stateStorage.SetBatch(batch)
+}
+
+func unpackPathValues(valueList []interface{}) []utils.PathValue {
+	batch := make([]utils.PathValue, len(valueList))
+	for i := 0; i < len(valueList); i++ {
+		pathValue, ok := valueList[i].(map[string]interface{})
+		if !ok {
+			return nil
 		}
-		data := `{"path":"` + path + `", "dp":{"value":"` + value + `", "ts":"` + ts + `"}}`
-		_, err := udsConn.Write([]byte(data))
-		if err != nil {
-			utils.Error.Printf("setVehicleData:Write failed, err = %s", err)
-			return ""
+		if batch[i].Path, ok = pathValue["path"].(string); !ok {
+			return nil
 		}
-		return ts
-	case "apache-iotdb":
-		vssKey := []string{"`" + path + "`"} // Back-quote the VSS node for the DB insert, e.g. `Vehicle.CurrentLocation.Longitude`
-		vssValue := []string{value}
-		IoTDBts := time.Now().UTC().UnixNano() / 1000000
-
-		// IoTDB will automatically convert the value string to the native data type in the timeseries schema for basic types
-		//		utils.Info.Printf("IoTDB: DB insert with prefixPath: %v vssKey: %v, vssValue: %v, ts: %v", IoTDbPrefixPath, vssKey, vssValue, IoTDBts)
-		if status, err := IoTDBsession.InsertStringRecord(IoTDbPrefixPath, vssKey, vssValue, IoTDBts); err != nil {
-			utils.Error.Printf("IoTDB: DB insert using InsertStringRecord failed with: %v", err)
-			return ""
-		} else {
-			if status != nil {
-				if err = client.VerifySuccess(status); err != nil {
-					utils.Error.Printf("IoTDB: DB insert Verify failed with: %v", err)
-					return ""
-				}
-			}
+		if batch[i].Value, ok = pathValue["value"].(string); !ok {
+			return nil
 		}
-		return ts
 	}
-	return ""
+	return batch
 }
 
 func unpackPaths(paths string) []string {
@@ -613,26 +528,45 @@ func createHistoryList(vss_data []byte) bool {
 	historyList = make([]HistoryList, len(pathList.LeafPaths))
 	for i := 0; i < len(pathList.LeafPaths); i++ {
 		historyList[i].Path = pathList.LeafPaths[i]
-		historyList[i].Frequency = 0
-		historyList[i].BufSize = 0
-		historyList[i].Status = 0
-		historyList[i].BufIndex = 0
-		historyList[i].Buffer = nil
 	}
 	return true
 }
 
+// Restores the recordings saved in the history storage, and restarts the capture of those that were active.
+func restoreHistoryRecordings(historyChannel chan int) {
+	recordings, err := historyStorage.LoadRecordings()
+	if err != nil {
+		utils.Error.Printf("restoreHistoryRecordings: Could not load recordings, err=%s", err)
+		return
+	}
+	for _, recording := range recordings {
+		index := getHistoryListIndex(recording.Path)
+		if index == -1 {
+			utils.Warning.Printf("restoreHistoryRecordings: Recorded path=%s is not in the tree", recording.Path)
+			continue
+		}
+		historyList[index].HistoryRecording = recording
+		historyList[index].Created = true
+		if recording.Active && activateHistory(historyChannel, index, recording.Frequency) != nil {
+			historyList[index].Active = false
+		}
+	}
+	utils.Info.Printf("restoreHistoryRecordings: %d recordings restored", len(recordings))
+}
+
 func historyServer(historyAccessChan chan string, vss_data []byte) {
 	listExists := createHistoryList(vss_data) // file is created by core-server at startup
-	histCtrlChannel := make(chan string)
-	go initHistoryControlServer(histCtrlChannel)
+	go initHistoryControlServer()
 	historyChannel := make(chan int)
+	if listExists {
+		restoreHistoryRecordings(historyChannel)
+	}
 	for {
 		select {
 		case signalId := <-historyChannel:
 			captureHistoryValue(signalId)
-		case histCtrlReq := <-histCtrlChannel: // history config request
-			histCtrlChannel <- processHistoryCtrl(histCtrlReq, historyChannel, listExists)
+		case histCtrlReq := <-historyControlChannel: // history config request
+			histCtrlReq.reply <- processHistoryCtrl(histCtrlReq.request, historyChannel, listExists)
 		case getRequest := <-historyAccessChan: // history get request
 			response := ""
 			if listExists == true {
@@ -657,20 +591,38 @@ func processHistoryCtrl(histCtrlReq string, historyChan chan int, listExists boo
 		return "400 Bad Request"
 	}
 	index := getHistoryListIndex(requestMap["path"].(string))
+	if index == -1 {
+		utils.Error.Printf("processHistoryCtrl:Path not found=%s", requestMap["path"].(string))
+		return "404 Not Found"
+	}
 	switch requestMap["action"].(string) {
 	case "create":
-		if requestMap["buf-size"] == nil {
-			utils.Error.Printf("processHistoryCtrl:Buffer size missing")
-			return "400 Bad Request"
+		bufSize := 0
+		if bufSizeParam, _ := requestMap["buf-size"].(string); len(bufSizeParam) > 0 {
+			var err error
+			bufSize, err = strconv.Atoi(bufSizeParam)
+			if err != nil || bufSize < 0 {
+				utils.Error.Printf("processHistoryCtrl:Buffer size malformed=%s", bufSizeParam)
+				return "400 Bad Request"
+			}
 		}
-		bufSize, err := strconv.Atoi(requestMap["buf-size"].(string))
-		if err != nil {
-			utils.Error.Printf("processHistoryCtrl:Buffer size malformed=%s", requestMap["buf-size"].(string))
-			return "400 Bad Request"
+		var retention time.Duration
+		if retentionParam, _ := requestMap["retention"].(string); len(retentionParam) > 0 {
+			var err error
+			retention, err = utils.ParseIsoDuration(retentionParam)
+			if err != nil {
+				utils.Error.Printf("processHistoryCtrl:Retention malformed=%s", retentionParam)
+				return "400 Bad Request"
+			}
 		}
 		historyList[index].BufSize = bufSize
-		historyList[index].Buffer = make([]string, bufSize)
+		historyList[index].Retention = retention
+		historyList[index].Created = true
 	case "start":
+		if !historyList[index].Created {
+			utils.Error.Printf("processHistoryCtrl:History recording must first be created")
+			return "409 Conflict"
+		}
 		if requestMap["frequency"] == nil {
 			utils.Error.Printf("processHistoryCtrl:Frequency missing")
 			return "400 Bad Request"
@@ -680,28 +632,116 @@ func processHistoryCtrl(histCtrlReq string, historyChan chan int, listExists boo
 			utils.Error.Printf("processHistoryCtrl:Frequeny malformed=%s", requestMap["frequency"].(string))
 			return "400 Bad Request"
 		}
+		if freq <= 0 {
+			utils.Error.Printf("processHistoryCtrl:Frequency must be positive=%d", freq)
+			return "400 Bad Request"
+		}
+		if activateHistory(historyChan, index, freq) != nil {
+			return "503 Service Unavailable"
+		}
 		historyList[index].Frequency = freq
-		historyList[index].Status = 1
-		activateHistory(historyChan, index, freq)
+		historyList[index].Active = true
 	case "stop":
-		historyList[index].Status = 0
+		historyList[index].Active = false
 		deactivateHistory(index)
+	case "list":
+		if !historyList[index].Created {
+			return "404 Not Found"
+		}
+		return getHistoryCapture(index)
 	case "delete":
-		if historyList[index].Status != 0 {
+		if historyList[index].Active {
 			utils.Error.Printf("processHistoryCtrl:History recording must first be stopped")
 			return "409 Conflict"
 		}
-		historyList[index].Frequency = 0
-		historyList[index].BufSize = 0
-		historyList[index].BufIndex = 0
-		historyList[index].Buffer = nil
+		if err := historyStorage.DeleteRecording(historyList[index].Path); err != nil {
+			utils.Error.Printf("processHistoryCtrl:Could not delete recording, err=%s", err)
+			return "500 Internal Server Error"
+		}
+		historyList[index] = HistoryList{HistoryRecording: HistoryRecording{Path: historyList[index].Path}}
+		return "200 OK"
 	default:
 		utils.Error.Printf("processHistoryCtrl:Unknown command:action=%s", requestMap["action"].(string))
 		return "400 Bad Request"
 	}
+	if !historyList[index].Created { // stop of a recording that was never created
+		return "200 OK"
+	}
+	if err := historyStorage.SaveRecording(historyList[index].HistoryRecording); err != nil {
+		utils.Error.Printf("processHistoryCtrl:Could not save recording, err=%s", err)
+		return "500 Internal Server Error"
+	}
 	return "200 OK"
 }
 
+func getHistoryCapture(index int) string {
+	capture := HistoryCapture{Path: historyList[index].Path, Status: "created", Frequency: strconv.Itoa(historyList[index].Frequency),
+		BufSize: strconv.Itoa(historyList[index].BufSize)}
+	if historyList[index].Active {
+		capture.Status = "recording"
+	} else if historyList[index].Frequency > 0 {
+		capture.Status = "stopped"
+	}
+	if historyList[index].Retention > 0 {
+		capture.Retention = utils.FormatIsoDuration(historyList[index].Retention)
+	}
+	pruneHistory(index, time.Now())
+	samples, err := historyStorage.Count(historyList[index].Path)
+	if err != nil {
+		utils.Error.Printf("getHistoryCapture:Could not count samples, err=%s", err)
+		return "500 Internal Server Error"
+	}
+	capture.Samples = strconv.Itoa(samples)
+	if historyList[index].BufSize > 0 {
+		capture.FillLevel = strconv.Itoa(100*samples/historyList[index].BufSize) + "%"
+	}
+	captureJson, _ := json.Marshal(capture)
+	return string(captureJson)
+}
+
+/*
+* Forwards a history-control request to the history server, for each of its paths in turn.
+* A create/start/stop/delete command stops at the first path that fails, and returns the error index and message.
+* A list command returns the captures of the paths that have a recording, as a JSON array.
+ */
+func historyControl(requestMap map[string]interface{}) (string, int, string) {
+	command := requestMap["command"].(string)
+	captures := []json.RawMessage{}
+	for _, path := range unpackPaths(requestMap["path"].(string)) {
+		ctrlRequest := map[string]interface{}{"action": command, "path": path}
+		for _, key := range []string{"buf-size", "retention", "frequency"} {
+			if requestMap[key] != nil {
+				ctrlRequest[key] = requestMap[key]
+			}
+		}
+		ctrlJson, _ := json.Marshal(ctrlRequest)
+		response := requestHistoryControl(string(ctrlJson))
+		if command == "list" {
+			if strings.HasPrefix(response, "{") {
+				captures = append(captures, json.RawMessage(response))
+			}
+			continue
+		}
+		if response != "200 OK" {
+			return "", historyControlErrorIndex(response), path + ": " + response
+		}
+	}
+	capturesJson, _ := json.Marshal(captures)
+	return string(capturesJson), -1, ""
+}
+
+func historyControlErrorIndex(status string) int {
+	switch status[:3] {
+	case "400":
+		return 0 //bad_request
+	case "404":
+		return 6 //unavailable_data
+	case "409":
+		return 5 //forbidden_request
+	}
+	return 7 //service_unavailable
+}
+
 func getHistoryListIndex(path string) int {
 	for i := 0; i < len(historyList); i++ {
 		if historyList[i].Path == path {
@@ -715,62 +755,102 @@ func getCurrentUtcTime() time.Time {
 	return time.Now().UTC()
 }
 
-func convertFromIsoTime(isoTime string) (time.Time, error) {
-	time, err := time.Parse(time.RFC3339, isoTime)
-	return time, err
-}
-
-func processHistoryGet(request string) string { // {"path":"X", "period":"Y"}
-	var requestMap = make(map[string]interface{})
-	utils.MapRequest(request, &requestMap)
-	index := getHistoryListIndex(requestMap["path"].(string))
-	currentTs := getCurrentUtcTime()
-	periodTime, _ := convertFromIsoTime(requestMap["period"].(string))
-	oldTs := currentTs.Add(time.Hour*(time.Duration)((24*periodTime.Day()+periodTime.Hour())*(-1)) -
-		time.Minute*(time.Duration)(periodTime.Minute()) - time.Second*(time.Duration)(periodTime.Second())).UTC()
-	var matches int
-	for matches = 0; matches < historyList[index].BufIndex; matches++ {
-		storedTs, _ := convertFromIsoTime(getDPTs(historyList[index].Buffer[matches]))
-		if storedTs.Before(oldTs) {
-			break
-		}
+// Returns the samples of the path within the time range of the history filter, or an empty string if there are none.
+func processHistoryGet(request string) string {
+	var getRequest HistoryGetRequest
+	if err := json.Unmarshal([]byte(request), &getRequest); err != nil {
+		utils.Error.Printf("processHistoryGet:Malformed request=%s", request)
+		return ""
+	}
+	index := getHistoryListIndex(getRequest.Path)
+	if index == -1 || !historyList[index].Created {
+		return ""
+	}
+	historyRange, err := utils.ParseHistoryFilter(getRequest.Parameter, time.Now())
+	if err != nil {
+		utils.Error.Printf("processHistoryGet:Invalid history filter, err=%s", err)
+		return ""
+	}
+	pruneHistory(index, time.Now())
+	samples, err := historyStorage.Query(getRequest.Path, historyRange.Start, historyRange.End)
+	if err != nil {
+		utils.Error.Printf("processHistoryGet:Query failed, err=%s", err)
+		return ""
+	}
+	if historyRange.Interval > 0 {
+		samples = downsampleHistory(samples, historyRange.Start, historyRange.Interval, historyRange.Aggregate)
 	}
-	return historicDataPack(index, matches)
+	return historicDataPack(samples)
 }
 
-func historicDataPack(index int, matches int) string {
+func historicDataPack(samples []HistorySample) string {
 	dp := ""
-	if matches > 1 {
+	if len(samples) > 1 {
 		dp += "["
 	}
-	for i := 0; i < matches; i++ {
-		dp += `{"value":"` + getDPValue(historyList[index].Buffer[i]) + `", "ts":"` + getDPTs(historyList[index].Buffer[i]) + `"}, `
+	for i := 0; i < len(samples); i++ {
+		dp += `{"value":"` + samples[i].Value + `", "ts":"` + samples[i].Ts + `"}, `
 	}
-	if matches > 0 {
+	if len(samples) > 0 {
 		dp = dp[:len(dp)-2]
 	}
-	if matches > 1 {
+	if len(samples) > 1 {
 		dp += "]"
 	}
 	return dp
 }
 
+// Saves the current data point of the signal if it is new, and then applies the retention policy of the recording.
 func captureHistoryValue(signalId int) {
 	dp := getVehicleData(historyList[signalId].Path)
 	utils.Info.Printf("captureHistoryValue:Captured historic dp = %s", dp)
-	newTs := getDPTs(dp)
-	latestTs := ""
-	if historyList[signalId].BufIndex > 0 {
-		latestTs = getDPTs(historyList[signalId].Buffer[historyList[signalId].BufIndex-1])
+	value := getDPValue(dp)
+	ts := getDPTs(dp)
+	if ts == historyList[signalId].LatestTs || strings.HasPrefix(value, "Data-") { // Data-not-available, Data-not-found, Data-error
+		return
+	}
+	sampleTime, err := parseDpTs(ts)
+	if err != nil {
+		sampleTime = time.Now()
+	}
+	if err = historyStorage.Append(historyList[signalId].Path, HistorySample{value, ts, sampleTime}); err != nil {
+		utils.Error.Printf("captureHistoryValue:Could not save sample, err=%s", err)
+		return
 	}
-	if newTs != latestTs && historyList[signalId].BufIndex < historyList[signalId].BufSize-1 {
-		historyList[signalId].Buffer[historyList[signalId].BufIndex] = dp
-		utils.Info.Printf("captureHistoryValue:Saved historic dp in buffer element=%d", historyList[signalId].BufIndex)
-		historyList[signalId].BufIndex++
+	historyList[signalId].LatestTs = ts
+	historyList[signalId].Unpruned++
+	now := time.Now()
+	if historyList[signalId].Unpruned >= historyPruneInterval(historyList[signalId].BufSize) || now.Sub(historyList[signalId].PrunedAt) >= time.Minute {
+		pruneHistory(signalId, now)
 	}
 }
 
-func initHistoryControlServer(histCtrlChan chan string) {
+/*
+* The samples are pruned after a tenth of the buffer size, but at most 100 samples, has been appended since the latest prune, or a minute has passed,
+* so that the cost of a prune, which grows with the buffer size, is shared by many captures. The history is also pruned before it is read.
+ */
+func historyPruneInterval(bufSize int) int {
+	if bufSize <= 0 || bufSize/10 > 100 {
+		return 100
+	}
+	if bufSize < 10 {
+		return 1
+	}
+	return bufSize / 10
+}
+
+func pruneHistory(signalId int, now time.Time) {
+	if historyList[signalId].Unpruned == 0 && now.Sub(historyList[signalId].PrunedAt) < time.Minute {
+		return
+	}
+	historyList[signalId].Unpruned = 0
+	historyList[signalId].PrunedAt = now
+	if err := historyStorage.Prune(historyList[signalId].HistoryRecording, now); err != nil {
+		utils.Error.Printf("pruneHistory:Could not prune history, err=%s", err)
+	}
+}
+
+func initHistoryControlServer() {
 	l, err := net.Listen("unix", utils.GetUdsPath("Vehicle", "history"))
 	if err != nil {
 		utils.Error.Printf("HistCtrlServer:Listen failed, er = %s.", err)
@@ -784,11 +864,11 @@ func initHistoryControlServer(histCtrlChan chan string) {
 			return
 		}
 
-		go historyControlServer(conn, histCtrlChan)
+		go historyControlServer(conn)
 	}
 }
 
-func historyControlServer(conn net.Conn, histCtrlChan chan string) {
+func historyControlServer(conn net.Conn) {
 	buf := make([]byte, 512)
 	for {
 		nr, err := conn.Read(buf)
@@ -800,8 +880,7 @@ func historyControlServer(conn net.Conn, histCtrlChan chan string) {
 
 		data := buf[:nr]
 		utils.Info.Printf("HistCtrlServer:Read:data = %s", string(data))
-		histCtrlChan <- string(data)
-		resp := <-histCtrlChan
+		resp := requestHistoryControl(string(data))
 		_, err = conn.Write([]byte(resp))
 		if err != nil {
 			utils.Error.Printf("HistCtrlServer:Write failed, err = %s", err)
@@ -810,14 +889,14 @@ func historyControlServer(conn net.Conn, histCtrlChan chan string) {
 	}
 }
 
-func getDataPack(pathArray []string, filterList []utils.FilterObject) string {
+func getDataPack(vin string, pathArray []string, filterList []utils.FilterObject) string {
 	dataPack := ""
 	if len(pathArray) > 1 {
 		dataPack += "["
 	}
 	getHistory := false
 	getDomain := false
-	period := ""
+	historyParameter := ""
 	domain := ""
 	if filterList != nil {
 		for i := 0; i < len(filterList); i++ {
@@ -825,8 +904,8 @@ func getDataPack(pathArray []string, filterList []utils.FilterObject) string {
 				if !historySupport {
 					return ""
 				}
-				period = filterList[i].Parameter
-				utils.Info.Printf("Historic data request, period=%s", period)
+				historyParameter = filterList[i].Parameter
+				utils.Info.Printf("Historic data request, parameter=%s", historyParameter)
 				getHistory = true
 				break
 			} else if filterList[i].Type == "dynamic-metadata" {
@@ -838,19 +917,18 @@ func getDataPack(pathArray []string, filterList []utils.FilterObject) string {
 		}
 	}
 	var dataPoint string
-	var request string
 	for i := 0; i < len(pathArray); i++ {
 		if getHistory == true {
-			request = `{"path":"` + pathArray[i] + `", "period":"` + period + `"}`
-			historyAccessChannel <- request
+			request, _ := json.Marshal(HistoryGetRequest{vinPath(vin, pathArray[i]), historyParameter})
+			historyAccessChannel <- string(request)
 			dataPoint = <-historyAccessChannel
 			if len(dataPoint) == 0 {
 				return ""
 			}
 		} else if getDomain == true {
-			dataPoint = getMetadataDomainDp(domain, pathArray[i])
+			dataPoint = getMetadataDomainDp(domain, vinPath(vin, pathArray[i]))
 		} else {
-			dataPoint = getVehicleData(pathArray[i])
+			dataPoint = getVehicleData(vinPath(vin, pathArray[i]))
 		}
 		dataPack += `{"path":"` + pathArray[i] + `", "dp":` + dataPoint + "}, "
 	}
@@ -861,8 +939,11 @@ func getDataPack(pathArray []string, filterList []utils.FilterObject) string {
 	return dataPack
 }
 
-func getVssPathList(host string, port int, path string) []byte {
-	url := "http://" + host + ":" + strconv.Itoa(port) + path
+// Fetches the path list from the core listener, where the host is used if the core listens on all interfaces
+func getVssPathList(host string, path string) []byte {
+	// Set client timeout
+	client, hostPort := utils.NewListenerClient(utils.ServerConfiguration.Core.Address, host, time.Second*10)
+	url := "http://" + hostPort + path
 	utils.Info.Printf("url = %s", url)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -872,10 +953,7 @@ func getVssPathList(host string, port int, path string) []byte {
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Host", host+":"+strconv.Itoa(port))
-
-	// Set client timeout
-	client := &http.Client{Timeout: time.Second * 10}
+	req.Header.Set("Host", hostPort)
 
 	// Send request
 	resp, err := client.Do(req)
@@ -901,106 +979,73 @@ func getMetadataDomainDp(domain string, path string) string {
 	value := ""
 	switch domain {
 	case "samplerate":
-		value = getSampleRate(path)
+		value = signalStatistics.SampleRate(path)
 	case "availability":
-		value = getAvailability(path)
-	case "validate":
-		value = getValidation(path)
+		value = signalStatistics.Availability(path)
+	case "desired":
+		return actuatorTargets.GetDesired(path)
 	default:
 		value = "Unknown domain"
 	}
 	return `{"value":"` + value + `","ts":"` + utils.GetRfcTime() + `"}`
 }
 
-func getSampleRate(path string) string {
-	return "X Hz" //dummy return
-}
-
-func getAvailability(path string) string {
-	return "available" //dummy return
-}
-
-func getValidation(path string) string {
-	return "read-write" //dummy return
-}
-
-func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType string, histSupport bool, dbFile string) {
-	stateDbType = stateStorageType
+func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType string, histSupport bool, dbFile string, historyStorageType string, historyDbFile string, maxScheduledJobs int,
+	durable DurableSubscriptionConfig) {
 	historySupport = histSupport
+	durableConfig = durable
+	scheduler = NewScheduler(maxScheduledJobs)
 
-	utils.ReadUdsRegistrations("uds-registration.json")
-
-	switch stateDbType {
-	case "sqlite":
-		if utils.FileExists(dbFile) {
-			dbHandle, dbErr = sql.Open("sqlite3", dbFile)
-			if dbErr != nil {
-				utils.Error.Printf("Could not open state storage file = %s, err = %s", dbFile, dbErr)
-				os.Exit(1)
-			} else {
-				utils.Info.Printf("SQLite state storage initialised.")
-			}
-		} else {
-			utils.Error.Printf("Could not find state storage file = %s", dbFile)
-		}
-	case "redis":
-		addr := utils.GetUdsPath("Vehicle", "redis")
-		utils.Info.Printf(addr)
-		redisClient = redis.NewClient(&redis.Options{
-			Network:  "unix",
-			Addr:     addr, //TODO replace with check and exit if not defined.
-			Password: "",
-			DB:       1,
-		})
-		err := redisClient.Ping().Err()
+	utils.ReadUdsRegistrations(UdsRegistrationFile)
+
+	var err error
+	stateStorage, err = openStateStorage(stateStorageType, StateStorageConfig{DbFile: dbFile})
+	if err != nil {
+		utils.Error.Printf("Could not initialise %s state storage, err = %s", stateStorageType, err)
+		os.Exit(1)
+	}
+	defer stateStorage.Close()
+	if historySupport {
+		historyStorage, err = openHistoryStorage(historyStorageType, HistoryStorageConfig{DbFile: historyDbFile})
 		if err != nil {
-			utils.Error.Printf("redis-server ,ping err = %s", err)
-			if utils.FileExists("redis.log") {
-				os.Remove("redis.log")
-			}
-			cmd := exec.Command("/usr/bin/bash", "redisNativeInit.sh")
-			err := cmd.Run()
-			if err != nil {
-				utils.Error.Printf("redis-server startup failed, err=%s", err)
-				// os.Exit(1) should terminate the process
-				return
-			}
-		} else {
-			utils.Info.Printf("Redis state ping is ok")
-		}
-		utils.Info.Printf("Redis state storage initialised.")
-	case "apache-iotdb":
-		utils.Info.Printf("IoTDB: creating new session with host:%v port:%v user:%v pass:%v", IoTDBconfig.Host, IoTDBconfig.Port, IoTDBconfig.UserName, IoTDBconfig.Password)
-		IoTDBsession = client.NewSession(IoTDBconfig)
-		if err := IoTDBsession.Open(false, 0); err != nil {
-			utils.Error.Printf("IoTDB: Failed to open session with error=%s", err)
+			utils.Error.Printf("Could not initialise %s history storage, err = %s", historyStorageType, err)
 			os.Exit(1)
 		}
-		defer IoTDBsession.Close()
-	default:
-		utils.Error.Printf("Unknown state storage type = %s", stateDbType)
+		defer historyStorage.Close()
 	}
 
 	dataChan := make(chan string)
 	backendChan := make(chan string)
 	subscriptionChan := make(chan int)
 	historyAccessChannel = make(chan string)
+	historyControlChannel = make(chan historyCtrlRequest)
 	CLChannel = make(chan CLPack, 5) // allow some buffering...
 	subscriptionList := []SubscriptionState{}
+	setTimeoutChan := make(chan int)
+	actuatorTargets = NewActuatorTargets()
+	signalStatistics = NewSignalStatistics()
 	subscriptionId = 1 // do not start with zero!
+	durableTicker := time.NewTicker(time.Second) // expiry and saving of detached subscriptions
+	if durableConfig.Grace > 0 {
+		subscriptionList, subscriptionId = restoreDurableSubscriptions(subscriptionChan, subscriptionId)
+	} else {
+		durableTicker.Stop()
+	}
 
 	var serverCoreIP string = utils.GetModelIP(2)
 
-	vss_data := getVssPathList(serverCoreIP, 8081, "/vsspathlist")
+	vss_data := getVssPathList(serverCoreIP, "/vsspathlist")
 	go initDataServer(serviceMgrChan, dataChan, backendChan)
 	if historySupport {
 		go historyServer(historyAccessChannel, vss_data)
 	}
-	var dummyTicker *time.Ticker
-	if stateDbType != "none" {
-		dummyTicker = time.NewTicker(47 * time.Millisecond)
+	changeChan := make(chan string, 100)
+	subscriptTicker := time.NewTicker(23 * time.Millisecond) //range/change subscriptions, if the state storage cannot notify changes
+	if err := stateStorage.Subscribe(changeChan); err != nil {
+		utils.Info.Printf("Change notifications from state storage not available, range/change subscriptions are polled. Err=%s", err)
+	} else {
+		subscriptTicker.Stop()
 	}
-	subscriptTicker := time.NewTicker(23 * time.Millisecond) //range/change subscriptions
 
 	for {
 		select {
@@ -1017,20 +1062,69 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 			if requestMap["handle"] != nil {
 				responseMap["authorization"] = requestMap["handle"]
 			}
+			vin, _ := requestMap["vin"].(string)
 			switch requestMap["action"] {
-			case "set":
-				if strings.Contains(requestMap["path"].(string), "[") == true {
-					utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
+			case "history-control":
+				if len(vin) > 0 {
+					utils.SetErrorResponse(requestMap, errorResponseMap, 7, "History control is only supported for the vehicle of the server.") //service_unavailable
 					dataChan <- utils.FinalizeMessage(errorResponseMap)
 					break
 				}
-				ts := setVehicleData(requestMap["path"].(string), requestMap["value"].(string))
+				if !historySupport {
+					utils.SetErrorResponse(requestMap, errorResponseMap, 7, "History is not supported.") //service_unavailable
+					dataChan <- utils.FinalizeMessage(errorResponseMap)
+					break
+				}
+				captures, errorIndex, errorMessage := historyControl(requestMap)
+				if errorIndex != -1 {
+					utils.SetErrorResponse(requestMap, errorResponseMap, errorIndex, errorMessage)
+					dataChan <- utils.FinalizeMessage(errorResponseMap)
+					break
+				}
+				if requestMap["command"] == "list" {
+					dataChan <- addPackage(utils.FinalizeMessage(responseMap), "captures", captures)
+					break
+				}
+				dataChan <- utils.FinalizeMessage(responseMap)
+			case "set":
+				ts := ""
+				var batch []utils.PathValue
+				if valueList, ok := requestMap["value"].([]interface{}); ok { // multi-path set, all or nothing
+					batch = unpackPathValues(valueList)
+					if batch == nil {
+						utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
+						dataChan <- utils.FinalizeMessage(errorResponseMap)
+						break
+					}
+					for i := range batch {
+						batch[i].Path = vinPath(vin, batch[i].Path)
+					}
+					ts = setVehicleDataBatch(batch)
+				} else {
+					if strings.Contains(requestMap["path"].(string), "[") == true {
+						utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
+						dataChan <- utils.FinalizeMessage(errorResponseMap)
+						break
+					}
+					batch = []utils.PathValue{{Path: vinPath(vin, requestMap["path"].(string)), Value: requestMap["value"].(string)}}
+					ts = setVehicleData(batch[0].Path, batch[0].Value)
+				}
 				if len(ts) == 0 {
 					utils.SetErrorResponse(requestMap, errorResponseMap, 7, "") //service_unavailable
 					dataChan <- utils.FinalizeMessage(errorResponseMap)
 					break
 				}
+				actuatorTargets.SetDesired(batch, ts)
 				responseMap["ts"] = ts
+				if timeout, ok := requestMap["timeout"].(string); ok { // respond when the actuators have reached the set values
+					unreached := unreachedTargets(batch, AllPathsChanged)
+					if len(unreached) > 0 {
+						timeoutMsec, _ := strconv.Atoi(timeout)
+						actuatorTargets.AddPending(responseMap, unreached, time.Duration(timeoutMsec)*time.Millisecond, setTimeoutChan)
+						dataChan <- "" // the response is sent via backendChan
+						break
+					}
+				}
 				dataChan <- utils.FinalizeMessage(responseMap)
 			case "get":
 				pathArray := unpackPaths(requestMap["path"].(string))
@@ -1049,13 +1143,8 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 						dataChan <- utils.FinalizeMessage(errorResponseMap)
 						break
 					}
-					if filterList[0].Type == "dynamic-metadata" && filterList[0].Parameter == "server_capabilities" {
-						metadataPack := `{"filter":["paths","timebased","change","range","curvelog","history","dynamic-metadata","static-metadata"],"access_ctrl":["short_term","long_term","signalset_claim"],"transport_protocol":["https","wss","mqtts"]}`
-						dataChan <- addPackage(utils.FinalizeMessage(responseMap), "metadata", metadataPack)
-						break
-					}
 				}
-				dataPack := getDataPack(pathArray, filterList)
+				dataPack := getDataPack(vin, pathArray, filterList)
 				if len(dataPack) == 0 {
 					utils.Info.Printf("No historic data available")
 					utils.SetErrorResponse(requestMap, errorResponseMap, 6, "") //unavailable_data
@@ -1064,6 +1153,21 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 				}
 				dataChan <- addPackage(utils.FinalizeMessage(responseMap), "data", dataPack)
 			case "subscribe":
+				if resumeToken, ok := requestMap["resumeToken"].(string); ok {
+					index, missed := resumeSubscription(subscriptionList, resumeToken, requestMap["RouterId"].(string))
+					if index == -1 {
+						utils.SetErrorResponse(requestMap, errorResponseMap, 1, "Unknown or expired resume token, or subscription not detached.") //invalid_data
+						dataChan <- utils.FinalizeMessage(errorResponseMap)
+						break
+					}
+					responseMap["subscriptionId"] = strconv.Itoa(subscriptionList[index].SubscriptionId)
+					responseMap["resumeToken"] = resumeToken
+					dataChan <- utils.FinalizeMessage(responseMap)
+					for _, notification := range missed { // sent after the response
+						backendChan <- notification
+					}
+					break
+				}
 				var subscriptionState SubscriptionState
 				subscriptionState.SubscriptionId = subscriptionId
 				subscriptionState.RouterId = requestMap["RouterId"].(string)
@@ -1081,10 +1185,25 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 				if requestMap["gatingId"] != nil {
 					subscriptionState.GatingId = requestMap["gatingId"].(string)
 				}
-				subscriptionState.LatestDataPoint = getVehicleData(subscriptionState.Path[0])
+				subscriptionState.Vin = vin
+				if len(vin) > 0 && hasFilter(subscriptionState.FilterList, "curvelog") {
+					utils.SetErrorResponse(requestMap, errorResponseMap, 7, "Curve logging is only supported for the vehicle of the server.") //service_unavailable
+					dataChan <- utils.FinalizeMessage(errorResponseMap)
+					break
+				}
+				if activateIfIntervalOrCL(subscriptionState.FilterList, subscriptionChan, CLChannel, subscriptionId, subscriptionState.Path) != nil {
+					utils.SetErrorResponse(requestMap, errorResponseMap, 7, "Max number of timebased subscriptions reached.") //service_unavailable
+					dataChan <- utils.FinalizeMessage(errorResponseMap)
+					break
+				}
+				subscriptionState.LatestDataPoint = getVehicleData(vinPath(vin, subscriptionState.Path[0]))
+				if durableConfig.Grace > 0 {
+					subscriptionState.ResumeToken = newResumeToken()
+					responseMap["resumeToken"] = subscriptionState.ResumeToken
+					durableDirty = true
+				}
 				subscriptionList = append(subscriptionList, subscriptionState)
 				responseMap["subscriptionId"] = strconv.Itoa(subscriptionId)
-				activateIfIntervalOrCL(subscriptionState.FilterList, subscriptionChan, CLChannel, subscriptionId, subscriptionState.Path)
 				subscriptionId++ // not to be incremented elsewhere
 				dataChan <- utils.FinalizeMessage(responseMap)
 			case "unsubscribe":
@@ -1094,6 +1213,7 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 					if ok == true {
 						status, subscriptionList = deactivateSubscription(subscriptionList, subscriptId)
 						if status != -1 {
+							durableDirty = true
 							responseMap["subscriptionId"] = subscriptId
 							dataChan <- utils.FinalizeMessage(responseMap)
 							break
@@ -1104,6 +1224,11 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 				utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
 				dataChan <- utils.FinalizeMessage(errorResponseMap)
 			case "internal-killsubscriptions":
+				if durableConfig.Grace > 0 {
+					detached := detachSubscriptions(subscriptionList, requestMap["RouterId"].(string), time.Now())
+					utils.Info.Printf("%d subscriptions detached, waiting for resume", detached)
+					break
+				}
 				isRemoved := true
 				for isRemoved == true {
 					isRemoved, subscriptionList = scanAndRemoveListItem(subscriptionList, requestMap["RouterId"].(string))
@@ -1123,21 +1248,20 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 				utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
 				dataChan <- utils.FinalizeMessage(errorResponseMap)
 			} // switch
-		case <-dummyTicker.C:
-			dummyValue++
-			if dummyValue > 999 {
-				dummyValue = 0
-			}
 		case subThreads := <-threadsChan:
 			subscriptionList = setSubscriptionListThreads(subscriptionList, subThreads)
 		case subscriptionId := <-subscriptionChan: // interval notification triggered
-			subscriptionState := subscriptionList[getSubcriptionStateIndex(subscriptionId, subscriptionList)]
+			index := getSubcriptionStateIndex(subscriptionId, subscriptionList)
+			if index == -1 { // unsubscribed after the interval expired
+				break
+			}
+			subscriptionState := subscriptionList[index]
 			var subscriptionMap = make(map[string]interface{})
 			subscriptionMap["action"] = "subscription"
 			subscriptionMap["ts"] = utils.GetRfcTime()
 			subscriptionMap["subscriptionId"] = strconv.Itoa(subscriptionState.SubscriptionId)
 			subscriptionMap["RouterId"] = subscriptionState.RouterId
-			backendChan <- addPackage(utils.FinalizeMessage(subscriptionMap), "data", getDataPack(subscriptionState.Path, nil))
+			deliverNotification(&subscriptionList[index], addPackage(utils.FinalizeMessage(subscriptionMap), "data", getDataPack(subscriptionState.Vin, subscriptionState.Path, nil)), backendChan)
 		case clPack := <-CLChannel: // curve logging notification
 			index := getSubcriptionStateIndex(clPack.SubscriptionId, subscriptionList)
 			//subscriptionState := subscriptionList[index]
@@ -1151,30 +1275,59 @@ func ServiceMgrInit(mgrId int, serviceMgrChan chan string, stateStorageType stri
 			subscriptionMap["ts"] = utils.GetRfcTime()
 			subscriptionMap["subscriptionId"] = strconv.Itoa(subscriptionList[index].SubscriptionId)
 			subscriptionMap["RouterId"] = subscriptionList[index].RouterId
-			backendChan <- addPackage(utils.FinalizeMessage(subscriptionMap), "data", clPack.DataPack)
+			deliverNotification(&subscriptionList[index], addPackage(utils.FinalizeMessage(subscriptionMap), "data", clPack.DataPack), backendChan)
+		case changedPath := <-changeChan:
+			subscriptionList = checkRangeChangeSubscriptions(subscriptionList, changedPath, backendChan)
+			checkPendingSets(changedPath, backendChan)
+			signalStatistics.Observe(changedPath)
 		case <-subscriptTicker.C:
-			// check if range or change notification triggered
-			for i := range subscriptionList {
-				//				triggerDataPoint := getVehicleData(subscriptionList[i].Path[0])
-				doTrigger, updateLatest, triggerDataPoint := checkRangeChangeFilter(subscriptionList[i].FilterList, subscriptionList[i].LatestDataPoint, subscriptionList[i].Path[0])
-				if updateLatest == true {
-					subscriptionList[i].LatestDataPoint = triggerDataPoint
-				}
-				if doTrigger == true {
-					subscriptionState := subscriptionList[i]
-					var subscriptionMap = make(map[string]interface{})
-					subscriptionMap["action"] = "subscription"
-					subscriptionMap["ts"] = utils.GetRfcTime()
-					subscriptionMap["subscriptionId"] = strconv.Itoa(subscriptionState.SubscriptionId)
-					subscriptionMap["RouterId"] = subscriptionState.RouterId
-					subscriptionList[i].LatestDataPoint = triggerDataPoint
-					backendChan <- addPackage(utils.FinalizeMessage(subscriptionMap), "data", getDataPack(subscriptionList[i].Path, nil))
-				}
+			subscriptionList = checkRangeChangeSubscriptions(subscriptionList, AllPathsChanged, backendChan)
+			checkPendingSets(AllPathsChanged, backendChan)
+			signalStatistics.Observe(AllPathsChanged)
+		case <-durableTicker.C:
+			subscriptionList = expireDetachedSubscriptions(subscriptionList, time.Now())
+			saveDurableSubscriptions(subscriptionList)
+		case pendingId := <-setTimeoutChan:
+			if requestMap := actuatorTargets.RemovePending(pendingId); requestMap != nil {
+				utils.SetErrorResponse(requestMap, errorResponseMap, 8, "Actuator did not reach the set value within the timeout.") //request_timeout
+				backendChan <- utils.FinalizeMessage(errorResponseMap)
 			}
 		} // select
 	} // for
 }
 
+// respond to the sets that waited for the actuators on changedPath to reach the set values
+func checkPendingSets(changedPath string, backendChan chan string) {
+	for _, responseMap := range actuatorTargets.CheckPending(changedPath) {
+		backendChan <- utils.FinalizeMessage(responseMap)
+	}
+}
+
+// check if range or change notification triggered for subscriptions on changedPath
+func checkRangeChangeSubscriptions(subscriptionList []SubscriptionState, changedPath string, backendChan chan string) []SubscriptionState {
+	for i := range subscriptionList {
+		storagePath := vinPath(subscriptionList[i].Vin, subscriptionList[i].Path[0])
+		if changedPath != AllPathsChanged && storagePath != changedPath {
+			continue
+		}
+		doTrigger, updateLatest, triggerDataPoint := checkRangeChangeFilter(subscriptionList[i].FilterList, subscriptionList[i].LatestDataPoint, storagePath)
+		if updateLatest == true {
+			subscriptionList[i].LatestDataPoint = triggerDataPoint
+		}
+		if doTrigger == true {
+			subscriptionState := subscriptionList[i]
+			var subscriptionMap = make(map[string]interface{})
+			subscriptionMap["action"] = "subscription"
+			subscriptionMap["ts"] = utils.GetRfcTime()
+			subscriptionMap["subscriptionId"] = strconv.Itoa(subscriptionState.SubscriptionId)
+			subscriptionMap["RouterId"] = subscriptionState.RouterId
+			subscriptionList[i].LatestDataPoint = triggerDataPoint
+			deliverNotification(&subscriptionList[i], addPackage(utils.FinalizeMessage(subscriptionMap), "data", getDataPack(subscriptionList[i].Vin, subscriptionList[i].Path, nil)), backendChan)
+		}
+	}
+	return subscriptionList
+}
+
 func getSubscriptionData(subscriptionList []SubscriptionState, gatingId string) (string, string) {
 	for i := 0; i < len(subscriptionList); i++ {
 		if subscriptionList[i].GatingId == gatingId {
@@ -0,0 +1,36 @@
+package serviceMgr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func TestVehiclePartition(t *testing.T) {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	stateStorage = mapStorage{"Vehicle.Speed": "10", "VIN001:Vehicle.Speed": "50", "VIN002:Vehicle.Speed": "70"}
+	if dataPack := getDataPack("VIN001", []string{"Vehicle.Speed"}, nil); dataPack != `{"path":"Vehicle.Speed", "dp":{"value":"50", "ts":"2023-01-01T00:00:00Z"}}` {
+		t.Errorf("Unexpected data pack of VIN001 %s", dataPack)
+	}
+	if dataPack := getDataPack("", []string{"Vehicle.Speed"}, nil); !strings.Contains(dataPack, `"value":"10"`) {
+		t.Errorf("Unexpected data pack of the server vehicle %s", dataPack)
+	}
+
+	changeFilter := []utils.FilterObject{{Type: "change", Parameter: `{"logic-op":"ne", "diff":"0"}`}}
+	subscriptionList := []SubscriptionState{
+		{SubscriptionId: 1, RouterId: "2?1", Path: []string{"Vehicle.Speed"}, FilterList: changeFilter, LatestDataPoint: `{"value":"40", "ts":"2023-01-01T00:00:00Z"}`, Vin: "VIN001"},
+		{SubscriptionId: 2, RouterId: "2?2", Path: []string{"Vehicle.Speed"}, FilterList: changeFilter, LatestDataPoint: `{"value":"40", "ts":"2023-01-01T00:00:00Z"}`, Vin: "VIN002"},
+	}
+	backendChan := make(chan string, 10)
+	checkRangeChangeSubscriptions(subscriptionList, "VIN001:Vehicle.Speed", backendChan)
+	if len(backendChan) != 1 {
+		t.Fatalf("Expected one notification, got %d", len(backendChan))
+	}
+	if notification := <-backendChan; !strings.Contains(notification, `"subscriptionId":"1"`) || !strings.Contains(notification, `"value":"50"`) {
+		t.Errorf("Expected the notification of VIN001, got %s", notification)
+	}
+	if subscriptionList[1].LatestDataPoint != `{"value":"40", "ts":"2023-01-01T00:00:00Z"}` {
+		t.Errorf("Subscription of VIN002 triggered by a change of VIN001")
+	}
+}
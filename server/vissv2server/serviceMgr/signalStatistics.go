@@ -0,0 +1,136 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* Observes the updates of signals in the state storage, from which the samplerate and availability dynamic metadata are derived.
+* A signal is observed from its first change notification, or from the first time its metadata is requested.
+* When the state storage only reports that all paths may have changed, an update is a change of the data point of an observed signal.
+ */
+type SignalStatistics struct {
+	lock    sync.Mutex
+	signals map[string]*SignalUpdates
+}
+
+type SignalUpdates struct {
+	LatestDataPoint string
+	LatestUpdate    time.Time
+	MeanInterval    time.Duration // exponentially weighted
+	Updates         int
+}
+
+// A signal is stale if it has not been written for this long, or for three mean update intervals if that is longer.
+var AvailabilityTimeout = 10 * time.Second
+
+var signalStatistics *SignalStatistics
+
+func NewSignalStatistics() *SignalStatistics {
+	return &SignalStatistics{signals: make(map[string]*SignalUpdates)}
+}
+
+func (stats *SignalStatistics) Observe(changedPath string) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	if changedPath != AllPathsChanged {
+		stats.observePath(changedPath, true)
+		return
+	}
+	for path := range stats.signals {
+		stats.observePath(path, false)
+	}
+}
+
+func (stats *SignalStatistics) observePath(path string, changed bool) {
+	dataPoint := getVehicleData(path)
+	signal, ok := stats.signals[path]
+	if !ok {
+		signal = &SignalUpdates{}
+		stats.signals[path] = signal
+	}
+	if !changed && dataPoint == signal.LatestDataPoint {
+		return
+	}
+	now := time.Now()
+	if signal.Updates == 1 {
+		signal.MeanInterval = now.Sub(signal.LatestUpdate)
+	} else if signal.Updates > 1 {
+		signal.MeanInterval = (7*signal.MeanInterval + now.Sub(signal.LatestUpdate)) / 8
+	}
+	signal.LatestDataPoint = dataPoint
+	signal.LatestUpdate = now
+	signal.Updates++
+}
+
+// Starts observing a signal without counting its current data point as an update.
+func (stats *SignalStatistics) track(path string) *SignalUpdates {
+	signal, ok := stats.signals[path]
+	if !ok {
+		signal = &SignalUpdates{LatestDataPoint: getVehicleData(path)}
+		stats.signals[path] = signal
+	}
+	return signal
+}
+
+// Returns the update rate, which decreases if the signal has not been updated for longer than its mean interval, or "unknown" before two updates are observed.
+func (stats *SignalStatistics) SampleRate(path string) string {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	signal := stats.track(path)
+	if signal.Updates < 2 || signal.MeanInterval <= 0 {
+		return "unknown"
+	}
+	interval := signal.MeanInterval
+	if sinceLatest := time.Since(signal.LatestUpdate); sinceLatest > interval {
+		interval = sinceLatest
+	}
+	return strconv.FormatFloat(float64(time.Second)/float64(interval), 'g', 3, 64) + " Hz"
+}
+
+// Returns "available" if the signal has been written recently, "stale" if it has been written but not recently, else "not_available".
+func (stats *SignalStatistics) Availability(path string) string {
+	stats.lock.Lock()
+	signal := stats.track(path)
+	timeout := AvailabilityTimeout
+	if 3*signal.MeanInterval > timeout {
+		timeout = 3 * signal.MeanInterval
+	}
+	stats.lock.Unlock()
+	var dataPoint map[string]interface{}
+	if err := json.Unmarshal([]byte(getVehicleData(path)), &dataPoint); err != nil {
+		return "not_available"
+	}
+	value, _ := dataPoint["value"].(string)
+	ts, _ := dataPoint["ts"].(string)
+	if len(value) == 0 || strings.HasPrefix(value, "Data-") || len(ts) == 0 { // Data-not-available, Data-not-found, Data-error
+		return "not_available"
+	}
+	writeTime, err := parseDpTs(ts)
+	if err != nil {
+		utils.Warning.Printf("Availability: could not parse ts=%s of path=%s", ts, path)
+		return "not_available"
+	}
+	if time.Since(writeTime) > timeout {
+		return "stale"
+	}
+	return "available"
+}
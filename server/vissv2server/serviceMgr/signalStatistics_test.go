@@ -0,0 +1,38 @@
+package serviceMgr
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignalStatisticsSampleRate(t *testing.T) {
+	store := mapStorage{"Vehicle.Speed": "0"}
+	stateStorage = store
+	stats := NewSignalStatistics()
+	if rate := stats.SampleRate("Vehicle.Speed"); rate != "unknown" {
+		t.Errorf("Expected unknown rate before updates, got %s", rate)
+	}
+	for i := 1; i <= 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		store["Vehicle.Speed"] = strconv.Itoa(i)
+		stats.Observe(AllPathsChanged)
+		stats.Observe(AllPathsChanged) // no change, not an update
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(stats.SampleRate("Vehicle.Speed"), " Hz"), 64)
+	if err != nil || rate < 5 || rate > 55 {
+		t.Errorf("Expected a rate of about 50 Hz, got %f, err=%v", rate, err)
+	}
+}
+
+func TestSignalStatisticsAvailability(t *testing.T) {
+	stateStorage = mapStorage{"Vehicle.Speed": "50"} // written 2023-01-01
+	stats := NewSignalStatistics()
+	if availability := stats.Availability("Vehicle.Speed"); availability != "stale" {
+		t.Errorf("Expected stale, got %s", availability)
+	}
+	if availability := stats.Availability("Vehicle.Width"); availability != "not_available" {
+		t.Errorf("Expected not_available, got %s", availability)
+	}
+}
@@ -0,0 +1,141 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* Keeps the history in an SQLite DB file, which is created if it does not exist, so recordings and samples survive a restart of the server.
+* Sample times are saved as epoch msecs, which the time range queries use together with an index on path.
+ */
+type SqliteHistoryStorage struct {
+	dbHandle *sql.DB
+}
+
+const sqliteHistorySchema = "CREATE TABLE IF NOT EXISTS HISTORY_RECORDING (path TEXT PRIMARY KEY, frequency INTEGER, buf_size INTEGER, retention_ms INTEGER, active INTEGER);" +
+	"CREATE TABLE IF NOT EXISTS HISTORY_SAMPLE (path TEXT, value TEXT, ts TEXT, ts_ms INTEGER);" +
+	"CREATE INDEX IF NOT EXISTS HISTORY_SAMPLE_PATH_TIME ON HISTORY_SAMPLE (path, ts_ms);"
+
+func init() {
+	RegisterHistoryStorage("sqlite", newSqliteHistoryStorage)
+}
+
+func newSqliteHistoryStorage(config HistoryStorageConfig) (HistoryStorage, error) {
+	dbHandle, err := sql.Open("sqlite3", config.DbFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dbHandle.Exec(sqliteHistorySchema); err != nil {
+		dbHandle.Close()
+		return nil, err
+	}
+	utils.Info.Printf("SQLite history storage initialised, file = %s", config.DbFile)
+	return &SqliteHistoryStorage{dbHandle: dbHandle}, nil
+}
+
+func (store *SqliteHistoryStorage) SaveRecording(recording HistoryRecording) error {
+	_, err := store.dbHandle.Exec("INSERT OR REPLACE INTO HISTORY_RECORDING (path, frequency, buf_size, retention_ms, active) VALUES (?, ?, ?, ?, ?)",
+		recording.Path, recording.Frequency, recording.BufSize, recording.Retention.Milliseconds(), recording.Active)
+	return err
+}
+
+func (store *SqliteHistoryStorage) LoadRecordings() ([]HistoryRecording, error) {
+	rows, err := store.dbHandle.Query("SELECT path, frequency, buf_size, retention_ms, active FROM HISTORY_RECORDING")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var recordings []HistoryRecording
+	for rows.Next() {
+		var recording HistoryRecording
+		var retentionMs int64
+		if err = rows.Scan(&recording.Path, &recording.Frequency, &recording.BufSize, &retentionMs, &recording.Active); err != nil {
+			return nil, err
+		}
+		recording.Retention = time.Duration(retentionMs) * time.Millisecond
+		recordings = append(recordings, recording)
+	}
+	return recordings, rows.Err()
+}
+
+func (store *SqliteHistoryStorage) DeleteRecording(path string) error {
+	tx, err := store.dbHandle.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op after commit
+	if _, err = tx.Exec("DELETE FROM HISTORY_SAMPLE WHERE path=?", path); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM HISTORY_RECORDING WHERE path=?", path); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (store *SqliteHistoryStorage) Append(path string, sample HistorySample) error {
+	_, err := store.dbHandle.Exec("INSERT INTO HISTORY_SAMPLE (path, value, ts, ts_ms) VALUES (?, ?, ?, ?)", path, sample.Value, sample.Ts, sample.Time.UnixMilli())
+	return err
+}
+
+func (store *SqliteHistoryStorage) Query(path string, start time.Time, end time.Time) ([]HistorySample, error) {
+	rows, err := store.dbHandle.Query("SELECT value, ts, ts_ms FROM HISTORY_SAMPLE WHERE path=? AND ts_ms BETWEEN ? AND ? ORDER BY ts_ms, rowid",
+		path, start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var samples []HistorySample
+	for rows.Next() {
+		var sample HistorySample
+		var tsMs int64
+		if err = rows.Scan(&sample.Value, &sample.Ts, &tsMs); err != nil {
+			return nil, err
+		}
+		sample.Time = time.UnixMilli(tsMs)
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
+func (store *SqliteHistoryStorage) Count(path string) (int, error) {
+	var count int
+	err := store.dbHandle.QueryRow("SELECT COUNT(*) FROM HISTORY_SAMPLE WHERE path=?", path).Scan(&count)
+	return count, err
+}
+
+func (store *SqliteHistoryStorage) Prune(recording HistoryRecording, now time.Time) error {
+	if recording.Retention > 0 {
+		_, err := store.dbHandle.Exec("DELETE FROM HISTORY_SAMPLE WHERE path=? AND ts_ms<?", recording.Path, now.Add(-recording.Retention).UnixMilli())
+		if err != nil {
+			return err
+		}
+	}
+	if recording.BufSize > 0 {
+		_, err := store.dbHandle.Exec("DELETE FROM HISTORY_SAMPLE WHERE path=? AND rowid NOT IN "+
+			"(SELECT rowid FROM HISTORY_SAMPLE WHERE path=? ORDER BY ts_ms DESC, rowid DESC LIMIT ?)", recording.Path, recording.Path, recording.BufSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *SqliteHistoryStorage) Close() {
+	store.dbHandle.Close()
+}
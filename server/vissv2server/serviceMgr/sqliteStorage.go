@@ -0,0 +1,285 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+type SqliteStorage struct {
+	dbHandle   *sql.DB
+	changeChan chan string
+	rowPaths   map[int64]string // signal_id -> path, used to resolve update hook calls
+	latestTs   map[string]string
+	done       chan struct{}
+	partitions map[string]bool // VINs of the vehicle partitions that have rows
+	partLock   sync.Mutex
+}
+
+const sqliteDriverName = "sqlite3_vissv2"
+
+// Update hooks only see writes made through this process' own connections, so they are set on all connections of the server driver.
+var sqliteUpdateHookChan chan int64
+var sqliteUpdateHookLock sync.Mutex
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.RegisterUpdateHook(sqliteUpdateHook)
+			return nil
+		},
+	})
+	RegisterStateStorage("sqlite", newSqliteStorage)
+}
+
+func sqliteUpdateHook(op int, db string, table string, rowid int64) {
+	if op != sqlite3.SQLITE_UPDATE || table != "VSS_MAP" {
+		return
+	}
+	sqliteUpdateHookLock.Lock()
+	defer sqliteUpdateHookLock.Unlock()
+	if sqliteUpdateHookChan != nil {
+		select {
+		case sqliteUpdateHookChan <- rowid:
+		default: // the hook must not block the writer, the data_version watcher will catch up
+		}
+	}
+}
+
+func newSqliteStorage(config StateStorageConfig) (StateStorage, error) {
+	if !utils.FileExists(config.DbFile) {
+		return nil, errors.New("could not find state storage file = " + config.DbFile)
+	}
+	dbHandle, err := sql.Open(sqliteDriverName, config.DbFile)
+	if err != nil {
+		return nil, err
+	}
+	utils.Info.Printf("SQLite state storage initialised.")
+	return &SqliteStorage{dbHandle: dbHandle, partitions: make(map[string]bool)}, nil
+}
+
+/*
+* The signals of a vehicle partition, see vinPath(), are rows of their own, where the path is prefixed by the VIN.
+* The rows are created from the unprefixed rows when the partition is first accessed, if the feeder has not created them.
+ */
+func (store *SqliteStorage) createPartition(storagePath string) error {
+	vin, _ := splitVinPath(storagePath)
+	if len(vin) == 0 {
+		return nil
+	}
+	store.partLock.Lock()
+	defer store.partLock.Unlock()
+	if store.partitions[vin] {
+		return nil
+	}
+	_, err := store.dbHandle.Exec("INSERT INTO VSS_MAP (`path`) SELECT ? || `path` FROM VSS_MAP WHERE instr(`path`, ':')=0 "+
+		"AND NOT EXISTS (SELECT 1 FROM VSS_MAP WHERE substr(`path`, 1, ?)=?)", vin+":", len(vin)+1, vin+":")
+	if err != nil {
+		utils.Error.Printf("Could not create statestorage partition of %s, err = %s", vin, err)
+		return err
+	}
+	store.partitions[vin] = true
+	return nil
+}
+
+func (store *SqliteStorage) Get(path string) string {
+	if store.createPartition(path) != nil {
+		return `{"value":"Data-error", "ts":"` + utils.GetRfcTime() + `"}`
+	}
+	rows, err := store.dbHandle.Query("SELECT `c_value`, `c_ts` FROM VSS_MAP WHERE `path`=?", path)
+	if err != nil {
+		return `{"value":"Data-error", "ts":"` + utils.GetRfcTime() + `"}`
+	}
+	defer rows.Close()
+	value := ""
+	timestamp := ""
+
+	rows.Next()
+	err = rows.Scan(&value, &timestamp)
+	if err != nil {
+		utils.Warning.Printf("Data not found: %s for path=%s\n", err, path)
+		return `{"value":"Data-not-available", "ts":"` + utils.GetRfcTime() + `"}`
+	}
+	return `{"value":"` + value + `", "ts":"` + timestamp + `"}`
+}
+
+func (store *SqliteStorage) Set(path string, value string) string {
+	ts := utils.GetRfcTime()
+	if store.createPartition(path) != nil {
+		return ""
+	}
+	stmt, err := store.dbHandle.Prepare("UPDATE VSS_MAP SET d_value=?, d_ts=? WHERE `path`=?")
+	if err != nil {
+		utils.Error.Printf("Could not prepare for statestorage updating, err = %s", err)
+		return ""
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(value, ts, path)
+	if err != nil {
+		utils.Error.Printf("Could not update statestorage, err = %s", err)
+		return ""
+	}
+	return ts
+}
+
+// The batch is written in one transaction, which is rolled back if any of the paths is not found.
+func (store *SqliteStorage) SetBatch(batch []utils.PathValue) string {
+	ts := utils.GetRfcTime()
+	for _, pathValue := range batch {
+		if store.createPartition(pathValue.Path) != nil {
+			return ""
+		}
+	}
+	tx, err := store.dbHandle.Begin()
+	if err != nil {
+		utils.Error.Printf("Could not begin statestorage transaction, err = %s", err)
+		return ""
+	}
+	defer tx.Rollback() // no-op after commit
+	stmt, err := tx.Prepare("UPDATE VSS_MAP SET d_value=?, d_ts=? WHERE `path`=?")
+	if err != nil {
+		utils.Error.Printf("Could not prepare for statestorage updating, err = %s", err)
+		return ""
+	}
+	defer stmt.Close()
+
+	for _, pathValue := range batch {
+		result, err := stmt.Exec(pathValue.Value, ts, pathValue.Path)
+		if err != nil {
+			utils.Error.Printf("Could not update statestorage, err = %s", err)
+			return ""
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			utils.Error.Printf("Could not update statestorage, path not found = %s", pathValue.Path)
+			return ""
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		utils.Error.Printf("Could not commit statestorage transaction, err = %s", err)
+		return ""
+	}
+	return ts
+}
+
+/*
+* Writes made by the server itself are reported by the SQLite update hook.
+* Feeders write from other processes, which the update hook cannot see, so a dedicated connection watches PRAGMA data_version,
+* which only changes when another connection has committed a write, and the table is then scanned for changed timestamps.
+ */
+func (store *SqliteStorage) Subscribe(changeChan chan string) error {
+	store.changeChan = changeChan
+	store.rowPaths = make(map[int64]string)
+	store.latestTs = make(map[string]string)
+	rows, err := store.dbHandle.Query("SELECT `signal_id`, `path`, IFNULL(`c_ts`, '') FROM VSS_MAP")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var rowid int64
+		var path, ts string
+		if err = rows.Scan(&rowid, &path, &ts); err != nil {
+			rows.Close()
+			return err
+		}
+		store.rowPaths[rowid] = path
+		store.latestTs[path] = ts
+	}
+	rows.Close()
+	watchConn, err := store.dbHandle.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	store.done = make(chan struct{})
+	hookChan := make(chan int64, 100)
+	sqliteUpdateHookLock.Lock()
+	sqliteUpdateHookChan = hookChan
+	sqliteUpdateHookLock.Unlock()
+	go store.changeWatcher(watchConn, hookChan)
+	return nil
+}
+
+func (store *SqliteStorage) changeWatcher(watchConn *sql.Conn, hookChan chan int64) {
+	defer watchConn.Close()
+	dataVersion := store.getDataVersion(watchConn)
+	versionTicker := time.NewTicker(23 * time.Millisecond)
+	defer versionTicker.Stop()
+	for {
+		select {
+		case rowid := <-hookChan:
+			path, ok := store.rowPaths[rowid]
+			if !ok { // a row of a partition that was created after the subscribe
+				if ok = watchConn.QueryRowContext(context.Background(), "SELECT `path` FROM VSS_MAP WHERE `signal_id`=?", rowid).Scan(&path) == nil; ok {
+					store.rowPaths[rowid] = path
+				}
+			}
+			if ok {
+				store.changeChan <- path
+			}
+		case <-versionTicker.C:
+			newVersion := store.getDataVersion(watchConn)
+			if newVersion != dataVersion {
+				dataVersion = newVersion
+				store.notifyChangedRows(watchConn)
+			}
+		case <-store.done:
+			return
+		}
+	}
+}
+
+func (store *SqliteStorage) getDataVersion(watchConn *sql.Conn) int64 {
+	var dataVersion int64
+	err := watchConn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&dataVersion)
+	if err != nil {
+		utils.Error.Printf("SQLite data_version query failed, err = %s", err)
+	}
+	return dataVersion
+}
+
+func (store *SqliteStorage) notifyChangedRows(watchConn *sql.Conn) {
+	rows, err := watchConn.QueryContext(context.Background(), "SELECT `path`, IFNULL(`c_ts`, '') FROM VSS_MAP")
+	if err != nil {
+		utils.Error.Printf("SQLite change scan failed, err = %s", err)
+		return
+	}
+	var changedPaths []string
+	for rows.Next() {
+		var path, ts string
+		if rows.Scan(&path, &ts) == nil && store.latestTs[path] != ts {
+			store.latestTs[path] = ts
+			changedPaths = append(changedPaths, path)
+		}
+	}
+	rows.Close()
+	for _, path := range changedPaths {
+		store.changeChan <- path
+	}
+}
+
+func (store *SqliteStorage) Close() {
+	if store.done != nil {
+		sqliteUpdateHookLock.Lock()
+		sqliteUpdateHookChan = nil
+		sqliteUpdateHookLock.Unlock()
+		close(store.done)
+	}
+	store.dbHandle.Close()
+}
@@ -0,0 +1,96 @@
+package serviceMgr
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func openTestSqliteStorage(t *testing.T) StateStorage {
+	utils.InitLog("servicemgr-log.txt", t.TempDir(), false, "error")
+	dbFile := filepath.Join(t.TempDir(), "statestorage.db")
+	db, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatalf("Could not create test DB, err=%s", err)
+	}
+	_, err = db.Exec("CREATE TABLE VSS_MAP (signal_id INTEGER PRIMARY KEY, path TEXT, c_value TEXT, c_ts TEXT, d_value TEXT, d_ts TEXT);" +
+		"INSERT INTO VSS_MAP (path, c_value, c_ts) VALUES ('Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature', '20', ''), ('Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed', '0', '');")
+	db.Close()
+	if err != nil {
+		t.Fatalf("Could not populate test DB, err=%s", err)
+	}
+	store, err := openStateStorage("sqlite", StateStorageConfig{DbFile: dbFile})
+	if err != nil {
+		t.Fatalf("Could not open state storage, err=%s", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func getDesiredValue(t *testing.T, store StateStorage, path string) string {
+	var value sql.NullString
+	err := store.(*SqliteStorage).dbHandle.QueryRow("SELECT `d_value` FROM VSS_MAP WHERE `path`=?", path).Scan(&value)
+	if err != nil {
+		t.Fatalf("Could not read %s, err=%s", path, err)
+	}
+	return value.String
+}
+
+func TestSqliteSetBatch(t *testing.T) {
+	store := openTestSqliteStorage(t)
+	batch := []utils.PathValue{
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature", Value: "22"},
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed", Value: "40"},
+	}
+	if ts := store.SetBatch(batch); len(ts) == 0 {
+		t.Fatalf("SetBatch failed")
+	}
+	for _, pathValue := range batch {
+		if value := getDesiredValue(t, store, pathValue.Path); value != pathValue.Value {
+			t.Errorf("%s: expected %s, got %s", pathValue.Path, pathValue.Value, value)
+		}
+	}
+
+	batch = []utils.PathValue{
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature", Value: "18"},
+		{Path: "Vehicle.Cabin.HVAC.Station.Row1.Driver.AirDistribution", Value: "UP"}, // not in the state storage
+	}
+	if ts := store.SetBatch(batch); len(ts) != 0 {
+		t.Fatalf("SetBatch with unknown path succeeded")
+	}
+	if value := getDesiredValue(t, store, batch[0].Path); value != "22" {
+		t.Errorf("Failed batch was partially written, expected 22, got %s", value)
+	}
+}
+
+func TestSqlitePartition(t *testing.T) {
+	store := openTestSqliteStorage(t)
+	path := vinPath("VIN001", "Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature")
+	if dp := store.Get(path); dp[:len(`{"value":"Data-not-available"`)] != `{"value":"Data-not-available"` {
+		t.Errorf("Get of a signal that the feeder has not written, got %s", dp)
+	}
+	if ts := store.Set(path, "22"); len(ts) == 0 {
+		t.Fatalf("Set of a partition path failed")
+	}
+	if value := getDesiredValue(t, store, path); value != "22" {
+		t.Errorf("%s: expected 22, got %s", path, value)
+	}
+	if value := getDesiredValue(t, store, "Vehicle.Cabin.HVAC.Station.Row1.Driver.Temperature"); value != "" {
+		t.Errorf("Set of a partition path written to the vehicle of the server, got %s", value)
+	}
+	store.(*SqliteStorage).dbHandle.Exec("UPDATE VSS_MAP SET c_value='21', c_ts='2023-05-01T00:00:00Z' WHERE `path`=?", path) // the feeder
+	if dp := store.Get(path); dp != `{"value":"21", "ts":"2023-05-01T00:00:00Z"}` {
+		t.Errorf("Get of a partition path, got %s", dp)
+	}
+	batch := []utils.PathValue{{Path: vinPath("VIN002", "Vehicle.Cabin.HVAC.Station.Row1.Driver.FanSpeed"), Value: "40"}}
+	if ts := store.SetBatch(batch); len(ts) == 0 {
+		t.Errorf("SetBatch of a new partition failed")
+	}
+	var rows int
+	store.(*SqliteStorage).dbHandle.QueryRow("SELECT COUNT(*) FROM VSS_MAP").Scan(&rows)
+	if rows != 6 {
+		t.Errorf("Expected 2 rows of each of the vehicle, VIN001 and VIN002, got %d rows", rows)
+	}
+}
@@ -0,0 +1,81 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2022 Geotab Inc
+* (C) 2021 Mitsubishi Electrics Automotive
+* (C) 2019 Geotab Inc
+* (C) 2019 Volvo Cars
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package serviceMgr
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+/*
+* A state storage implementation is selected at startup by the --statestorage name it is registered under.
+* Get returns a data point formatted as {"value":"Y", "ts":"Z"}, and Set returns the timestamp of the write, or an empty string on failure.
+* SetBatch writes all the path/value pairs, with a common timestamp that is returned, or none of them, in which case an empty string is returned.
+* Subscribe asks the storage to send the path of every signal that changes on changeChan, or AllPathsChanged if it cannot tell which.
+* An implementation that cannot detect changes returns ErrChangeSubscriptionNotSupported, in which case the service manager polls.
+ */
+type StateStorage interface {
+	Get(path string) string
+	Set(path string, value string) string
+	SetBatch(batch []utils.PathValue) string
+	Subscribe(changeChan chan string) error
+	Close()
+}
+
+type StateStorageConfig struct {
+	DbFile string // only used by file based storages, e.g. sqlite
+}
+
+type StateStorageFactory func(config StateStorageConfig) (StateStorage, error)
+
+const AllPathsChanged = "*"
+
+var ErrChangeSubscriptionNotSupported = errors.New("change subscription not supported by state storage")
+
+var stateStorageRegistry = map[string]StateStorageFactory{}
+var stateStorageRegistryLock sync.Mutex
+
+// Makes a state storage implementation selectable by name. Typically called from an init() function.
+func RegisterStateStorage(name string, factory StateStorageFactory) {
+	stateStorageRegistryLock.Lock()
+	defer stateStorageRegistryLock.Unlock()
+	if _, exists := stateStorageRegistry[name]; exists {
+		panic("RegisterStateStorage: called twice for " + name)
+	}
+	stateStorageRegistry[name] = factory
+}
+
+// Returns the sorted names of all registered state storage implementations.
+func StateStorageNames() []string {
+	stateStorageRegistryLock.Lock()
+	defer stateStorageRegistryLock.Unlock()
+	names := make([]string, 0, len(stateStorageRegistry))
+	for name := range stateStorageRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func openStateStorage(name string, config StateStorageConfig) (StateStorage, error) {
+	stateStorageRegistryLock.Lock()
+	factory, exists := stateStorageRegistry[name]
+	stateStorageRegistryLock.Unlock()
+	if !exists {
+		return nil, errors.New("unknown state storage type = " + name)
+	}
+	return factory(config)
+}
@@ -0,0 +1,96 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	gomodel "github.com/COVESA/vss-tools/binary/go_parser/datamodel"
+	golib "github.com/COVESA/vss-tools/binary/go_parser/parserlib"
+)
+
+/*
+* Checks a set value against the datatype, min, max, and allowed values of the node in the VSS tree.
+* Array datatypes, e.g. uint8[], take a JSON array in the string value, where each element is checked. Datatypes that are not known, e.g. structs, are not checked.
+* The returned error names the violated constraint.
+ */
+func validateSetValue(nodeHandle *gomodel.Node_t, value string) error {
+	datatype := golib.VSSgetDatatype(nodeHandle)
+	if !strings.HasSuffix(datatype, "[]") {
+		return validateSetElement(nodeHandle, datatype, value)
+	}
+	var elementList []interface{}
+	if err := json.Unmarshal([]byte(value), &elementList); err != nil {
+		return errors.New("datatype " + datatype + " requires a JSON array value")
+	}
+	for i := range elementList {
+		element, isString := elementList[i].(string)
+		if !isString {
+			jsonElement, err := json.Marshal(elementList[i])
+			if err != nil {
+				return err
+			}
+			element = string(jsonElement)
+		}
+		if err := validateSetElement(nodeHandle, datatype[:len(datatype)-2], element); err != nil {
+			return errors.New("element " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+func validateSetElement(nodeHandle *gomodel.Node_t, datatype string, value string) error {
+	var err error
+	switch datatype {
+	case "int8", "int16", "int32", "int64":
+		bitSize, _ := strconv.Atoi(datatype[3:])
+		_, err = strconv.ParseInt(value, 10, bitSize)
+	case "uint8", "uint16", "uint32", "uint64":
+		bitSize, _ := strconv.Atoi(datatype[4:])
+		_, err = strconv.ParseUint(value, 10, bitSize)
+	case "float":
+		_, err = strconv.ParseFloat(value, 32)
+	case "double":
+		_, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		if value != "true" && value != "false" {
+			err = strconv.ErrSyntax
+		}
+	}
+	if err != nil {
+		return errors.New("value " + strconv.Quote(value) + " is not of datatype " + datatype)
+	}
+	if nodeHandle.Min != "" || nodeHandle.Max != "" {
+		numValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("value " + strconv.Quote(value) + " is not a number, as required by min/max")
+		}
+		if min, err := strconv.ParseFloat(nodeHandle.Min, 64); err == nil && numValue < min {
+			return errors.New("value " + value + " is below min " + nodeHandle.Min)
+		}
+		if max, err := strconv.ParseFloat(nodeHandle.Max, 64); err == nil && numValue > max {
+			return errors.New("value " + value + " is above max " + nodeHandle.Max)
+		}
+	}
+	numOfAllowed := golib.VSSgetNumOfAllowedElements(nodeHandle)
+	if numOfAllowed > 0 {
+		allowed := make([]string, numOfAllowed)
+		for i := 0; i < numOfAllowed; i++ {
+			allowed[i] = golib.VSSgetAllowedElement(nodeHandle, i)
+			if allowed[i] == value {
+				return nil
+			}
+		}
+		return errors.New("value " + strconv.Quote(value) + " is not one of the allowed values [" + strings.Join(allowed, ", ") + "]")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	gomodel "github.com/COVESA/vss-tools/binary/go_parser/datamodel"
+)
+
+func TestValidateSetValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		node       gomodel.Node_t
+		value      string
+		constraint string // part of the error message, empty if the value is valid
+	}{
+		{"int", gomodel.Node_t{Datatype: "int16"}, "-300", ""},
+		{"int overflow", gomodel.Node_t{Datatype: "int8"}, "300", "datatype int8"},
+		{"uint negative", gomodel.Node_t{Datatype: "uint8"}, "-1", "datatype uint8"},
+		{"float", gomodel.Node_t{Datatype: "float"}, "21.5", ""},
+		{"float not a number", gomodel.Node_t{Datatype: "float"}, "warm", "datatype float"},
+		{"boolean", gomodel.Node_t{Datatype: "boolean"}, "true", ""},
+		{"boolean wrong", gomodel.Node_t{Datatype: "boolean"}, "1", "datatype boolean"},
+		{"min max", gomodel.Node_t{Datatype: "uint8", Min: "0", Max: "100"}, "100", ""},
+		{"above max", gomodel.Node_t{Datatype: "uint8", Min: "0", Max: "100"}, "101", "max 100"},
+		{"below min", gomodel.Node_t{Datatype: "float", Min: "16.5"}, "16", "min 16.5"},
+		{"allowed", gomodel.Node_t{Datatype: "string", Allowed: 2, AllowedDef: []string{"AUTO", "MANUAL"}}, "AUTO", ""},
+		{"not allowed", gomodel.Node_t{Datatype: "string", Allowed: 2, AllowedDef: []string{"AUTO", "MANUAL"}}, "OFF", "allowed values"},
+		{"array", gomodel.Node_t{Datatype: "uint8[]", Max: "10"}, "[1, 2, 10]", ""},
+		{"array element above max", gomodel.Node_t{Datatype: "uint8[]", Max: "10"}, "[1, 11]", "element 1"},
+		{"array not an array", gomodel.Node_t{Datatype: "uint8[]"}, "1", "JSON array"},
+		{"unknown datatype", gomodel.Node_t{Datatype: "Types.Position"}, "{}", ""},
+	}
+	for _, test := range tests {
+		test.node.NodeType = gomodel.ACTUATOR
+		err := validateSetValue(&test.node, test.value)
+		if len(test.constraint) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.constraint) {
+			t.Errorf("%s: expected error on %s, got %v", test.name, test.constraint, err)
+		}
+	}
+}
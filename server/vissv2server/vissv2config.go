@@ -0,0 +1,298 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/w3c/automotive-viss2/server/vissv2server/atServer"
+	"github.com/w3c/automotive-viss2/server/vissv2server/mqttMgr"
+	"github.com/w3c/automotive-viss2/server/vissv2server/serviceMgr"
+	"github.com/w3c/automotive-viss2/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// The version of the configuration file format that this server reads
+const configVersion = 1
+
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+
+/*
+* The server configuration, read from the vissv2server.yaml file. Members that are left out get the default values, and command line parameters override them.
+* The listeners are read from the server_config file if the listeners section is left out. The other JSON files are referenced by name, and read by the components.
+ */
+type VissConfig struct {
+	Version       int                 `yaml:"version"`
+	Log           LogConfig           `yaml:"log"`
+	VssPathList   string              `yaml:"vss_path_list"` // the path list file that is generated at startup
+	StateStorage  StorageConfig       `yaml:"state_storage"`
+	History       HistoryConfig       `yaml:"history"`
+	Consent       bool                `yaml:"consent_support"`
+	AccessTokens  AccessTokenConfig   `yaml:"access_tokens"`
+	Subscriptions SubscriptionConfig  `yaml:"subscriptions"`
+	ClientQueue   QueueConfig         `yaml:"client_queue"`
+	Listeners     *utils.ServerConfig `yaml:"listeners,omitempty"`
+	Files         FileConfig          `yaml:"files"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+	File  bool   `yaml:"file"` // log to a file in the ./logs directory instead of standard output
+}
+
+type StorageConfig struct {
+	Type   string      `yaml:"type"`
+	DbFile string      `yaml:"db_file"` // SQLite only
+	Iotdb  IotdbConfig `yaml:"iotdb"`   // apache-iotdb only
+}
+
+type IotdbConfig struct {
+	Host      string `yaml:"host"`
+	Port      string `yaml:"port"`
+	User      string `yaml:"user"`
+	Password  string `yaml:"password"`
+	Prefix    string `yaml:"prefix"` // prefix path of the VSS nodes in the database
+	TimeoutMs int64  `yaml:"timeout_ms"`
+}
+
+type HistoryConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Storage string `yaml:"storage"`
+	DbFile  string `yaml:"db_file"`
+}
+
+type AccessTokenConfig struct {
+	SigningAlgorithm string                  `yaml:"signing_algorithm"`
+	SigningKeyDir    string                  `yaml:"signing_key_dir"` // generated keys are saved here
+	KeyRotation      string                  `yaml:"key_rotation"`    // Go duration, empty or 0 disables rotation
+	ReplayStore      utils.ReplayStoreConfig `yaml:"replay_store"`    // the JWT ids of accepted PoPs
+}
+
+type SubscriptionConfig struct {
+	MaxTimers    int    `yaml:"max_timers"`    // 0 means no limit
+	ResumeGrace  int    `yaml:"resume_grace"`  // secs, 0 disables resumption
+	ResumeBuffer int    `yaml:"resume_buffer"` // 0 means no limit
+	File         string `yaml:"file"`          // resumable subscriptions are not saved if empty
+}
+
+type QueueConfig struct {
+	Depth  int    `yaml:"depth"` // 0 means no limit
+	Policy string `yaml:"policy"`
+}
+
+type FileConfig struct {
+	ServerConfig    string `yaml:"server_config"`
+	MqttConfig      string `yaml:"mqtt_config"`
+	TransportSecDir string `yaml:"transport_sec_dir"` // the directory of transportSec.json, which the credential paths in it are relative to
+	UdsRegistration string `yaml:"uds_registration"`
+	PurposeList     string `yaml:"purpose_list"`
+	ScopeList       string `yaml:"scope_list"`
+	SignalDimension string `yaml:"signal_dimension"`
+	AgtPublicKey    string `yaml:"agt_public_key"`  // the key of the trusted access grant token server
+	AdminToken      string `yaml:"admin_token"`     // the bearer token file of the admin endpoints, which are disabled if empty
+	RevocationList  string `yaml:"revocation_list"` // the revoked access tokens, which are only kept in memory if empty
+}
+
+func defaultVissConfig() VissConfig {
+	return VissConfig{
+		Version:     configVersion,
+		Log:         LogConfig{Level: "info"},
+		VssPathList: "../vsspathlist.json",
+		StateStorage: StorageConfig{Type: "redis", DbFile: "serviceMgr/statestorage.db",
+			Iotdb: IotdbConfig{Host: "iotdb-service", Port: "6667", User: "root", Password: "root", Prefix: "root.test2.dev1", TimeoutMs: 3000}},
+		History: HistoryConfig{Storage: "sqlite", DbFile: "serviceMgr/history.db"},
+		AccessTokens: AccessTokenConfig{SigningAlgorithm: "RS256", SigningKeyDir: "atServer/signing_keys",
+			ReplayStore: utils.ReplayStoreConfig{Type: "sqlite", DbFile: utils.DefaultReplayStoreFile, MaxEntries: 100000}},
+		Subscriptions: SubscriptionConfig{MaxTimers: 10000, ResumeBuffer: 100},
+		ClientQueue:   QueueConfig{Depth: 100, Policy: "drop-oldest"},
+		Files: FileConfig{ServerConfig: "server-config.json", MqttConfig: "mqtt-config.json", TransportSecDir: "../transport_sec",
+			UdsRegistration: "uds-registration.json", PurposeList: "atServer/purposelist.json", ScopeList: "atServer/scopelist.json",
+			SignalDimension: "signaldimension.json", AgtPublicKey: "agt_public_key.rsa", RevocationList: "atServer/revocationlist.json"},
+	}
+}
+
+// Reads the configuration file. The default configuration is returned if the file does not exist, unless it is required.
+func readVissConfig(fileName string, required bool) (VissConfig, error) {
+	config := defaultVissConfig()
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return config, nil
+		}
+		return config, err
+	}
+	var sections map[string]interface{}
+	if err = yaml.Unmarshal(data, &sections); err != nil {
+		return config, fmt.Errorf("%s: %s", fileName, err)
+	}
+	if _, ok := sections["listeners"]; ok { // left out members of it get the default values
+		listeners := utils.DefaultServerConfig()
+		config.Listeners = &listeners
+	}
+	config.Version = 0 // must be set by the file
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&config); err != nil && err != io.EOF {
+		return config, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return config, nil
+}
+
+// Reads the listeners from the server_config file, unless they are in the configuration file.
+func (config *VissConfig) resolveListeners() error {
+	if config.Listeners != nil {
+		return nil
+	}
+	listeners, err := utils.ReadServerConfig(config.Files.ServerConfig)
+	if err != nil {
+		return fmt.Errorf("files.server_config: %s", err)
+	}
+	config.Listeners = &listeners
+	return nil
+}
+
+// Returns all errors of the configuration, one per line, or nil. The listeners must have been resolved.
+func (config VissConfig) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(config.Version != 0, "version: missing, must be %d", configVersion)
+	check(config.Version == 0 || config.Version == configVersion, "version: %d is not supported, must be %d", config.Version, configVersion)
+	check(slices.Contains(logLevels, config.Log.Level), "log.level: %s is not one of %s", config.Log.Level, strings.Join(logLevels, ", "))
+	check(len(config.VssPathList) > 0, "vss_path_list: missing")
+	check(slices.Contains(serviceMgr.StateStorageNames(), config.StateStorage.Type), "state_storage.type: %s is not one of %s",
+		config.StateStorage.Type, strings.Join(serviceMgr.StateStorageNames(), ", "))
+	check(len(config.StateStorage.DbFile) > 0, "state_storage.db_file: missing")
+	check(config.StateStorage.Iotdb.TimeoutMs >= 0, "state_storage.iotdb.timeout_ms: must not be negative")
+	check(slices.Contains(serviceMgr.HistoryStorageNames(), config.History.Storage), "history.storage: %s is not one of %s",
+		config.History.Storage, strings.Join(serviceMgr.HistoryStorageNames(), ", "))
+	check(len(config.History.DbFile) > 0, "history.db_file: missing")
+	check(slices.Contains(atServer.SigningAlgorithms(), config.AccessTokens.SigningAlgorithm), "access_tokens.signing_algorithm: %s is not one of %s",
+		config.AccessTokens.SigningAlgorithm, strings.Join(atServer.SigningAlgorithms(), ", "))
+	check(len(config.AccessTokens.SigningKeyDir) > 0, "access_tokens.signing_key_dir: missing")
+	if _, err := config.AccessTokens.keyRotation(); err != nil {
+		errs = append(errs, fmt.Errorf("access_tokens.key_rotation: %s", err))
+	}
+	replayStore := config.AccessTokens.ReplayStore
+	check(slices.Contains(utils.ReplayStoreTypes(), replayStore.Type), "access_tokens.replay_store.type: %s is not one of %s",
+		replayStore.Type, strings.Join(utils.ReplayStoreTypes(), ", "))
+	check(replayStore.Type != "sqlite" || len(replayStore.DbFile) > 0, "access_tokens.replay_store.db_file: missing")
+	check(replayStore.Type != "redis" || len(replayStore.RedisAddress) > 0, "access_tokens.replay_store.redis_address: missing")
+	check(replayStore.MaxEntries > 0, "access_tokens.replay_store.max_entries: must be positive")
+	check(config.Subscriptions.MaxTimers >= 0, "subscriptions.max_timers: must not be negative")
+	check(config.Subscriptions.ResumeGrace >= 0, "subscriptions.resume_grace: must not be negative")
+	check(config.Subscriptions.ResumeBuffer >= 0, "subscriptions.resume_buffer: must not be negative")
+	check(config.ClientQueue.Depth >= 0, "client_queue.depth: must not be negative")
+	check(slices.Contains(utils.QueuePolicies, config.ClientQueue.Policy), "client_queue.policy: %s is not one of %s",
+		config.ClientQueue.Policy, strings.Join(utils.QueuePolicies, ", "))
+	if err := config.Listeners.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("listeners.%s", err))
+	}
+	errs = append(errs, config.validateFiles()...)
+	return errors.Join(errs...)
+}
+
+// The referenced JSON files must be well-formed. Missing files are only errors for the purpose list if the access token server is enabled, and for the admin token if it is set.
+func (config VissConfig) validateFiles() []error {
+	var errs []error
+	references := []struct {
+		key      string
+		fileName string
+		required bool
+	}{
+		{"files.purpose_list", config.Files.PurposeList, config.Listeners.Ats.Enabled},
+		{"files.scope_list", config.Files.ScopeList, false},
+		{"files.uds_registration", config.Files.UdsRegistration, false},
+		{"files.signal_dimension", config.Files.SignalDimension, false},
+		{"files.transport_sec_dir", filepath.Join(config.Files.TransportSecDir, "transportSec.json"), false},
+	}
+	for _, reference := range references {
+		data, err := os.ReadFile(reference.fileName)
+		if err != nil {
+			if reference.required || !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("%s: %s", reference.key, err))
+			}
+		} else if !json.Valid(data) {
+			errs = append(errs, fmt.Errorf("%s: %s is not valid JSON", reference.key, reference.fileName))
+		}
+	}
+	if len(config.Files.AdminToken) > 0 {
+		if data, err := os.ReadFile(config.Files.AdminToken); err != nil {
+			errs = append(errs, fmt.Errorf("files.admin_token: %s", err))
+		} else if len(strings.TrimSpace(string(data))) == 0 {
+			errs = append(errs, fmt.Errorf("files.admin_token: %s is empty", config.Files.AdminToken))
+		}
+	}
+	if config.Listeners.Mqtt.Enabled {
+		if err := mqttMgr.CheckConfig(config.Files.MqttConfig); err != nil {
+			errs = append(errs, fmt.Errorf("files.mqtt_config: %s: %s", config.Files.MqttConfig, err))
+		}
+	}
+	return errs
+}
+
+// Sets the configuration of the server components, before they are started.
+func (config VissConfig) apply() {
+	utils.ClientQueueDepth = config.ClientQueue.Depth
+	utils.ClientQueuePolicy = config.ClientQueue.Policy
+	utils.ServerConfiguration = *config.Listeners
+	utils.TrSecConfigPath = strings.TrimSuffix(config.Files.TransportSecDir, "/") + "/"
+	mqttMgr.ConfigFile = config.Files.MqttConfig
+	serviceMgr.UdsRegistrationFile = config.Files.UdsRegistration
+	serviceMgr.SignalDimensionFile = config.Files.SignalDimension
+	serviceMgr.IoTDBconfig.Host = config.StateStorage.Iotdb.Host
+	serviceMgr.IoTDBconfig.Port = config.StateStorage.Iotdb.Port
+	serviceMgr.IoTDBconfig.UserName = config.StateStorage.Iotdb.User
+	serviceMgr.IoTDBconfig.Password = config.StateStorage.Iotdb.Password
+	serviceMgr.IoTDbPrefixPath = config.StateStorage.Iotdb.Prefix
+	serviceMgr.IoTDbTimeout = config.StateStorage.Iotdb.TimeoutMs
+	atServer.PurposeListFile = config.Files.PurposeList
+	atServer.ScopeListFile = config.Files.ScopeList
+	atServer.AgtPublicKeyFile = config.Files.AgtPublicKey
+	atServer.AdminTokenFile = config.Files.AdminToken
+	atServer.RevocationListFile = config.Files.RevocationList
+	atServer.SigningAlgorithm = config.AccessTokens.SigningAlgorithm
+	atServer.SigningKeyDir = config.AccessTokens.SigningKeyDir
+	atServer.KeyRotationPeriod, _ = config.AccessTokens.keyRotation()
+	atServer.ReplayStoreConfig = config.AccessTokens.ReplayStore
+}
+
+func (config AccessTokenConfig) keyRotation() (time.Duration, error) {
+	if len(config.KeyRotation) == 0 {
+		return 0, nil
+	}
+	period, err := time.ParseDuration(config.KeyRotation)
+	if err == nil && period < 0 {
+		err = fmt.Errorf("%s is negative", config.KeyRotation)
+	}
+	return period, err
+}
+
+// Writes the effective configuration as YAML, with the password masked.
+func (config VissConfig) print(w io.Writer) error {
+	if len(config.StateStorage.Iotdb.Password) > 0 {
+		config.StateStorage.Iotdb.Password = "********"
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	return encoder.Encode(config)
+}
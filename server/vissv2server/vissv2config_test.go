@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+func writeConfig(t *testing.T, dir string, name string, content string) string {
+	fileName := filepath.Join(dir, name)
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed, err=%s", err)
+	}
+	return fileName
+}
+
+func TestReadVissConfig(t *testing.T) {
+	utils.InitLog("servercore-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	if config, err := readVissConfig(filepath.Join(dir, "missing.yaml"), false); err != nil || config.Version != configVersion || config.Listeners != nil {
+		t.Errorf("Expected the default configuration, got %+v, err=%v", config, err)
+	}
+	if _, err := readVissConfig(filepath.Join(dir, "missing.yaml"), true); err == nil {
+		t.Errorf("Missing configuration file accepted when required")
+	}
+	if _, err := readVissConfig(writeConfig(t, dir, "unknown.yaml", "version: 1\nclient_queue:\n  dept: 10\n"), true); err == nil || !strings.Contains(err.Error(), "dept") {
+		t.Errorf("Expected an error on the unknown member dept, got %v", err)
+	}
+
+	fileName := writeConfig(t, dir, "vissv2server.yaml", `version: 1
+state_storage:
+  type: sqlite
+client_queue:
+  policy: coalesce
+listeners:
+  http:
+    enabled: true
+    address: "[::1]:18888"
+  core:
+    enabled: true
+    address: unix:`+filepath.Join(dir, "core.sock")+`
+files:
+  purpose_list: `+writeConfig(t, dir, "purposelist.json", `{"purposes":[]}`)+`
+`)
+	config, err := readVissConfig(fileName, true)
+	if err == nil {
+		err = config.resolveListeners()
+	}
+	if err != nil {
+		t.Fatalf("readVissConfig failed, err=%s", err)
+	}
+	if config.StateStorage.Type != "sqlite" || config.StateStorage.DbFile != "serviceMgr/statestorage.db" || config.ClientQueue.Policy != "coalesce" || config.ClientQueue.Depth != 100 {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	if config.AccessTokens.ReplayStore.DbFile != utils.DefaultReplayStoreFile { // the AGT server default, so that the servers share the store
+		t.Errorf("Unexpected replay store %+v", config.AccessTokens.ReplayStore)
+	}
+	if config.Listeners.Http.Address != "[::1]:18888" || config.Listeners.Ws != utils.DefaultServerConfig().Ws || config.Listeners.Core.ReadTimeout != "15s" {
+		t.Errorf("Unexpected listeners %+v", *config.Listeners)
+	}
+	if err = config.validate(); err != nil {
+		t.Errorf("validate failed, err=%s", err)
+	}
+
+	var printed bytes.Buffer
+	if err = config.print(&printed); err != nil || strings.Contains(printed.String(), "password: root") {
+		t.Fatalf("Unexpected printed configuration %s, err=%v", printed.String(), err)
+	}
+	reread, err := readVissConfig(writeConfig(t, dir, "printed.yaml", printed.String()), true)
+	if err != nil || reread.Listeners.Http.Address != "[::1]:18888" || reread.ClientQueue.Policy != "coalesce" {
+		t.Errorf("Printed configuration not read back, got %+v, err=%v", reread, err)
+	}
+}
+
+func TestValidateVissConfig(t *testing.T) {
+	utils.InitLog("servercore-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	config := defaultVissConfig()
+	config.Version = 2
+	config.Log.Level = "loud"
+	config.Subscriptions.MaxTimers = -1
+	config.AccessTokens.SigningAlgorithm = "HS256"
+	config.AccessTokens.KeyRotation = "monthly"
+	config.AccessTokens.ReplayStore.Type = "redis"
+	listeners := utils.DefaultServerConfig()
+	listeners.Grpc.Address = "localhost"
+	config.Listeners = &listeners
+	config.Files.PurposeList = filepath.Join(dir, "missing.json")
+	config.Files.ScopeList = writeConfig(t, dir, "scopelist.json", `{"scopes":`)
+	config.Files.UdsRegistration = filepath.Join(dir, "missing.json") // optional
+	config.Files.AdminToken = writeConfig(t, dir, "admintoken", "\n")
+	err := config.validate()
+	if err == nil {
+		t.Fatalf("Invalid configuration accepted")
+	}
+	for _, key := range []string{"version:", "log.level:", "subscriptions.max_timers:", "access_tokens.signing_algorithm:", "access_tokens.key_rotation:", "access_tokens.replay_store.redis_address:", "listeners.grpc:", "files.purpose_list:", "files.scope_list:", "files.admin_token:"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected an error on %s, got %s", key, err)
+		}
+	}
+	if strings.Contains(err.Error(), "files.uds_registration") {
+		t.Errorf("Missing optional file reported, got %s", err)
+	}
+
+	config = defaultVissConfig()
+	listeners.Grpc.Address = ":8887"
+	listeners.Ats.Enabled = false
+	config.Listeners = &listeners
+	config.Files.PurposeList = filepath.Join(dir, "missing.json")
+	config.Files.ScopeList = filepath.Join(dir, "missing.json")
+	if err = config.validate(); err != nil {
+		t.Errorf("Purpose list required without access token server, err=%s", err)
+	}
+}
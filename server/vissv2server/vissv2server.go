@@ -27,6 +27,7 @@ import (
 	"encoding/json"
 	//"io/ioutil"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -49,15 +50,8 @@ var VSSTreeRoot *gomodel.Node_t
 // set to MAXFOUNDNODES in cparserlib.h
 const MAXFOUNDNODES = 1500
 
-// the server components started as threads by vissv2server. If a component is commented out, it will not be started
-var serverComponents []string = []string{
-	"serviceMgr",
-	"httpMgr",
-	"wsMgr",
-	//	"mqttMgr",  //to avoid calls to the mosquitto broker if not used anyway
-	"grpcMgr",
-	"atServer",
-}
+// the transports that can be enabled or disabled in the server configuration, or on the command line
+var transportNames = []string{"http", "ws", "mqtt", "grpc", "ats"}
 
 /*
  * For communication between transport manager threads and vissv2server thread.
@@ -182,6 +176,14 @@ func searchTree(rootNode *gomodel.Node_t, path string, anyDepth bool, leafNodesO
 	return 0, nil
 }
 
+// A trailing "**" matches all nodes below, which the tree search does for a trailing "*" when searching at any depth.
+func wildcardToSearchPath(path string) string {
+	if strings.HasSuffix(path, ".**") {
+		return path[:len(path)-1]
+	}
+	return path
+}
+
 func getPathLen(path string) int {
 	for i := 0; i < len(path); i++ {
 		if path[i] == 0x00 { // the path buffer defined in searchData_t is initiated with all zeros
@@ -355,6 +357,23 @@ func jsonifyTreeNode(nodeHandle *gomodel.Node_t, jsonBuffer string, depth int, m
 	return jsonBuffer + newJsonBuffer
 }
 
+// nativeCnodeDef.h: the validate property is 0=none, 1=write-only, 2=read-write, plus 10 if consent is required
+func validationToString(validation int) string {
+	validationStr := ""
+	switch validation % 10 {
+	case 1:
+		validationStr = "write-only"
+	case 2:
+		validationStr = "read-write"
+	default:
+		return "none"
+	}
+	if validation/10 == 1 {
+		validationStr += "+consent"
+	}
+	return validationStr
+}
+
 func countPathSegments(path string) int {
 	return strings.Count(path, ".") + 1
 }
@@ -466,106 +485,6 @@ func getTokenContext(reqMap map[string]interface{}) string {
 	return ""
 }
 
-func validRequest(request string, action string) bool {
-	switch action {
-	case "get":
-		return isValidGetParams(request)
-	case "set":
-		return isValidSetParams(request)
-	case "subscribe":
-		return isValidSubscribeParams(request)
-	case "unsubscribe":
-		return isValidUnsubscribeParams(request)
-	case "internal-killsubscriptions":
-		return true
-	case "internal-cancelsubscription":
-		return true
-	}
-	return false
-}
-
-func isValidGetParams(request string) bool {
-	if strings.Contains(request, "path") == false {
-		return false
-	}
-	if strings.Contains(request, "filter") == true {
-		return isValidGetFilter(request)
-	}
-	return true
-}
-
-func isValidGetFilter(request string) bool { // paths, history,static-metadata, dynamic-metadata supported
-	if strings.Contains(request, "paths") == true {
-		if strings.Contains(request, "parameter") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "history") == true {
-		if strings.Contains(request, "parameter") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "static-metadata") == true {
-		if strings.Contains(request, "parameter") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "dynamic-metadata") == true {
-		if strings.Contains(request, "parameter") == true {
-			return true
-		}
-	}
-	return false
-}
-
-func isValidSetParams(request string) bool {
-	return strings.Contains(request, "path") && strings.Contains(request, "value")
-}
-
-func isValidSubscribeParams(request string) bool {
-	if strings.Contains(request, "path") == false {
-		return false
-	}
-	if strings.Contains(request, "filter") == true {
-		return isValidSubscribeFilter(request)
-	}
-	return true
-}
-
-func isValidSubscribeFilter(request string) bool { // paths, history, timebased, range, change, curvelog, static-metadata, dynamic-metadata supported
-	if isValidGetFilter(request) == true {
-		return true
-	}
-	if strings.Contains(request, "timebased") == true {
-		if strings.Contains(request, "parameter") == true && strings.Contains(request, "period") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "range") == true {
-		if strings.Contains(request, "parameter") == true && strings.Contains(request, "logic-op") == true &&
-			strings.Contains(request, "boundary") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "change") == true {
-		if strings.Contains(request, "parameter") == true && strings.Contains(request, "logic-op") == true &&
-			strings.Contains(request, "diff") == true {
-			return true
-		}
-	}
-	if strings.Contains(request, "curvelog") == true {
-		if strings.Contains(request, "parameter") == true && strings.Contains(request, "maxerr") == true &&
-			strings.Contains(request, "bufsize") == true {
-			return true
-		}
-	}
-	return false
-}
-
-func isValidUnsubscribeParams(request string) bool {
-	return strings.Contains(request, "subscriptionId")
-}
-
 // Receives a request (json containing path, action, token, routerId....) and calls
 // the appropriate function to handle the request
 func serveRequest(request string, tDChanIndex int, sDChanIndex int) {
@@ -577,28 +496,29 @@ func serveRequest(request string, tDChanIndex int, sDChanIndex int) {
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
-	if requestMap["action"] == nil || validRequest(request, requestMap["action"].(string)) == false {
-		utils.Error.Printf("serveRequest():invalid action params=%s", requestMap["action"])
-		utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
+	vissRequest, err := utils.ParseVissRequest(request)
+	if err != nil {
+		utils.Error.Printf("serveRequest():invalid request, %s", err)
+		utils.SetErrorResponse(requestMap, errorResponseMap, 1, err.Error()) //invalid_data
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
-	if requestMap["path"] != nil && strings.Contains(requestMap["path"].(string), "*") == true {
-		utils.Error.Printf("serveRequest():path contained wildcard=%s", requestMap["path"])
-		utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
+	if len(vissRequest.Vin) > 0 && tDChanIndex != 2 { // only the MQTT mgr serves several vehicles, see the VIN topics of it
+		utils.Error.Printf("serveRequest():vin in request from transport mgr %d", tDChanIndex)
+		utils.SetErrorResponse(requestMap, errorResponseMap, 1, "Unknown member vin.") //invalid_data
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
-	if requestMap["path"] != nil {
-		requestMap["path"] = utils.UrlToPath(requestMap["path"].(string)) // replace slash with dot
+	if vissRequest.Path != nil {
+		requestMap["path"] = utils.UrlToPath(*vissRequest.Path) // replace slash with dot
 	}
-	if requestMap["action"] == "set" && requestMap["filter"] != nil {
+	if vissRequest.Action == "set" && len(vissRequest.Filter) > 0 {
 		utils.Error.Printf("serveRequest():Set request combined with filtering.")
 		utils.SetErrorResponse(requestMap, errorResponseMap, 0, "") //bad_request
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
-	if requestMap["action"] == "unsubscribe" {
+	if vissRequest.Action == "unsubscribe" || (vissRequest.Action == "subscribe" && vissRequest.ResumeToken != nil) { // access was granted at subscribe
 		serviceDataChan[sDChanIndex] <- request
 		return
 	}
@@ -612,6 +532,7 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 	}
 	rootPath := requestMap["path"].(string)
 	var searchPath []string
+	getValidation := false // dynamic metadata that is read from the VSS tree
 
 	// Manages Filter Request
 	if requestMap["filter"] != nil {
@@ -661,30 +582,69 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 				return
 			}
 			// DYNAMIC METADATA FILTER
-			if filterList[i].Type == "dynamic-metadata" && filterList[i].Parameter == "server_capabilities" {
-				serviceDataChan[sDChanIndex] <- utils.FinalizeMessage(requestMap) // no further verification
+			if filterList[i].Type == "dynamic-metadata" && filterList[i].Parameter == "server_capabilities" { // no further verification
+				delete(requestMap, "path")
+				delete(requestMap, "filter")
+				requestMap["ts"] = utils.GetRfcTime()
+				backendChan[tDChanIndex] <- utils.AddKeyValue(utils.FinalizeMessage(requestMap), "metadata", serverCapabilities)
 				return
 			}
+			if filterList[i].Type == "dynamic-metadata" && filterList[i].Parameter == "validate" {
+				getValidation = true
+			}
 		}
 	}
 	if requestMap["filter"] == nil || len(searchPath) == 0 {
 		searchPath = make([]string, 1)
 		searchPath[0] = rootPath
 	}
+	valueList, isMultiSet := requestMap["value"].([]interface{}) // multi-path set, where the paths are relative to the request path
+	if requestMap["action"] == "set" && isMultiSet {
+		searchPath = make([]string, len(valueList))
+		for i := 0; i < len(valueList); i++ {
+			searchPath[i] = rootPath + "." + utils.UrlToPath(valueList[i].(map[string]interface{})["path"].(string))
+		}
+	}
 	var searchData []golib.SearchData_t
 	var matches int
 	totalMatches := 0
 	paths := ""
 	maxValidation := -1
+	validationPack := ""
 	for i := 0; i < len(searchPath); i++ {
 		anyDepth := true
 		validation := -1
-		matches, searchData = searchTree(VSSTreeRoot, searchPath[i], anyDepth, true, 0, nil, &validation)
+		matches, searchData = searchTree(VSSTreeRoot, wildcardToSearchPath(searchPath[i]), anyDepth, true, 0, nil, &validation)
 		//utils.Info.Printf("Path=%s, Matches=%d. Max validation from search=%d", searchPath[i], matches, int(validation))
 		utils.Info.Printf("Matches=%d. Max validation from search=%d", matches, int(validation))
+		if requestMap["action"] == "set" { // all paths of a set must be actuators, else nothing is set
+			if matches == 0 {
+				utils.SetErrorResponse(requestMap, errorResponseMap, 6, "Path not found: "+searchPath[i]) //unavailable_data
+				backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
+				return
+			}
+			if golib.VSSgetType(searchData[0].NodeHandle) != gomodel.ACTUATOR {
+				utils.SetErrorResponse(requestMap, errorResponseMap, 1, "Not an actuator: "+searchPath[i]) //invalid_data
+				backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
+				return
+			}
+			value, _ := requestMap["value"].(string)
+			if isMultiSet {
+				pathLen := getPathLen(string(searchData[0].NodePath[:]))
+				valueList[i].(map[string]interface{})["path"] = string(searchData[0].NodePath[:pathLen])
+				value, _ = valueList[i].(map[string]interface{})["value"].(string)
+			}
+			if err := validateSetValue(searchData[0].NodeHandle, value); err != nil {
+				utils.SetErrorResponse(requestMap, errorResponseMap, 1, searchPath[i]+": "+err.Error()) //invalid_data
+				backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
+				return
+			}
+		}
 		for i := 0; i < matches; i++ {
 			pathLen := getPathLen(string(searchData[i].NodePath[:]))
 			paths += "\"" + string(searchData[i].NodePath[:pathLen]) + "\", "
+			validation = utils.GetMaxValidation(golib.VSSgetValidation(searchData[i].NodeHandle), validation) // a wildcard may match nodes with different access control
+			validationPack += `{"path":"` + string(searchData[i].NodePath[:pathLen]) + `", "dp":{"value":"` + validationToString(golib.VSSgetValidation(searchData[i].NodeHandle)) + `", "ts":"` + utils.GetRfcTime() + `"}}, `
 		}
 		totalMatches += matches
 		maxValidation = utils.GetMaxValidation(int(validation), maxValidation)
@@ -694,11 +654,6 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
-	if requestMap["action"] == "set" && golib.VSSgetType(searchData[0].NodeHandle) != gomodel.ACTUATOR {
-		utils.SetErrorResponse(requestMap, errorResponseMap, 1, "") //invalid_data
-		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
-		return
-	}
 	paths = paths[:len(paths)-2]
 	if totalMatches == 1 {
 		paths = paths[1 : len(paths)-1] // remove hyphens
@@ -709,6 +664,14 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 	if requestMap["origin"] == "internal" { // internal message, no validation needed
 		maxValidation = 0
 	}
+	accessAction := requestMap["action"].(string)
+	if accessAction == "history-control" {
+		if requestMap["command"] == "list" {
+			accessAction = "get"
+		} else if maxValidation%10 == 0 { // changing a recording always requires an access token with write permission
+			maxValidation++
+		}
+	}
 
 	tokenHandle := ""
 	gatingId := ""
@@ -721,12 +684,12 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 		if requestMap["authorization"] == nil {
 			errorCode = 2
 		} else {
-			if requestMap["action"] == "set" || maxValidation%10 == 2 { // no validation for get/subscribe when validation is 1 (write-only)
+			if accessAction == "set" || accessAction == "history-control" || maxValidation%10 == 2 { // no validation for get/subscribe when validation is 1 (write-only)
 				// checks if requestmap authorization is a string
 				if authToken, ok := requestMap["authorization"].(string); !ok {
 					errorCode = 1
 				} else {
-					errorCode, tokenHandle, gatingId = verifyToken(authToken, requestMap["action"].(string), paths, maxValidation)
+					errorCode, tokenHandle, gatingId = verifyToken(authToken, accessAction, paths, maxValidation)
 				}
 			}
 		}
@@ -740,6 +703,18 @@ func issueServiceRequest(requestMap map[string]interface{}, tDChanIndex int, sDC
 		backendChan[tDChanIndex] <- utils.FinalizeMessage(errorResponseMap)
 		return
 	}
+	if getValidation {
+		validationPack = validationPack[:len(validationPack)-2]
+		if totalMatches > 1 {
+			validationPack = "[" + validationPack + "]"
+		}
+		delete(requestMap, "path")
+		delete(requestMap, "filter")
+		delete(requestMap, "authorization")
+		requestMap["ts"] = utils.GetRfcTime()
+		backendChan[tDChanIndex] <- utils.AddKeyValue(utils.FinalizeMessage(requestMap), "data", validationPack)
+		return
+	}
 	requestMap["path"] = paths
 	if tokenHandle != "" {
 		requestMap["handle"] = tokenHandle
@@ -782,40 +757,172 @@ func createPathListFile(listFname string) {
 	sortPathList(listFname)
 }
 
+func validateTransportNames(args []string) error {
+	for _, name := range args {
+		if !slices.Contains(transportNames, name) {
+			return fmt.Errorf("unknown transport %s, must be one of %s", name, strings.Join(transportNames, ", "))
+		}
+	}
+	return nil
+}
+
+func setTransportsEnabled(config *utils.ServerConfig, names []string, enabled bool) {
+	for _, name := range names {
+		switch name {
+		case "http":
+			config.Http.Enabled = enabled
+		case "ws":
+			config.Ws.Enabled = enabled
+		case "mqtt":
+			config.Mqtt.Enabled = enabled
+		case "grpc":
+			config.Grpc.Enabled = enabled
+		case "ats":
+			config.Ats.Enabled = enabled
+		}
+	}
+}
+
+// Returns the server components to start as threads by vissv2server, the service manager and the enabled transports.
+func enabledComponents(config utils.ServerConfig) []string {
+	components := []string{"serviceMgr"}
+	transports := []struct {
+		enabled   bool
+		component string
+	}{{config.Http.Enabled, "httpMgr"}, {config.Ws.Enabled, "wsMgr"}, {config.Mqtt.Enabled, "mqttMgr"}, {config.Grpc.Enabled, "grpcMgr"}, {config.Ats.Enabled, "atServer"}}
+	for _, transport := range transports {
+		if transport.enabled {
+			components = append(components, transport.component)
+		}
+	}
+	return components
+}
+
 func main() {
+	defaults := defaultVissConfig()
 	// Create new parser object
 	parser := argparse.NewParser("print", "Server Core")
+	configFile := parser.String("", "config", &argparse.Options{Required: false,
+		Help: "server configuration file, the command line parameters override it", Default: "vissv2server.yaml"})
+	printConfig := parser.Flag("", "print-config", &argparse.Options{Required: false, Help: "print the effective configuration, then terminate", Default: false})
 	// Create string flag
 	logFile := parser.Flag("", "logfile", &argparse.Options{Required: false, Help: "outputs to logfile in ./logs folder"})
-	logLevel := parser.Selector("", "loglevel", []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}, &argparse.Options{
+	logLevel := parser.Selector("", "loglevel", logLevels, &argparse.Options{
 		Required: false,
 		Help:     "changes log output level",
-		Default:  "info"})
+		Default:  defaults.Log.Level})
 	dryRun := parser.Flag("", "dryrun", &argparse.Options{Required: false, Help: "dry run to generate vsspathlist file", Default: false})
-	vssJson := parser.String("", "vssJson", &argparse.Options{Required: false, Help: "path and name vssPathlist json file", Default: "../vsspathlist.json"})
-	stateDB := parser.Selector("s", "statestorage", []string{"sqlite", "redis", "apache-iotdb", "none"}, &argparse.Options{Required: false,
-		Help: "Statestorage must be either sqlite, redis, apache-iotdb, or none", Default: "redis"})
+	vssJson := parser.String("", "vssJson", &argparse.Options{Required: false, Help: "path and name vssPathlist json file", Default: defaults.VssPathList})
+	stateDB := parser.Selector("s", "statestorage", serviceMgr.StateStorageNames(), &argparse.Options{Required: false,
+		Help: "Statestorage must be one of " + strings.Join(serviceMgr.StateStorageNames(), ", "), Default: defaults.StateStorage.Type})
 	historySupport := parser.Flag("j", "history", &argparse.Options{Required: false, Help: "Support for historic data requests", Default: false})
+	historyDB := parser.Selector("", "historystorage", serviceMgr.HistoryStorageNames(), &argparse.Options{Required: false,
+		Help: "History storage must be one of " + strings.Join(serviceMgr.HistoryStorageNames(), ", "), Default: defaults.History.Storage})
+	historyDbFile := parser.String("", "historydbfile", &argparse.Options{
+		Required: false,
+		Help:     "history storage database filename, created if it does not exist",
+		Default:  defaults.History.DbFile})
 	dbFile := parser.String("", "dbfile", &argparse.Options{
 		Required: false,
 		Help:     "statestorage database filename",
-		Default:  "serviceMgr/statestorage.db"})
+		Default:  defaults.StateStorage.DbFile})
 	consentSupport := parser.Flag("c", "consentsupport", &argparse.Options{Required: false, Help: "try to connect to ECF", Default: false})
+	maxTimers := parser.Int("", "maxtimers", &argparse.Options{Required: false,
+		Help: "max number of active timebased subscriptions and history captures, 0 means no limit", Default: defaults.Subscriptions.MaxTimers})
+	resumeGrace := parser.Int("", "resumegrace", &argparse.Options{Required: false,
+		Help: "secs that the subscriptions of a lost client session are kept for resumption, 0 disables resumption", Default: defaults.Subscriptions.ResumeGrace})
+	resumeBuffer := parser.Int("", "resumebuffer", &argparse.Options{Required: false,
+		Help: "max number of notifications buffered per subscription while it is not resumed, 0 means no limit", Default: defaults.Subscriptions.ResumeBuffer})
+	queueDepth := parser.Int("", "queuedepth", &argparse.Options{Required: false,
+		Help: "max number of notifications queued per WS client, 0 means no limit", Default: defaults.ClientQueue.Depth})
+	queuePolicy := parser.Selector("", "queuepolicy", utils.QueuePolicies, &argparse.Options{Required: false,
+		Help: "what to do when the notification queue of a WS client is full, one of " + strings.Join(utils.QueuePolicies, ", "), Default: defaults.ClientQueue.Policy})
+	subscriptionFile := parser.String("", "subscriptionfile", &argparse.Options{Required: false,
+		Help: "file where resumable subscriptions are saved to survive a restart, not saved if empty", Default: defaults.Subscriptions.File})
+	serverConfigFile := parser.String("", "serverconfig", &argparse.Options{Required: false,
+		Help: "server configuration file with the listener addresses, timeouts, and enabled transports", Default: defaults.Files.ServerConfig})
+	httpAddr := parser.String("", "httpaddr", &argparse.Options{Required: false, Help: "HTTP listener address, overrides the server configuration", Default: ""})
+	wsAddr := parser.String("", "wsaddr", &argparse.Options{Required: false, Help: "WS listener address, overrides the server configuration", Default: ""})
+	grpcAddr := parser.String("", "grpcaddr", &argparse.Options{Required: false, Help: "gRPC listener address, overrides the server configuration", Default: ""})
+	atsAddr := parser.String("", "atsaddr", &argparse.Options{Required: false, Help: "access token server listener address, overrides the server configuration", Default: ""})
+	coreAddr := parser.String("", "coreaddr", &argparse.Options{Required: false, Help: "path list listener address, overrides the server configuration", Default: ""})
+	ecfAddr := parser.String("", "ecfaddr", &argparse.Options{Required: false, Help: "ECF address, overrides the server configuration", Default: ""})
+	enabledTransports := parser.StringList("", "enable", &argparse.Options{Required: false, Validate: validateTransportNames,
+		Help: "transport to enable, one of " + strings.Join(transportNames, ", ") + ", can be repeated"})
+	disabledTransports := parser.StringList("", "disable", &argparse.Options{Required: false, Validate: validateTransportNames,
+		Help: "transport to disable, one of " + strings.Join(transportNames, ", ") + ", can be repeated"})
 
 	// Parse input
 	err := parser.Parse(os.Args)
 	if err != nil {
 		fmt.Print(parser.Usage(err))
 	}
+	parsed := map[string]bool{}
+	for _, arg := range parser.GetArgs() {
+		parsed[arg.GetLname()] = arg.GetParsed()
+	}
+
+	config, err := readVissConfig(*configFile, parsed["config"])
+	if err == nil {
+		if parsed["serverconfig"] {
+			config.Files.ServerConfig = *serverConfigFile
+			config.Listeners = nil // read from it instead of the listeners section
+		}
+		err = config.resolveListeners()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %s\n", err)
+		os.Exit(1)
+	}
+	commandLine := map[string]func(){ // the command line parameters that override the configuration file
+		"logfile":          func() { config.Log.File = *logFile },
+		"loglevel":         func() { config.Log.Level = *logLevel },
+		"vssJson":          func() { config.VssPathList = *vssJson },
+		"statestorage":     func() { config.StateStorage.Type = *stateDB },
+		"history":          func() { config.History.Enabled = *historySupport },
+		"historystorage":   func() { config.History.Storage = *historyDB },
+		"historydbfile":    func() { config.History.DbFile = *historyDbFile },
+		"dbfile":           func() { config.StateStorage.DbFile = *dbFile },
+		"consentsupport":   func() { config.Consent = *consentSupport },
+		"maxtimers":        func() { config.Subscriptions.MaxTimers = *maxTimers },
+		"resumegrace":      func() { config.Subscriptions.ResumeGrace = *resumeGrace },
+		"resumebuffer":     func() { config.Subscriptions.ResumeBuffer = *resumeBuffer },
+		"queuedepth":       func() { config.ClientQueue.Depth = *queueDepth },
+		"queuepolicy":      func() { config.ClientQueue.Policy = *queuePolicy },
+		"subscriptionfile": func() { config.Subscriptions.File = *subscriptionFile },
+		"httpaddr":         func() { config.Listeners.Http.Address, config.Listeners.Http.TlsAddress = *httpAddr, *httpAddr },
+		"wsaddr":           func() { config.Listeners.Ws.Address, config.Listeners.Ws.TlsAddress = *wsAddr, *wsAddr },
+		"grpcaddr":         func() { config.Listeners.Grpc.Address, config.Listeners.Grpc.TlsAddress = *grpcAddr, *grpcAddr },
+		"atsaddr":          func() { config.Listeners.Ats.Address, config.Listeners.Ats.TlsAddress = *atsAddr, *atsAddr },
+		"coreaddr":         func() { config.Listeners.Core.Address, config.Listeners.Core.TlsAddress = *coreAddr, *coreAddr },
+		"ecfaddr":          func() { config.Listeners.EcfAddress = *ecfAddr },
+		"enable":           func() { setTransportsEnabled(config.Listeners, *enabledTransports, true) },
+		"disable":          func() { setTransportsEnabled(config.Listeners, *disabledTransports, false) },
+	}
+	for _, arg := range parser.GetArgs() { // in the order of declaration, so disable is applied after enable
+		if override, ok := commandLine[arg.GetLname()]; ok && arg.GetParsed() {
+			override()
+		}
+	}
+	if err = config.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration errors:\n%s\n", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		config.print(os.Stdout)
+		return
+	}
 
-	utils.InitLog("servercore-log.txt", "./logs", *logFile, *logLevel)
+	utils.InitLog("servercore-log.txt", "./logs", config.Log.File, config.Log.Level)
+	config.apply()
+	serverComponents := enabledComponents(*config.Listeners)
 
 	if !initVssFile() {
 		utils.Error.Fatal(" Tree file not found")
 		return
 	}
 
-	createPathListFile(*vssJson) // save in server directory, where transport managers will expect it to be
+	createPathListFile(config.VssPathList) // save in server directory, where transport managers will expect it to be
 	if *dryRun {
 		utils.Info.Printf("vsspathlist.json created. Job done.")
 		return
@@ -823,25 +930,23 @@ func main() {
 
 	router := mux.NewRouter()
 	router.HandleFunc("/vsspathlist", pathList.VssPathListHandler).Methods("GET")
+	router.HandleFunc("/clientqueues", ClientQueuesHandler).Methods("GET")
 
-	srv := &http.Server{
-		Addr:         "0.0.0.0:8081",
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      router,
-	}
+	srv := config.Listeners.Core.NewHttpServer(router)
 
 	// Active wait for 3 seconds to allow the server to start
 	time.Sleep(3 * time.Second)
 
 	go func() {
-		utils.Info.Printf("Server is listening on %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil {
+		utils.Info.Printf("Server is listening on %s", config.Listeners.Core.Address)
+		if err := utils.ServeHttp(srv, config.Listeners.Core.Address, "", ""); err != nil {
 			log.Fatal("ListenAndServe: ", err)
 		}
 	}()
 
+	utils.ReadTransportSecConfig()
+	serverCapabilities = buildServerCapabilities(serverComponents, config.StateStorage.Type, config.History.Enabled, config.Consent, config.Subscriptions.MaxTimers)
+
 	for _, serverComponent := range serverComponents {
 		switch serverComponent {
 		case "httpMgr":
@@ -857,10 +962,12 @@ func main() {
 			go grpcMgr.GrpcMgrInit(3, transportMgrChannel[3])
 			go transportDataSession(transportMgrChannel[3], transportDataChan[3], backendChan[3])
 		case "serviceMgr":
-			go serviceMgr.ServiceMgrInit(0, serviceMgrChannel[0], *stateDB, *historySupport, *dbFile)
+			go serviceMgr.ServiceMgrInit(0, serviceMgrChannel[0], config.StateStorage.Type, config.History.Enabled, config.StateStorage.DbFile, config.History.Storage,
+				config.History.DbFile, config.Subscriptions.MaxTimers, serviceMgr.DurableSubscriptionConfig{Grace: time.Duration(config.Subscriptions.ResumeGrace) * time.Second,
+					MaxMissed: config.Subscriptions.ResumeBuffer, File: config.Subscriptions.File})
 			go serviceDataSession(serviceMgrChannel[0], serviceDataChan[0], backendChan)
 		case "atServer":
-			go atServer.AtServerInit(atsChannel[0], atsChannel[1], VSSTreeRoot, *consentSupport)
+			go atServer.AtServerInit(atsChannel[0], atsChannel[1], VSSTreeRoot, config.Consent)
 		}
 	}
 
@@ -61,6 +61,9 @@ var clientBackendChan = []chan string{
 	make(chan string),
 }
 
+// notifications are queued per client, so that a slow client does not block the others
+var clientQueues []*utils.ClientQueue
+
 var wsClientIndex int
 
 const isClientLocal = false
@@ -68,16 +71,31 @@ const isClientLocal = false
 func RemoveRoutingForwardResponse(response string, transportMgrChan chan string) {
 	trimmedResponse, clientId := utils.RemoveInternalData(response)
 	if strings.Contains(trimmedResponse, "\"subscription\"") {
-		clientBackendChan[clientId] <- trimmedResponse //subscription notification
+		clientQueues[clientId].Push(trimmedResponse) //subscription notification
 	} else {
 		wsClientChan[clientId] <- trimmedResponse
 	}
 }
 
+// Returns the max number of parallel WS app clients.
+func MaxClients() int {
+	return len(wsClientChan)
+}
+
+// Returns the notification queue metrics of the WS app clients.
+func QueueMetrics() []utils.QueueMetrics {
+	metrics := make([]utils.QueueMetrics, len(clientQueues))
+	for i := range clientQueues {
+		metrics[i] = clientQueues[i].Metrics()
+	}
+	return metrics
+}
+
 func WsMgrInit(mgrId int, transportMgrChan chan string) {
 	utils.ReadTransportSecConfig()
+	clientQueues = utils.NewClientQueues(len(clientBackendChan))
 
-	go utils.WsServer{ClientBackendChannel: clientBackendChan}.InitClientServer(utils.MuxServer[1], wsClientChan, mgrId, &wsClientIndex) // go routine needed due to listenAndServe call...
+	go utils.WsServer{ClientBackendChannel: clientBackendChan, ClientQueues: clientQueues}.InitClientServer(utils.MuxServer[1], wsClientChan, mgrId, &wsClientIndex) // go routine needed due to listenAndServe call...
 
 	utils.Info.Println("WS manager data session initiated.")
 
@@ -0,0 +1,46 @@
+package wsMgr
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/w3c/automotive-viss2/utils"
+)
+
+// One client that never reads its notifications must neither block the sender, nor delay the notifications to another client.
+func TestStalledClientDoesNotDelayOthers(t *testing.T) {
+	utils.InitLog("wsmgr-log.txt", t.TempDir(), false, "error")
+	const notifications = 20000
+	clientQueues = []*utils.ClientQueue{utils.NewClientQueue(0, 100, "drop-oldest"), utils.NewClientQueue(1, 0, "drop-oldest")}
+	for i := range clientQueues {
+		clientQueues[i].Open(nil)
+		go utils.PumpClientQueue(clientQueues[i], clientBackendChan[i])
+		defer clientQueues[i].Close()
+	}
+	received := make(chan time.Duration)
+	start := time.Now()
+	go func() {
+		for i := 0; i < notifications; i++ {
+			<-clientBackendChan[1]
+		}
+		received <- time.Since(start)
+	}()
+
+	for i := 0; i < notifications; i++ {
+		ts := strconv.Itoa(i)
+		RemoveRoutingForwardResponse(`{"RouterId":"1?0", "action":"subscription", "subscriptionId":"1", "ts":"`+ts+`"}`, nil) // stalled client
+		RemoveRoutingForwardResponse(`{"RouterId":"1?1", "action":"subscription", "subscriptionId":"2", "ts":"`+ts+`"}`, nil)
+	}
+	sendTime := time.Since(start)
+	select {
+	case receiveTime := <-received:
+		t.Logf("%d notifications per client sent in %s, received by the other client in %s", notifications, sendTime, receiveTime)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("The other client did not receive all notifications, queue metrics %+v", clientQueues[1].Metrics())
+	}
+	metrics := clientQueues[0].Metrics()
+	if metrics.Depth > 100 || metrics.Dropped < notifications-101 {
+		t.Errorf("Stalled client queue not bounded, metrics %+v", metrics)
+	}
+}
@@ -0,0 +1,205 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package utils
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+/*
+* A client queue keeps the notifications to a client session, so that a slow client does not block the notifications to the others.
+* When the queue is full, the policy decides what happens to a new notification:
+* drop-oldest drops the oldest queued notification, coalesce replaces the queued notification of the same subscription, or else drops the oldest,
+* and disconnect drops all queued notifications and closes the client session.
+ */
+type ClientQueue struct {
+	lock       sync.Mutex
+	messages   []queuedMessage
+	maxDepth   int
+	policy     string
+	notEmpty   chan struct{}
+	closed     chan struct{} // closed when the session ends
+	active     bool
+	disconnect func()
+	metrics    QueueMetrics
+}
+
+type queuedMessage struct {
+	key     string // subscription id, only used by coalesce
+	message string
+}
+
+type QueueMetrics struct {
+	Client    int  `json:"client"`
+	Active    bool `json:"active"`
+	Depth     int  `json:"depth"`
+	HighWater int  `json:"high_water"` // max depth during the session
+	Enqueued  int  `json:"enqueued"`
+	Dropped   int  `json:"dropped"`
+	Coalesced int  `json:"coalesced"`
+	Overflows int  `json:"overflows"` // disconnects due to a full queue
+}
+
+var QueuePolicies = []string{"drop-oldest", "coalesce", "disconnect"}
+
+// Set from the command line before the transport managers are started.
+var ClientQueueDepth = 100
+var ClientQueuePolicy = "drop-oldest"
+
+func NewClientQueue(client int, maxDepth int, policy string) *ClientQueue {
+	return &ClientQueue{maxDepth: maxDepth, policy: policy, notEmpty: make(chan struct{}, 1), closed: make(chan struct{}), metrics: QueueMetrics{Client: client}}
+}
+
+// Returns one queue per client, with the depth and policy set from the command line.
+func NewClientQueues(clients int) []*ClientQueue {
+	queues := make([]*ClientQueue, clients)
+	for i := range queues {
+		queues[i] = NewClientQueue(i, ClientQueueDepth, ClientQueuePolicy)
+	}
+	return queues
+}
+
+// Starts a client session. disconnect is called if the session shall be closed due to a full queue.
+func (queue *ClientQueue) Open(disconnect func()) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	queue.messages = nil
+	queue.closed = make(chan struct{})
+	queue.active = true
+	queue.disconnect = disconnect
+	queue.metrics = QueueMetrics{Client: queue.metrics.Client, Active: true}
+}
+
+// Ends a client session. Queued notifications are dropped.
+func (queue *ClientQueue) Close() {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if !queue.active {
+		return
+	}
+	queue.active = false
+	queue.metrics.Dropped += len(queue.messages)
+	queue.messages = nil
+	queue.metrics.Active = false
+	queue.metrics.Depth = 0
+	close(queue.closed)
+}
+
+// Queues a notification without blocking. It is dropped if there is no client session.
+func (queue *ClientQueue) Push(message string) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if !queue.active {
+		queue.metrics.Dropped++
+		return
+	}
+	queue.metrics.Enqueued++
+	key := ""
+	if queue.policy == "coalesce" {
+		key = subscriptionKey(message)
+	}
+	if queue.maxDepth > 0 && len(queue.messages) >= queue.maxDepth {
+		if queue.policy == "coalesce" && len(key) > 0 {
+			for i := range queue.messages {
+				if queue.messages[i].key == key {
+					queue.messages[i].message = message
+					queue.metrics.Coalesced++
+					return
+				}
+			}
+		}
+		if queue.policy == "disconnect" {
+			Warning.Printf("ClientQueue: queue of client %d is full, client is disconnected", queue.metrics.Client)
+			queue.metrics.Overflows++
+			queue.metrics.Dropped += len(queue.messages) + 1
+			queue.messages = nil
+			queue.metrics.Depth = 0
+			queue.metrics.Active = false
+			queue.active = false // the session is closed by the disconnect
+			if queue.disconnect != nil {
+				go queue.disconnect()
+			}
+			close(queue.closed)
+			return
+		}
+		queue.messages = queue.messages[1:]
+		queue.metrics.Dropped++
+	}
+	queue.messages = append(queue.messages, queuedMessage{key, message})
+	queue.metrics.Depth = len(queue.messages)
+	if queue.metrics.Depth > queue.metrics.HighWater {
+		queue.metrics.HighWater = queue.metrics.Depth
+	}
+	select {
+	case queue.notEmpty <- struct{}{}:
+	default:
+	}
+}
+
+// Waits for the oldest notification of the session. Returns false if the session has ended.
+func (queue *ClientQueue) Pop(session chan struct{}) (string, bool) {
+	for {
+		queue.lock.Lock()
+		if !queue.active || queue.closed != session {
+			queue.lock.Unlock()
+			return "", false
+		}
+		if len(queue.messages) > 0 {
+			message := queue.messages[0].message
+			queue.messages = queue.messages[1:]
+			queue.metrics.Depth = len(queue.messages)
+			queue.lock.Unlock()
+			return message, true
+		}
+		queue.lock.Unlock()
+		select {
+		case <-queue.notEmpty:
+		case <-session:
+			return "", false
+		}
+	}
+}
+
+// Returns a channel that is closed when the current session ends.
+func (queue *ClientQueue) Session() chan struct{} {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	return queue.closed
+}
+
+func (queue *ClientQueue) Metrics() QueueMetrics {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	return queue.metrics
+}
+
+// Forwards the notifications of the queue to the client session, until the session ends.
+func PumpClientQueue(queue *ClientQueue, clientBackendChannel chan string) {
+	session := queue.Session()
+	for {
+		message, ok := queue.Pop(session)
+		if !ok {
+			return
+		}
+		select {
+		case clientBackendChannel <- message:
+		case <-session:
+			return
+		}
+	}
+}
+
+func subscriptionKey(notification string) string {
+	var keyMap struct {
+		SubscriptionId string `json:"subscriptionId"`
+	}
+	json.Unmarshal([]byte(notification), &keyMap)
+	return keyMap.SubscriptionId
+}
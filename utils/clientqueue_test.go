@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func notification(subscriptionId string, value int) string {
+	return `{"action":"subscription","subscriptionId":"` + subscriptionId + `","data":{"path":"Vehicle.Speed","dp":{"value":"` + strconv.Itoa(value) + `"}}}`
+}
+
+func popAll(queue *ClientQueue) []string {
+	session := queue.Session()
+	var messages []string
+	for queue.Metrics().Depth > 0 {
+		message, _ := queue.Pop(session)
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestClientQueueDropOldest(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	queue := NewClientQueue(0, 3, "drop-oldest")
+	queue.Push(notification("1", 0)) // no session
+	queue.Open(nil)
+	for i := 1; i <= 5; i++ {
+		queue.Push(notification("1", i))
+	}
+	metrics := queue.Metrics()
+	if metrics.Depth != 3 || metrics.HighWater != 3 || metrics.Enqueued != 5 || metrics.Dropped != 2 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+	if messages := popAll(queue); len(messages) != 3 || messages[0] != notification("1", 3) || messages[2] != notification("1", 5) {
+		t.Errorf("Expected the three latest notifications, got %v", messages)
+	}
+}
+
+func TestClientQueueCoalesce(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	queue := NewClientQueue(0, 3, "coalesce")
+	queue.Open(nil)
+	queue.Push(notification("1", 1))
+	queue.Push(notification("2", 1))
+	queue.Push(notification("3", 1))
+	queue.Push(notification("1", 2)) // full, replaces the notification of subscription 1
+	queue.Push(notification("4", 1)) // full, no notification of subscription 4 to coalesce with
+	if metrics := queue.Metrics(); metrics.Coalesced != 1 || metrics.Dropped != 1 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+	if messages := popAll(queue); len(messages) != 3 || messages[0] != notification("2", 1) || messages[2] != notification("4", 1) {
+		t.Errorf("Unexpected notifications %v", messages)
+	}
+}
+
+func TestClientQueueCoalesceNotFull(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	queue := NewClientQueue(0, 3, "coalesce")
+	queue.Open(nil)
+	queue.Push(notification("1", 1))
+	queue.Push(notification("1", 2)) // queued, as the queue is not full
+	if metrics := queue.Metrics(); metrics.Coalesced != 0 || metrics.Depth != 2 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+	if messages := popAll(queue); len(messages) != 2 || messages[0] != notification("1", 1) || messages[1] != notification("1", 2) {
+		t.Errorf("Unexpected notifications %v", messages)
+	}
+}
+
+func TestClientQueueDisconnect(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	queue := NewClientQueue(0, 2, "disconnect")
+	disconnected := make(chan bool, 1)
+	queue.Open(func() { disconnected <- true })
+	session := queue.Session()
+	for i := 1; i <= 3; i++ {
+		queue.Push(notification("1", i))
+	}
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatalf("Client was not disconnected")
+	}
+	if _, ok := queue.Pop(session); ok {
+		t.Errorf("Pop succeeded after disconnect")
+	}
+	if metrics := queue.Metrics(); metrics.Active || metrics.Overflows != 1 || metrics.Dropped != 3 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+	queue.Close() // by the session termination, after the disconnect
+	queue.Open(nil)
+	queue.Push(notification("1", 4))
+	if messages := popAll(queue); len(messages) != 1 {
+		t.Errorf("New session got %v", messages)
+	}
+}
@@ -186,15 +186,18 @@ func VerifyTokenSignature(token string, key string) error { // compatible with r
 }
 
 func ExtractFromToken(token string, claim string) string { // TODO remove white space sensitivity
+	if strings.Count(token, ".") != 2 {
+		return ""
+	}
 	delimiter1 := strings.Index(token, ".")
 	delimiter2 := strings.Index(token[delimiter1+1:], ".") + delimiter1 + 1
 	header := token[:delimiter1]
 	payload := token[delimiter1+1 : delimiter2]
 	decodedHeaderByte, _ := base64.RawURLEncoding.DecodeString(header)
 	decodedHeader := string(decodedHeaderByte)
-	claimIndex := strings.Index(decodedHeader, claim)
+	claimIndex := strings.Index(decodedHeader, `"`+claim+`":`) // the claim name, not a value that contains it
 	if claimIndex != -1 {
-		startIndex := claimIndex + len(claim) + 2
+		startIndex := claimIndex + len(claim) + 3
 		endIndex := strings.Index(decodedHeader[startIndex:], ",") + startIndex // ...claim":abc,...  or ...claim":"abc",... or See next line
 		if endIndex == startIndex-1 {                                           // ...claim":abc}  or ...claim":"abc"}
 			endIndex = len(decodedHeader) - 1
@@ -209,9 +212,9 @@ func ExtractFromToken(token string, claim string) string { // TODO remove white
 	}
 	decodedPayloadByte, _ := base64.RawURLEncoding.DecodeString(payload)
 	decodedPayload := string(decodedPayloadByte)
-	claimIndex = strings.Index(decodedPayload, claim)
+	claimIndex = strings.Index(decodedPayload, `"`+claim+`":`) // the claim name, not a value that contains it
 	if claimIndex != -1 {
-		startIndex := claimIndex + len(claim) + 2
+		startIndex := claimIndex + len(claim) + 3
 		endIndex := strings.Index(decodedPayload[startIndex:], ",") + startIndex // ...claim":abc,...  or ...claim":"abc",... or See next line
 		if endIndex == startIndex-1 {                                            // ...claim":abc}  or ...claim":"abc"}
 			endIndex = len(decodedPayload) - 1
@@ -297,7 +300,7 @@ func FinalizeMessage(responseMap map[string]interface{}) string {
 
 func AddKeyValue(message string, key string, value string) string { // to avoid Marshal() to reformat using \"
 	if len(value) > 0 {
-		if value[0] == '{' {
+		if value[0] == '{' || value[0] == '[' {
 			return message[:len(message)-1] + ", \"" + key + "\":" + value + "}"
 		}
 		return message[:len(message)-1] + ", \"" + key + "\":\"" + value + "\"}"
@@ -325,7 +328,7 @@ func FileExists(filename string) bool {
 func ExtractRootName(path string) string {
 	dotDelimiter := strings.Index(path, ".")
 	if dotDelimiter == -1 {
-		Info.Print("ExtractRootName():Could not find root node name in path=%s", path)
+		Info.Printf("ExtractRootName():Could not find root node name in path=%s", path)
 		return path
 	}
 	return path[:dotDelimiter]
@@ -315,6 +315,8 @@ type JsonWebKey struct {
 	Thumb  string `json:"-"`
 	Type   string `json:"kty"`
 	Use    string `json:"use,omitempty"`
+	KeyId  string `json:"kid,omitempty"` // JWKS
+	Alg    string `json:"alg,omitempty"` // JWKS
 	PubMod string `json:"n,omitempty"`   // RSA
 	PubExp string `json:"e,omitempty"`   // RSA
 	Curve  string `json:"crv,omitempty"` //ECDSA
@@ -336,8 +338,9 @@ func (jkey *JsonWebKey) Initialize(pubKey crypto.PublicKey, use string) error {
 		jkey.Type = "EC"
 		ecdsaPubKey := pubKey.(*ecdsa.PublicKey)
 		jkey.Curve = fmt.Sprintf("P-%v", ecdsaPubKey.Curve.Params().BitSize)
-		jkey.Xcoord = base64.RawURLEncoding.EncodeToString(ecdsaPubKey.X.Bytes())
-		jkey.Ycoord = base64.RawURLEncoding.EncodeToString(ecdsaPubKey.Y.Bytes())
+		size := (ecdsaPubKey.Curve.Params().BitSize + 7) / 8 // coordinates are padded to the curve size, RFC7518 section 6.2.1
+		jkey.Xcoord = base64.RawURLEncoding.EncodeToString(ecdsaPubKey.X.FillBytes(make([]byte, size)))
+		jkey.Ycoord = base64.RawURLEncoding.EncodeToString(ecdsaPubKey.Y.FillBytes(make([]byte, size)))
 	default:
 		return fmt.Errorf("error: can not initialize jwk with pubkey of type: %T", typ)
 	}
@@ -93,8 +93,10 @@ func (token *JsonWebToken) AssymSign(privKey crypto.PrivateKey) error {
 		if err != nil {
 			return err
 		}
-		signature = rSign.Bytes() // APPENDS r,s in big endian
-		signature = append(signature, sSign.Bytes()...)
+		size := (ecdsaPriv.Curve.Params().BitSize + 7) / 8 // APPENDS r,s in big endian, each padded to the curve size
+		signature = make([]byte, 2*size)
+		rSign.FillBytes(signature[:size])
+		sSign.FillBytes(signature[size:])
 	default:
 		return fmt.Errorf("error: can not sign jwt: invalid key type: %T", typ)
 	}
@@ -180,6 +182,9 @@ func (token JsonWebToken) CheckAssymSignature(key crypto.PublicKey) (err error)
 		if pubKey.Curve != elliptic.P256() {
 			return errors.New("elliptic curve type not supported")
 		}
+		if len(signature) != 64 {
+			return errors.New("invalid ecdsa signature length")
+		}
 		var r, s *big.Int
 		r = new(big.Int)
 		s = new(big.Int)
@@ -169,6 +169,44 @@ func UnsubscribeResponseJsonToPb(vssUnsubscribeResp string, compression Compress
 	return pbUnsubscribeResponseMessage
 }
 
+func HistoryControlRequestPbToJson(pbHistoryControlReq *pb.HistoryControlRequestMessage, compression Compression) string {
+	currentCompression = compression
+	jsonMessage := populateJsonFromProtoHistoryControlReq(pbHistoryControlReq)
+	return jsonMessage
+}
+
+func HistoryControlResponsePbToJson(pbHistoryControlResp *pb.HistoryControlResponseMessage, compression Compression) string {
+	currentCompression = compression
+	jsonMessage := populateJsonFromProtoHistoryControlResp(pbHistoryControlResp)
+	return jsonMessage
+}
+
+func HistoryControlRequestJsonToPb(vssHistoryControlReq string, compression Compression) *pb.HistoryControlRequestMessage {
+	currentCompression = compression
+	var historyControlReqMessageMap map[string]interface{}
+	err := json.Unmarshal([]byte(vssHistoryControlReq), &historyControlReqMessageMap)
+	if err != nil {
+		Error.Printf("HistoryControlRequestJsonToPb:Unmarshal error data=%s, err=%s", vssHistoryControlReq, err)
+		return nil
+	}
+	pbHistoryControlRequestMessage := &pb.HistoryControlRequestMessage{}
+	createHistoryControlRequestPb(pbHistoryControlRequestMessage, historyControlReqMessageMap)
+	return pbHistoryControlRequestMessage
+}
+
+func HistoryControlResponseJsonToPb(vssHistoryControlResp string, compression Compression) *pb.HistoryControlResponseMessage {
+	currentCompression = compression
+	var historyControlRespMessageMap map[string]interface{}
+	err := json.Unmarshal([]byte(vssHistoryControlResp), &historyControlRespMessageMap)
+	if err != nil {
+		Error.Printf("HistoryControlResponseJsonToPb:Unmarshal error data=%s, err=%s", vssHistoryControlResp, err)
+		return nil
+	}
+	pbHistoryControlResponseMessage := &pb.HistoryControlResponseMessage{}
+	createHistoryControlResponsePb(pbHistoryControlResponseMessage, historyControlRespMessageMap)
+	return pbHistoryControlResponseMessage
+}
+
 /*func ExtractSubscriptionId(jsonSubResponse string) string {
 	var subResponseMap map[string]interface{}
 	err := json.Unmarshal([]byte(jsonSubResponse), &subResponseMap)
@@ -595,6 +633,51 @@ func createUnsubscribeResponsePb(protoMessage *pb.UnsubscribeResponseMessage, me
 	}
 }
 
+func createHistoryControlRequestPb(protoMessage *pb.HistoryControlRequestMessage, messageMap map[string]interface{}) {
+	protoMessage.Path = messageMap["path"].(string)
+	protoMessage.Command = messageMap["command"].(string)
+	protoMessage.BufSize = getOptionalString(messageMap, "buf-size")
+	protoMessage.Retention = getOptionalString(messageMap, "retention")
+	protoMessage.Frequency = getOptionalString(messageMap, "frequency")
+	protoMessage.Authorization = getOptionalString(messageMap, "authorization")
+	protoMessage.RequestId = getOptionalString(messageMap, "requestId")
+}
+
+func createHistoryControlResponsePb(protoMessage *pb.HistoryControlResponseMessage, messageMap map[string]interface{}) {
+	protoMessage.RequestId = getOptionalString(messageMap, "requestId")
+	protoMessage.Ts = messageMap["ts"].(string)
+	protoMessage.Authorization = getOptionalString(messageMap, "authorization")
+	if messageMap["error"] != nil {
+		protoMessage.Status = pb.ResponseStatus_ERROR
+		protoMessage.ErrorResponse = getProtoErrorMessage(messageMap["error"].(map[string]interface{}))
+		return
+	}
+	protoMessage.Status = pb.ResponseStatus_SUCCESS
+	captures, _ := messageMap["captures"].([]interface{})
+	for _, capture := range captures {
+		captureMap, ok := capture.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pbCapture := &pb.HistoryControlResponseMessage_CaptureMessage{}
+		pbCapture.Path, _ = captureMap["path"].(string)
+		pbCapture.Status, _ = captureMap["status"].(string)
+		pbCapture.Frequency, _ = captureMap["frequency"].(string)
+		pbCapture.BufSize, _ = captureMap["buf-size"].(string)
+		pbCapture.Retention = getOptionalString(captureMap, "retention")
+		pbCapture.Samples, _ = captureMap["samples"].(string)
+		pbCapture.FillLevel = getOptionalString(captureMap, "fill-level")
+		protoMessage.Captures = append(protoMessage.Captures, pbCapture)
+	}
+}
+
+func getOptionalString(messageMap map[string]interface{}, key string) *string {
+	if value, ok := messageMap[key].(string); ok {
+		return &value
+	}
+	return nil
+}
+
 //      *******************************Proto to JSON code ***************************************
 func populateJsonFromProtoGetReq(protoMessage *pb.GetRequestMessage) string {
 	jsonMessage := "{"
@@ -625,8 +708,9 @@ func populateJsonFromProtoGetResp(protoMessage *pb.GetResponseMessage) string {
 func populateJsonFromProtoSetReq(protoMessage *pb.SetRequestMessage) string {
 	jsonMessage := "{"
 	jsonMessage += `"action":"set"`
-	jsonMessage += `,"path":"` + protoMessage.GetPath() + `","value":"` +
-		protoMessage.GetValue() + `"` + createJSON(protoMessage.GetAuthorization(), "authorization") + createJSON(protoMessage.GetRequestId(), "requestId")
+	value, _ := json.Marshal(protoMessage.GetValue()) // escaped, as the value of an array datatype is a JSON array in the string
+	jsonMessage += `,"path":"` + protoMessage.GetPath() + `","value":` +
+		string(value) + createJSON(protoMessage.GetAuthorization(), "authorization") + createJSON(protoMessage.GetRequestId(), "requestId")
 	return jsonMessage + "}"
 }
 
@@ -697,6 +781,36 @@ func populateJsonFromProtoUnsubscribeResp(protoMessage *pb.UnsubscribeResponseMe
 	return jsonMessage + "}"
 }
 
+func populateJsonFromProtoHistoryControlReq(protoMessage *pb.HistoryControlRequestMessage) string {
+	jsonMessage := "{"
+	jsonMessage += `"action":"history-control"`
+	jsonMessage += `,"path":"` + protoMessage.GetPath() + `","command":"` + protoMessage.GetCommand() + `"` +
+		createJSON(protoMessage.GetBufSize(), "buf-size") + createJSON(protoMessage.GetRetention(), "retention") + createJSON(protoMessage.GetFrequency(), "frequency") +
+		createJSON(protoMessage.GetAuthorization(), "authorization") + createJSON(protoMessage.GetRequestId(), "requestId")
+	return jsonMessage + "}"
+}
+
+func populateJsonFromProtoHistoryControlResp(protoMessage *pb.HistoryControlResponseMessage) string {
+	jsonMessage := "{"
+	jsonMessage += `"action":"history-control"`
+	if protoMessage.GetStatus() != 0 { // ERROR
+		jsonMessage += getJsonError(protoMessage.GetErrorResponse())
+	} else if len(protoMessage.GetCaptures()) > 0 {
+		jsonMessage += `,"captures":[`
+		for i, capture := range protoMessage.GetCaptures() {
+			if i > 0 {
+				jsonMessage += ","
+			}
+			jsonMessage += `{"path":"` + capture.GetPath() + `","status":"` + capture.GetStatus() + `","frequency":"` + capture.GetFrequency() +
+				`","buf-size":"` + capture.GetBufSize() + `"` + createJSON(capture.GetRetention(), "retention") + `,"samples":"` + capture.GetSamples() + `"` +
+				createJSON(capture.GetFillLevel(), "fill-level") + "}"
+		}
+		jsonMessage += "]"
+	}
+	jsonMessage += `,"ts":"` + protoMessage.GetTs() + `"` + createJSON(protoMessage.GetRequestId(), "requestId") + createJSON(protoMessage.GetAuthorization(), "authorization")
+	return jsonMessage + "}"
+}
+
 func getJsonFilter(filter *pb.FilterExpressions) string {
 	var filterExp []*pb.FilterExpressions_FilterExpression
 	if filter == nil {
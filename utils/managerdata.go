@@ -49,7 +49,7 @@ type ErrorInformation struct {
 	Message string
 }
 
-var ErrorInfoList [8]ErrorInformation = [8]ErrorInformation{
+var ErrorInfoList [9]ErrorInformation = [9]ErrorInformation{
 	{"400","bad_request","The request is malformed."},
 	{"400","invalid_data","Data present in the request is invalid."},
 	{"401","expired_token","Access token has expired."},
@@ -57,7 +57,8 @@ var ErrorInfoList [8]ErrorInformation = [8]ErrorInformation{
 	{"401","missing_token","Access token is missing."},
 	{"403","forbidden_request","The server refuses to carry out the request."},
 	{"404","unavailable_data","The requested data was not found."},
-	{"503","service_unavailable","The server is temporarily unable to handle the request."}}
+	{"503","service_unavailable","The server is temporarily unable to handle the request."},
+	{"408","request_timeout","The server timed out waiting for the request to complete."}}
 
 var MuxServer = []*http.ServeMux{
 	http.NewServeMux(), // for app client HTTP sessions
@@ -107,6 +108,7 @@ type HttpChannel struct {
 
 type WsChannel struct {
 	clientBackendChannel []chan string
+	clientQueues         []*ClientQueue
 	mgrIndex             int
 	clientIndex          *int
 }
@@ -121,4 +123,5 @@ type HttpServer struct {
 }
 type WsServer struct {
 	ClientBackendChannel []chan string
+	ClientQueues         []*ClientQueue // notifications to the clients
 }
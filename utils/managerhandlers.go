@@ -25,6 +25,9 @@ import (
 
 const backendTermination = "internal-backend-termination"
 
+// HTTP requests on paths with this prefix are history-control requests, GET lists the captures, and POST issues the command in the JSON body.
+const historyControlPrefix = "/history-control"
+
 func getWsClientIndex() int {
 	freeIndex := -1
 	for i := range WsClientIndexList {
@@ -121,6 +124,10 @@ func frontendHttpAppSession(w http.ResponseWriter, req *http.Request, clientChan
 	} else {
 		requestMap["path"] = path
 	}
+	historyControl := strings.HasPrefix(requestMap["path"].(string), historyControlPrefix+"/")
+	if historyControl {
+		requestMap["path"] = strings.TrimPrefix(requestMap["path"].(string), historyControlPrefix)
+	}
 	Info.Printf("HTTP method:%s, path: %s", req.Method, path)
 	token := req.Header.Get("Authorization")
 	Info.Printf("HTTP token:%s", token)
@@ -134,10 +141,31 @@ func frontendHttpAppSession(w http.ResponseWriter, req *http.Request, clientChan
 		fallthrough // should work for POST also...
 	case "GET":
 		requestMap["action"] = "get"
-	case "POST": // set
+		if historyControl {
+			requestMap["action"] = "history-control"
+			requestMap["command"] = "list"
+		}
+	case "POST": // set, or history control
+		if historyControl { // e.g. {"command":"start", "frequency":"3600"}
+			requestMap["action"] = "history-control"
+			body, _ := io.ReadAll(req.Body)
+			var commandMap map[string]interface{}
+			json.Unmarshal(body, &commandMap)
+			for _, key := range []string{"command", "buf-size", "retention", "frequency"} {
+				if commandMap[key] != nil {
+					requestMap[key] = commandMap[key]
+				}
+			}
+			break
+		}
 		requestMap["action"] = "set"
 		body, _ := io.ReadAll(req.Body)
-		requestMap["value"] = string(body)
+		var valueList []map[string]interface{} // multi-path set, e.g. [{"path":"X", "value":"Y"}, ...]
+		if json.Unmarshal(body, &valueList) == nil {
+			requestMap["value"] = valueList
+		} else {
+			requestMap["value"] = string(body)
+		}
 	default:
 		//		http.Error(w, "400 Unsupported method", http.StatusBadRequest)
 		Warning.Printf("Only GET and POST methods are supported.")
@@ -181,8 +209,9 @@ func frontendWSAppSession(conn *websocket.Conn, clientChannel chan string, clien
 }
 
 // Receives a response for the client through the channel. Then writes the response back to the client.
-func backendWSAppSession(conn *websocket.Conn, clientBackendChannel chan string, compression Compression) {
+func backendWSAppSession(conn *websocket.Conn, clientBackendChannel chan string, clientQueue *ClientQueue, compression Compression) {
 	defer conn.Close()
+	defer clientQueue.Close()
 	for {
 		message := <-clientBackendChannel
 		Info.Printf("backendWSAppSession(): Message received=%s", message)
@@ -282,7 +311,10 @@ func (wsH WsChannel) makeappClientHandler(appClientChannel []chan string) func(h
 				Info.Printf("WS session started, compression variant=%d", compression)
 				go frontendWSAppSession(conn, appClientChannel[*wsH.clientIndex], wsH.clientBackendChannel[*wsH.clientIndex],
 					*wsH.clientIndex, compression)
-				go backendWSAppSession(conn, wsH.clientBackendChannel[*wsH.clientIndex], compression)
+				clientQueue := wsH.clientQueues[*wsH.clientIndex]
+				clientQueue.Open(func() { conn.Close() }) // a closed connection terminates the session
+				go PumpClientQueue(clientQueue, wsH.clientBackendChannel[*wsH.clientIndex])
+				go backendWSAppSession(conn, wsH.clientBackendChannel[*wsH.clientIndex], clientQueue, compression)
 			}
 		} else {
 			Error.Printf("Client must set up a Websocket session.")
@@ -295,41 +327,32 @@ func (server HttpServer) InitClientServer(muxServer *http.ServeMux, httpClientCh
 	appClientHandler := HttpChannel{}.makeappClientHandler(httpClientChan)
 	muxServer.HandleFunc("/", appClientHandler)
 	Info.Printf("InitClientServer():SecureConfiguration.TransportSec=%s", SecureConfiguration.TransportSec)
-	// Manages the server TLS claims
-	if SecureConfiguration.TransportSec == "yes" {
-		server := http.Server{
-			Addr: ":" + SecureConfiguration.HttpSecPort,
-			TLSConfig: GetTLSConfig("localhost", TrSecConfigPath+SecureConfiguration.CaSecPath+"Root.CA.crt",
-				tls.ClientAuthType(CertOptToInt(SecureConfiguration.ServerCertOpt)), nil),
-			Handler: muxServer,
-		}
-		Info.Printf("HTTPS:CerOpt=%s", SecureConfiguration.ServerCertOpt)
-		Error.Fatal(server.ListenAndServeTLS(TrSecConfigPath+SecureConfiguration.ServerSecPath+"server.crt", TrSecConfigPath+SecureConfiguration.ServerSecPath+"server.key"))
-	} else {
-		Error.Fatal(http.ListenAndServe(":8888", muxServer))
-	}
+	serveClients(ServerConfiguration.Http, SecureConfiguration.HttpSecPort, muxServer)
 }
 
 // Launches the WebSocket Manager
 func (server WsServer) InitClientServer(muxServer *http.ServeMux, wsClientChan []chan string, mgrIndex int, clientIndex *int) {
 	*clientIndex = 0
-	appClientHandler := WsChannel{server.ClientBackendChannel, mgrIndex, clientIndex}.makeappClientHandler(wsClientChan) // Generates a handler for the requests
+	appClientHandler := WsChannel{server.ClientBackendChannel, server.ClientQueues, mgrIndex, clientIndex}.makeappClientHandler(wsClientChan) // Generates a handler for the requests
 	// For the web client
 	muxServer.HandleFunc("/webclient/", http.StripPrefix("/webclient/", http.FileServer(http.Dir("../../viss-web-client"))).ServeHTTP)
 	muxServer.HandleFunc("/", appClientHandler)
 	Info.Printf("InitClientServer():SecureConfiguration.TransportSec=%s", SecureConfiguration.TransportSec)
-	if SecureConfiguration.TransportSec == "yes" { // In  case a secure connection is used
-		server := http.Server{
-			Addr: ":" + SecureConfiguration.WsSecPort,
-			TLSConfig: GetTLSConfig("localhost", TrSecConfigPath+SecureConfiguration.CaSecPath+"Root.CA.crt",
-				tls.ClientAuthType(CertOptToInt(SecureConfiguration.ServerCertOpt)), nil),
-			Handler: muxServer,
-		}
+	serveClients(ServerConfiguration.Ws, SecureConfiguration.WsSecPort, muxServer)
+}
+
+// Serves the clients on the configured listener, with the server TLS claims if transport security is applied
+func serveClients(listener ListenerConfig, secPort string, muxServer *http.ServeMux) {
+	server := listener.NewHttpServer(muxServer)
+	certFile, keyFile := "", ""
+	if SecureConfiguration.TransportSec == "yes" {
+		server.TLSConfig = GetTLSConfig("localhost", TrSecConfigPath+SecureConfiguration.CaSecPath+"Root.CA.crt",
+			tls.ClientAuthType(CertOptToInt(SecureConfiguration.ServerCertOpt)), nil)
+		certFile = TrSecConfigPath + SecureConfiguration.ServerSecPath + "server.crt"
+		keyFile = TrSecConfigPath + SecureConfiguration.ServerSecPath + "server.key"
 		Info.Printf("HTTPS:CerOpt=%s", SecureConfiguration.ServerCertOpt)
-		Error.Fatal(server.ListenAndServeTLS(TrSecConfigPath+SecureConfiguration.ServerSecPath+"server.crt", TrSecConfigPath+SecureConfiguration.ServerSecPath+"server.key"))
-	} else { // No sec connection
-		Error.Fatal(http.ListenAndServe(":8080", muxServer))
 	}
+	Error.Fatal(ServeHttp(server, listener.ListenAddress(secPort), certFile, keyFile))
 }
 
 func CertOptToInt(serverCertOpt string) int {
@@ -353,7 +376,7 @@ func GetTLSConfig(host string, caCertFile string, certOpt tls.ClientAuthType, se
 	if certOpt > tls.RequestClientCert { // If a client certificate is required, then the CA certificate is needed
 		caCert, err = os.ReadFile(caCertFile)
 		if err != nil {
-			Error.Printf("Error opening cert file", caCertFile, ", error ", err)
+			Error.Printf("Error opening cert file %s, error %s", caCertFile, err)
 			return nil
 		}
 		caCertPool = x509.NewCertPool()
@@ -22,7 +22,7 @@ func ProtobufToJson(serialisedMessage []byte, compression Compression) string {
 	protoMessage := &pb.ProtobufMessage{}
 	err := proto.Unmarshal(serialisedMessage, protoMessage)
 	if err != nil {
-		Error.Printf("Unmarshaling error: ", err)
+		Error.Printf("Unmarshaling error: %s", err)
 		return ""
 	}
 	jsonMessage := populateJsonFromProto(protoMessage)
@@ -35,7 +35,7 @@ func JsonToProtobuf(jsonMessage string, compression Compression) []byte {
 	protoMessage = populateProtoFromJson(jsonMessage)
 	serialisedMessage, err := proto.Marshal(protoMessage)
 	if err != nil {
-		Error.Printf("Marshaling error: ", err)
+		Error.Printf("Marshaling error: %s", err)
 		return nil
 	}
 	return serialisedMessage
@@ -0,0 +1,215 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+* The JWT ids of the PoP tokens that the AGT server and the access token server have accepted, which must not be accepted again until the PoPs have expired.
+* The sqlite and redis stores persist over restarts, and are shared by the servers if they are configured with the same database file or redis server.
+* A store holds at most MaxEntries unexpired ids, and rejects new ids when it is full, so that a flood of ids cannot open a replay window.
+ */
+type ReplayStoreConfig struct {
+	Type         string `json:"type" yaml:"type"`                   // memory, sqlite, or redis
+	DbFile       string `json:"db_file" yaml:"db_file"`             // sqlite
+	RedisAddress string `json:"redis_address" yaml:"redis_address"` // host:port, or the path of a unix socket
+	RedisDb      int    `json:"redis_db" yaml:"redis_db"`           // a database that is only used for the JWT ids
+	MaxEntries   int    `json:"max_entries" yaml:"max_entries"`
+}
+
+// the default sqlite file of both the AGT server and the access token server, so that they share the store out of the box
+const DefaultReplayStoreFile = "/var/tmp/vissv2/replaystore.db"
+
+type ReplayStore interface {
+	CheckAndAdd(jti string, ttl time.Duration) (bool, error) // returns false if the jti is stored and has not expired, else stores it for the ttl
+	Close() error
+}
+
+var ErrReplayStoreFull = errors.New("replay store is full")
+
+func ReplayStoreTypes() []string {
+	return []string{"memory", "sqlite", "redis"}
+}
+
+func OpenReplayStore(config ReplayStoreConfig) (ReplayStore, error) {
+	if config.MaxEntries <= 0 {
+		return nil, fmt.Errorf("max_entries must be positive")
+	}
+	switch config.Type {
+	case "memory":
+		return &memoryReplayStore{entries: map[string]time.Time{}, maxEntries: config.MaxEntries}, nil
+	case "sqlite":
+		return openSqliteReplayStore(config)
+	case "redis":
+		return openRedisReplayStore(config)
+	}
+	return nil, fmt.Errorf("type %s is not one of %s", config.Type, strings.Join(ReplayStoreTypes(), ", "))
+}
+
+/*
+* Checks that the jti of a PoP has not been accepted before, and stores it until the PoP has expired.
+* A replay is logged as an audit event. The PoP is also rejected if the jti cannot be stored.
+ */
+func CheckReplay(store ReplayStore, jti string, ttl time.Duration, server string) bool {
+	if len(jti) == 0 {
+		Info.Printf("CheckReplay:PoP without jti rejected")
+		return false
+	}
+	ok, err := store.CheckAndAdd(jti, ttl)
+	if err != nil {
+		Error.Printf("CheckReplay:jti not stored, err=%s", err)
+		return false
+	}
+	if !ok {
+		Warning.WithFields(logrus.Fields{"audit": "replay-rejected", "server": server, "jti": jti}).Warn("Replayed PoP rejected")
+	}
+	return ok
+}
+
+type memoryReplayStore struct {
+	mutex      sync.Mutex
+	entries    map[string]time.Time // jti -> expiry
+	maxEntries int
+}
+
+func (store *memoryReplayStore) CheckAndAdd(jti string, ttl time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	if expiry, ok := store.entries[jti]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	if len(store.entries) >= store.maxEntries {
+		for id, expiry := range store.entries {
+			if !now.Before(expiry) {
+				delete(store.entries, id)
+			}
+		}
+		if len(store.entries) >= store.maxEntries {
+			return false, ErrReplayStoreFull
+		}
+	}
+	store.entries[jti] = now.Add(ttl)
+	return true, nil
+}
+
+func (store *memoryReplayStore) Close() error {
+	return nil
+}
+
+// The ids in the JTIS table, which expired ids are deleted from when the store is full.
+type sqliteReplayStore struct {
+	mutex      sync.Mutex
+	dbHandle   *sql.DB
+	maxEntries int
+}
+
+func openSqliteReplayStore(config ReplayStoreConfig) (ReplayStore, error) {
+	if len(config.DbFile) == 0 {
+		return nil, errors.New("db_file is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(config.DbFile), 0700); err != nil {
+		return nil, err
+	}
+	dbHandle, err := sql.Open("sqlite3", config.DbFile+"?_busy_timeout=5000") // the database may be shared with another server
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dbHandle.Exec("CREATE TABLE IF NOT EXISTS JTIS (`jti` TEXT PRIMARY KEY, `expiry` INTEGER NOT NULL)"); err != nil {
+		dbHandle.Close()
+		return nil, fmt.Errorf("%s: %s", config.DbFile, err)
+	}
+	return &sqliteReplayStore{dbHandle: dbHandle, maxEntries: config.MaxEntries}, nil
+}
+
+func (store *sqliteReplayStore) CheckAndAdd(jti string, ttl time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	var count int
+	if err := store.dbHandle.QueryRow("SELECT COUNT(*) FROM JTIS").Scan(&count); err != nil {
+		return false, err
+	}
+	if count >= store.maxEntries {
+		if _, err := store.dbHandle.Exec("DELETE FROM JTIS WHERE `expiry` <= ?", now.UnixMilli()); err != nil {
+			return false, err
+		}
+		if err := store.dbHandle.QueryRow("SELECT COUNT(*) FROM JTIS").Scan(&count); err != nil {
+			return false, err
+		}
+		if count >= store.maxEntries {
+			return false, ErrReplayStoreFull
+		}
+	}
+	// an expired id is replaced, an unexpired id is left unchanged
+	result, err := store.dbHandle.Exec("INSERT INTO JTIS VALUES (?, ?) ON CONFLICT(`jti`) DO UPDATE SET `expiry`=excluded.`expiry` WHERE `expiry` <= ?",
+		jti, now.Add(ttl).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+func (store *sqliteReplayStore) Close() error {
+	return store.dbHandle.Close()
+}
+
+// The ids as keys with the ttl as expiry, in a redis database that is not used for other keys.
+type redisReplayStore struct {
+	redisClient *redis.Client
+	maxEntries  int
+}
+
+func openRedisReplayStore(config ReplayStoreConfig) (ReplayStore, error) {
+	if len(config.RedisAddress) == 0 {
+		return nil, errors.New("redis_address is required")
+	}
+	network := "tcp"
+	if strings.HasPrefix(config.RedisAddress, "/") {
+		network = "unix"
+	}
+	redisClient := redis.NewClient(&redis.Options{Network: network, Addr: config.RedisAddress, DB: config.RedisDb})
+	if err := redisClient.Ping().Err(); err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("%s: %s", config.RedisAddress, err)
+	}
+	return &redisReplayStore{redisClient: redisClient, maxEntries: config.MaxEntries}, nil
+}
+
+func (store *redisReplayStore) CheckAndAdd(jti string, ttl time.Duration) (bool, error) {
+	count, err := store.redisClient.DBSize().Result() // redis deletes the expired keys
+	if err != nil {
+		return false, err
+	}
+	if count >= int64(store.maxEntries) {
+		if exists, err := store.redisClient.Exists("jti:" + jti).Result(); err != nil || exists == 1 {
+			return false, err
+		}
+		return false, ErrReplayStoreFull
+	}
+	return store.redisClient.SetNX("jti:"+jti, 1, ttl).Result()
+}
+
+func (store *redisReplayStore) Close() error {
+	return store.redisClient.Close()
+}
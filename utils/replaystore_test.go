@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplayStore(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	dbFile := filepath.Join(t.TempDir(), "jti.db")
+	for _, config := range []ReplayStoreConfig{{Type: "memory", MaxEntries: 2}, {Type: "sqlite", DbFile: dbFile, MaxEntries: 2}} {
+		store, err := OpenReplayStore(config)
+		if err != nil {
+			t.Fatalf("%s: OpenReplayStore failed, err=%s", config.Type, err)
+		}
+		if !CheckReplay(store, "jti-1", time.Hour, "test") || CheckReplay(store, "jti-1", time.Hour, "test") {
+			t.Errorf("%s: replayed jti accepted", config.Type)
+		}
+		if CheckReplay(store, "", time.Hour, "test") {
+			t.Errorf("%s: empty jti accepted", config.Type)
+		}
+		if ok, err := store.CheckAndAdd("jti-2", 10*time.Millisecond); !ok || err != nil {
+			t.Errorf("%s: jti-2 not accepted, err=%v", config.Type, err)
+		}
+		if ok, err := store.CheckAndAdd("jti-3", time.Hour); ok || !errors.Is(err, ErrReplayStoreFull) {
+			t.Errorf("%s: jti accepted by a full store, err=%v", config.Type, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if ok, err := store.CheckAndAdd("jti-3", time.Hour); !ok || err != nil {
+			t.Errorf("%s: expired jti not removed from a full store, err=%v", config.Type, err)
+		}
+		if ok, _ := store.CheckAndAdd("jti-3", time.Hour); ok {
+			t.Errorf("%s: replayed jti-3 accepted", config.Type)
+		}
+		store.Close()
+	}
+	store, _ := OpenReplayStore(ReplayStoreConfig{Type: "sqlite", DbFile: dbFile, MaxEntries: 10}) // as after a restart
+	defer store.Close()
+	if ok, err := store.CheckAndAdd("jti-1", time.Hour); ok || err != nil {
+		t.Errorf("jti-1 accepted after reopening the store, err=%v", err)
+	}
+	if _, err := OpenReplayStore(ReplayStoreConfig{Type: "file", MaxEntries: 10}); err == nil {
+		t.Errorf("Unknown store type accepted")
+	}
+}
@@ -0,0 +1,647 @@
+/**
+* (C) 2023 Ford Motor Company
+* (C) 2021 Geotab Inc
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Typed representation of a VISS request, as received by the server core from the transport managers.
+type VissRequest struct {
+	Action         string             `json:"action"`
+	Path           *string            `json:"path"`
+	Filter         json.RawMessage    `json:"filter"`
+	Value          json.RawMessage    `json:"value"` // a string, or an array of path/value pairs in a multi-path set
+	RequestId      string             `json:"requestId"`
+	Authorization  string             `json:"authorization"`
+	SubscriptionId *string            `json:"subscriptionId"`
+	Metadata       string             `json:"metadata"`
+	RouterId       string             `json:"RouterId"`    // added by the transport manager
+	Origin         string             `json:"origin"`      // added by the transport manager
+	Vin            string             `json:"vin"`         // added by the MQTT transport manager, state storage partition of the vehicle
+	GatingId       string             `json:"gatingId"`    // internal-cancelsubscription
+	Timeout        *string            `json:"timeout"`     // set, msecs to wait for the actuator to reach the value
+	Command        string             `json:"command"`     // history-control: create, start, stop, delete, or list
+	BufSize        *string            `json:"buf-size"`    // history-control create
+	Retention      *string            `json:"retention"`   // history-control create
+	Frequency      *string            `json:"frequency"`   // history-control start, captures per hour
+	ResumeToken    *string            `json:"resumeToken"` // subscribe, resumes a detached durable subscription
+	FilterList     []FilterExpression `json:"-"`
+	ValueList      []PathValue        `json:"-"` // multi-path set
+}
+
+// A path/value pair of a multi-path set, where the path is relative to the request path.
+type PathValue struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+type FilterExpression struct {
+	Type      string          `json:"type"`
+	Parameter json.RawMessage `json:"parameter"`
+}
+
+type TimebasedParameter struct {
+	Period string `json:"period"`
+}
+
+type RangeParameter struct {
+	LogicOp  string `json:"logic-op"`
+	Boundary string `json:"boundary"`
+}
+
+type ChangeParameter struct {
+	LogicOp string `json:"logic-op"`
+	Diff    string `json:"diff"`
+}
+
+type CurvelogParameter struct {
+	MaxErr  string `json:"maxerr"`
+	BufSize string `json:"bufsize"`
+}
+
+// The object form of a history filter parameter. The string form is an ISO 8601 duration, which is the period back from now.
+type HistoryParameter struct {
+	Start     string `json:"start"`     // RFC3339, or use period
+	End       string `json:"end"`       // RFC3339, default is now
+	Period    string `json:"period"`    // ISO 8601 duration back from end
+	Interval  string `json:"interval"`  // ISO 8601 duration, samples are down-sampled to one per interval
+	Aggregate string `json:"aggregate"` // how the samples of an interval are down-sampled, default is last
+}
+
+// A resolved history filter, selecting the samples from Start to End, down-sampled to one per Interval unless it is zero.
+type HistoryRange struct {
+	Start     time.Time
+	End       time.Time
+	Interval  time.Duration
+	Aggregate string
+}
+
+// A RequestError names the request field that failed validation, e.g. "filter[1].parameter.logic-op".
+type RequestError struct {
+	Field  string
+	Reason string
+}
+
+func (err *RequestError) Error() string {
+	if len(err.Field) == 0 {
+		return err.Reason
+	}
+	return err.Field + ": " + err.Reason
+}
+
+var getFilterTypes = []string{"paths", "history", "static-metadata", "dynamic-metadata"}
+var subscribeFilterTypes = []string{"paths", "history", "timebased", "range", "change", "curvelog", "static-metadata", "dynamic-metadata"}
+var logicOperators = []string{"eq", "ne", "gt", "gte", "lt", "lte"}
+var metadataDomains = []string{"server_capabilities", "samplerate", "availability", "validate", "desired"}
+var historyAggregates = []string{"first", "last", "min", "max", "avg"}
+var historyCommands = []string{"create", "start", "stop", "delete", "list"}
+
+// Returns the filter types that the server supports.
+func FilterTypes() []string {
+	return append([]string{}, subscribeFilterTypes...)
+}
+
+/*
+* Decodes and validates a request. Unknown members, members of the wrong JSON type, and missing or malformed action specific members
+* are reported as a *RequestError.
+ */
+func ParseVissRequest(request string) (*VissRequest, error) {
+	var vissRequest VissRequest
+	if err := strictUnmarshal([]byte(request), &vissRequest, ""); err != nil {
+		return nil, err
+	}
+	if vissRequest.Timeout != nil && vissRequest.Action != "set" {
+		return &vissRequest, &RequestError{"timeout", "only allowed in set request"}
+	}
+	if vissRequest.ResumeToken != nil && vissRequest.Action != "subscribe" {
+		return &vissRequest, &RequestError{"resumeToken", "only allowed in subscribe request"}
+	}
+	switch vissRequest.Action {
+	case "get":
+		return &vissRequest, vissRequest.validate(getFilterTypes, true)
+	case "subscribe":
+		if vissRequest.ResumeToken != nil {
+			return &vissRequest, vissRequest.validateResume()
+		}
+		return &vissRequest, vissRequest.validate(subscribeFilterTypes, true)
+	case "set":
+		if err := vissRequest.validate(nil, false); err != nil {
+			return &vissRequest, err
+		}
+		if strings.Contains(*vissRequest.Path, "*") {
+			return &vissRequest, &RequestError{"path", "wildcards are not allowed in set request"}
+		}
+		if vissRequest.Timeout != nil {
+			if timeout, err := strconv.Atoi(*vissRequest.Timeout); err != nil || timeout <= 0 {
+				return &vissRequest, &RequestError{"timeout", "must be a positive integer number of milliseconds"}
+			}
+		}
+		return &vissRequest, vissRequest.validateSetValue()
+	case "history-control":
+		if err := vissRequest.validate(nil, false); err != nil {
+			return &vissRequest, err
+		}
+		if len(vissRequest.Filter) > 0 {
+			return &vissRequest, &RequestError{"filter", "not allowed in history-control request"}
+		}
+		return &vissRequest, vissRequest.validateHistoryControl()
+	case "unsubscribe":
+		if vissRequest.SubscriptionId == nil || len(*vissRequest.SubscriptionId) == 0 {
+			return &vissRequest, &RequestError{"subscriptionId", "missing"}
+		}
+		return &vissRequest, nil
+	case "internal-killsubscriptions", "internal-cancelsubscription":
+		return &vissRequest, nil
+	case "":
+		return &vissRequest, &RequestError{"action", "missing"}
+	}
+	return &vissRequest, &RequestError{"action", "unknown action " + strconv.Quote(vissRequest.Action)}
+}
+
+func (vissRequest *VissRequest) validate(filterTypes []string, checkFilter bool) error {
+	if vissRequest.Path == nil || len(*vissRequest.Path) == 0 {
+		return &RequestError{"path", "missing"}
+	}
+	if err := validateWildcards(*vissRequest.Path, "path"); err != nil {
+		return err
+	}
+	if !checkFilter || len(vissRequest.Filter) == 0 {
+		return nil
+	}
+	var err error
+	vissRequest.FilterList, err = parseFilter(vissRequest.Filter)
+	if err != nil {
+		return err
+	}
+	numOfPathsFilters := 0
+	for i, filterExp := range vissRequest.FilterList {
+		field := "filter"
+		if vissRequest.Filter[0] == '[' {
+			field += "[" + strconv.Itoa(i) + "]"
+		}
+		if !containsString(filterTypes, filterExp.Type) {
+			return &RequestError{field + ".type", "filter type " + strconv.Quote(filterExp.Type) + " not supported in " + vissRequest.Action + " request"}
+		}
+		if filterExp.Type == "paths" {
+			numOfPathsFilters++
+			if numOfPathsFilters > 1 {
+				return &RequestError{field, "only one paths filter is allowed"}
+			}
+			if strings.HasSuffix(*vissRequest.Path, "**") {
+				return &RequestError{"path", "\"**\" can not be combined with a paths filter"}
+			}
+		}
+		if err = validateFilterParameter(filterExp, field+".parameter"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A subscribe request that resumes a subscription has the resume token instead of a path and filter.
+func (vissRequest *VissRequest) validateResume() error {
+	if len(*vissRequest.ResumeToken) == 0 {
+		return &RequestError{"resumeToken", "must not be empty"}
+	}
+	if vissRequest.Path != nil {
+		return &RequestError{"path", "not allowed when resuming a subscription"}
+	}
+	if len(vissRequest.Filter) > 0 {
+		return &RequestError{"filter", "not allowed when resuming a subscription"}
+	}
+	return nil
+}
+
+// The value of a set is either a string, or a non-empty array of path/value pairs that are all set, or none of them.
+func (vissRequest *VissRequest) validateSetValue() error {
+	if len(vissRequest.Value) == 0 || string(vissRequest.Value) == "null" {
+		return &RequestError{"value", "missing"}
+	}
+	if vissRequest.Value[0] != '[' {
+		var value string
+		return strictUnmarshal(vissRequest.Value, &value, "value")
+	}
+	var valueList []json.RawMessage
+	if err := strictUnmarshal(vissRequest.Value, &valueList, "value"); err != nil {
+		return err
+	}
+	if len(valueList) == 0 {
+		return &RequestError{"value", "empty value array"}
+	}
+	vissRequest.ValueList = make([]PathValue, len(valueList))
+	for i := range valueList { // one by one, for the errors to name the element
+		if err := strictUnmarshal(valueList[i], &vissRequest.ValueList[i], "value["+strconv.Itoa(i)+"]"); err != nil {
+			return err
+		}
+	}
+	for i, pathValue := range vissRequest.ValueList {
+		field := "value[" + strconv.Itoa(i) + "].path"
+		if len(pathValue.Path) == 0 {
+			return &RequestError{field, "missing"}
+		}
+		if strings.Contains(pathValue.Path, "*") {
+			return &RequestError{field, "wildcards are not allowed in set request"}
+		}
+		for j := 0; j < i; j++ {
+			if UrlToPath(vissRequest.ValueList[j].Path) == UrlToPath(pathValue.Path) {
+				return &RequestError{field, "duplicate path " + strconv.Quote(pathValue.Path)}
+			}
+		}
+	}
+	return nil
+}
+
+// The parameters of a history-control command are only allowed for the command they apply to.
+func (vissRequest *VissRequest) validateHistoryControl() error {
+	if !containsString(historyCommands, vissRequest.Command) {
+		return &RequestError{"command", "must be one of " + strings.Join(historyCommands, ", ")}
+	}
+	if vissRequest.BufSize != nil {
+		if vissRequest.Command != "create" {
+			return &RequestError{"buf-size", "only allowed in create command"}
+		}
+		if bufSize, err := strconv.Atoi(*vissRequest.BufSize); err != nil || bufSize < 0 {
+			return &RequestError{"buf-size", "must be a non-negative integer"}
+		}
+	}
+	if vissRequest.Retention != nil {
+		if vissRequest.Command != "create" {
+			return &RequestError{"retention", "only allowed in create command"}
+		}
+		if _, err := ParseIsoDuration(*vissRequest.Retention); err != nil {
+			return &RequestError{"retention", "must be an ISO 8601 duration, e.g. P7D"}
+		}
+	}
+	if vissRequest.Frequency != nil && vissRequest.Command != "start" {
+		return &RequestError{"frequency", "only allowed in start command"}
+	}
+	if vissRequest.Command == "start" {
+		if vissRequest.Frequency == nil {
+			return &RequestError{"frequency", "missing"}
+		}
+		if frequency, err := strconv.Atoi(*vissRequest.Frequency); err != nil || frequency <= 0 {
+			return &RequestError{"frequency", "must be a positive integer number of captures per hour"}
+		}
+	}
+	return nil
+}
+
+// A filter is either a single filter expression, or an array of them.
+func parseFilter(filter json.RawMessage) ([]FilterExpression, error) {
+	var filterList []FilterExpression
+	switch filter[0] {
+	case '{':
+		filterList = make([]FilterExpression, 1)
+		if err := strictUnmarshal(filter, &filterList[0], "filter"); err != nil {
+			return nil, err
+		}
+	case '[':
+		if err := strictUnmarshal(filter, &filterList, "filter"); err != nil {
+			return nil, err
+		}
+		if len(filterList) == 0 {
+			return nil, &RequestError{"filter", "empty filter array"}
+		}
+	default:
+		return nil, &RequestError{"filter", "must be an object or an array of objects"}
+	}
+	for i := range filterList {
+		field := "filter"
+		if filter[0] == '[' {
+			field += "[" + strconv.Itoa(i) + "]"
+		}
+		if len(filterList[i].Type) == 0 {
+			return nil, &RequestError{field + ".type", "missing"}
+		}
+		if len(filterList[i].Parameter) == 0 {
+			return nil, &RequestError{field + ".parameter", "missing"}
+		}
+	}
+	return filterList, nil
+}
+
+func validateFilterParameter(filterExp FilterExpression, field string) error {
+	switch filterExp.Type {
+	case "paths":
+		var paths []string
+		if filterExp.Parameter[0] == '[' {
+			if err := strictUnmarshal(filterExp.Parameter, &paths, field); err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				return &RequestError{field, "empty paths array"}
+			}
+		} else {
+			paths = make([]string, 1)
+			if err := strictUnmarshal(filterExp.Parameter, &paths[0], field); err != nil {
+				return err
+			}
+		}
+		for i := range paths {
+			if len(paths[i]) == 0 {
+				return &RequestError{field, "empty path"}
+			}
+			if err := validateWildcards(paths[i], field); err != nil {
+				return err
+			}
+		}
+	case "timebased":
+		var timebased TimebasedParameter
+		if err := strictUnmarshal(filterExp.Parameter, &timebased, field); err != nil {
+			return err
+		}
+		if period, err := strconv.Atoi(timebased.Period); err != nil || period <= 0 {
+			return &RequestError{field + ".period", "must be a positive integer number of milliseconds"}
+		}
+	case "range":
+		var rangeList []RangeParameter
+		if filterExp.Parameter[0] == '[' {
+			if err := strictUnmarshal(filterExp.Parameter, &rangeList, field); err != nil {
+				return err
+			}
+			if len(rangeList) == 0 || len(rangeList) > 2 {
+				return &RequestError{field, "must contain one or two range expressions"}
+			}
+		} else {
+			rangeList = make([]RangeParameter, 1)
+			if err := strictUnmarshal(filterExp.Parameter, &rangeList[0], field); err != nil {
+				return err
+			}
+		}
+		for i := range rangeList {
+			if !containsString(logicOperators, rangeList[i].LogicOp) {
+				return &RequestError{field + ".logic-op", "must be one of " + strings.Join(logicOperators, ", ")}
+			}
+			if !isNumber(rangeList[i].Boundary) {
+				return &RequestError{field + ".boundary", "must be a number"}
+			}
+		}
+	case "change":
+		var change ChangeParameter
+		if err := strictUnmarshal(filterExp.Parameter, &change, field); err != nil {
+			return err
+		}
+		if !containsString(logicOperators, change.LogicOp) {
+			return &RequestError{field + ".logic-op", "must be one of " + strings.Join(logicOperators, ", ")}
+		}
+		if !isNumber(change.Diff) {
+			return &RequestError{field + ".diff", "must be a number"}
+		}
+	case "curvelog":
+		var curvelog CurvelogParameter
+		if err := strictUnmarshal(filterExp.Parameter, &curvelog, field); err != nil {
+			return err
+		}
+		if maxErr, err := strconv.ParseFloat(curvelog.MaxErr, 64); err != nil || maxErr < 0 {
+			return &RequestError{field + ".maxerr", "must be a non-negative number"}
+		}
+		if bufSize, err := strconv.Atoi(curvelog.BufSize); err != nil || bufSize <= 0 {
+			return &RequestError{field + ".bufsize", "must be a positive integer"}
+		}
+	case "history":
+		if _, err := parseHistoryParameter(filterExp.Parameter, time.Now(), field); err != nil {
+			return err
+		}
+	case "static-metadata":
+		var tree string
+		if err := strictUnmarshal(filterExp.Parameter, &tree, field); err != nil {
+			return err
+		}
+	case "dynamic-metadata":
+		var domain string
+		if err := strictUnmarshal(filterExp.Parameter, &domain, field); err != nil {
+			return err
+		}
+		if !containsString(metadataDomains, domain) {
+			return &RequestError{field, "must be one of " + strings.Join(metadataDomains, ", ")}
+		}
+	}
+	return nil
+}
+
+/*
+* A path segment is either a node name, the wildcard "*" that matches any single node name, or the wildcard "**" that matches all nodes below.
+* "**" must be the last segment. Segments are separated by dots or slashes.
+ */
+func validateWildcards(path string, field string) error {
+	segments := strings.FieldsFunc(path, func(c rune) bool { return c == '.' || c == '/' })
+	for i, segment := range segments {
+		if !strings.Contains(segment, "*") || segment == "*" {
+			continue
+		}
+		if segment != "**" {
+			return &RequestError{field, "wildcard must be a complete path segment, " + strconv.Quote(segment)}
+		}
+		if i != len(segments)-1 {
+			return &RequestError{field, "\"**\" is only allowed as the last path segment"}
+		}
+	}
+	return nil
+}
+
+// Decodes data into v, rejecting unknown object members and trailing data. Errors are reported relative to field.
+func strictUnmarshal(data []byte, v interface{}, field string) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil {
+		if _, err = decoder.Token(); err != io.EOF {
+			return &RequestError{field, "unexpected data after JSON value"}
+		}
+		return nil
+	}
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		if len(typeError.Field) > 0 {
+			field = joinField(field, typeError.Field)
+		}
+		return &RequestError{field, "must be of JSON type " + jsonTypeName(typeError.Type.Kind().String())}
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		member := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		if unquoted, err := strconv.Unquote(member); err == nil {
+			member = unquoted
+		}
+		return &RequestError{joinField(field, member), "unknown member"}
+	}
+	return &RequestError{field, "malformed JSON, " + err.Error()}
+}
+
+func joinField(parent string, child string) string { // child is a dot separated member path, where array elements are numbers
+	field := parent
+	for _, member := range strings.Split(child, ".") {
+		if _, err := strconv.Atoi(member); err == nil {
+			field += "[" + member + "]"
+		} else if len(field) == 0 {
+			field = member
+		} else {
+			field += "." + member
+		}
+	}
+	return field
+}
+
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string", "ptr":
+		return "string"
+	case "slice":
+		return "array"
+	case "struct", "map":
+		return "object"
+	}
+	return kind
+}
+
+func containsString(list []string, element string) bool {
+	for i := range list {
+		if list[i] == element {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+/*
+* Resolves the parameter of a history filter, as found in FilterObject.Parameter, i.e. either an ISO 8601 duration,
+* or a HistoryParameter JSON object. Relative times are resolved against now.
+ */
+func ParseHistoryFilter(parameter string, now time.Time) (HistoryRange, error) {
+	rawParameter := []byte(parameter)
+	if !strings.HasPrefix(parameter, "{") {
+		rawParameter, _ = json.Marshal(parameter)
+	}
+	return parseHistoryParameter(rawParameter, now, "parameter")
+}
+
+func parseHistoryParameter(parameter json.RawMessage, now time.Time, field string) (HistoryRange, error) {
+	historyRange := HistoryRange{End: now, Aggregate: "last"}
+	if !bytes.HasPrefix(bytes.TrimSpace(parameter), []byte("{")) {
+		var period string
+		if err := strictUnmarshal(parameter, &period, field); err != nil {
+			return historyRange, err
+		}
+		duration, err := ParseIsoDuration(period)
+		if err != nil {
+			return historyRange, &RequestError{field, "must be an ISO 8601 duration, e.g. P2DT12H, or an object with start and end"}
+		}
+		historyRange.Start = now.Add(-duration)
+		return historyRange, nil
+	}
+	var historyParameter HistoryParameter
+	if err := strictUnmarshal(parameter, &historyParameter, field); err != nil {
+		return historyRange, err
+	}
+	var err error
+	if len(historyParameter.End) > 0 {
+		if historyRange.End, err = time.Parse(time.RFC3339, historyParameter.End); err != nil {
+			return historyRange, &RequestError{field + ".end", "must be an RFC3339 time"}
+		}
+	}
+	switch {
+	case len(historyParameter.Start) > 0 && len(historyParameter.Period) > 0:
+		return historyRange, &RequestError{field + ".period", "not allowed together with start"}
+	case len(historyParameter.Start) > 0:
+		if historyRange.Start, err = time.Parse(time.RFC3339, historyParameter.Start); err != nil {
+			return historyRange, &RequestError{field + ".start", "must be an RFC3339 time"}
+		}
+	case len(historyParameter.Period) > 0:
+		duration, err := ParseIsoDuration(historyParameter.Period)
+		if err != nil {
+			return historyRange, &RequestError{field + ".period", "must be an ISO 8601 duration"}
+		}
+		historyRange.Start = historyRange.End.Add(-duration)
+	default:
+		return historyRange, &RequestError{field + ".start", "start or period is required"}
+	}
+	if !historyRange.Start.Before(historyRange.End) {
+		return historyRange, &RequestError{field + ".end", "must be after start"}
+	}
+	if len(historyParameter.Interval) > 0 {
+		historyRange.Interval, err = ParseIsoDuration(historyParameter.Interval)
+		if err != nil || historyRange.Interval <= 0 {
+			return historyRange, &RequestError{field + ".interval", "must be a positive ISO 8601 duration"}
+		}
+	}
+	if len(historyParameter.Aggregate) > 0 {
+		if historyRange.Interval == 0 {
+			return historyRange, &RequestError{field + ".aggregate", "requires an interval"}
+		}
+		if !containsString(historyAggregates, historyParameter.Aggregate) {
+			return historyRange, &RequestError{field + ".aggregate", "must be one of " + strings.Join(historyAggregates, ", ")}
+		}
+		historyRange.Aggregate = historyParameter.Aggregate
+	}
+	return historyRange, nil
+}
+
+var isoDurationRegexp = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// Formats a duration as an ISO 8601 duration like "P2DT12H", which ParseIsoDuration parses back to the same duration.
+func FormatIsoDuration(duration time.Duration) string {
+	isoDuration := "P"
+	if days := duration / (24 * time.Hour); days > 0 {
+		isoDuration += strconv.FormatInt(int64(days), 10) + "D"
+		duration -= days * 24 * time.Hour
+	}
+	if duration == 0 {
+		if isoDuration == "P" {
+			return "PT0S"
+		}
+		return isoDuration
+	}
+	isoDuration += "T"
+	if hours := duration / time.Hour; hours > 0 {
+		isoDuration += strconv.FormatInt(int64(hours), 10) + "H"
+		duration -= hours * time.Hour
+	}
+	if minutes := duration / time.Minute; minutes > 0 {
+		isoDuration += strconv.FormatInt(int64(minutes), 10) + "M"
+		duration -= minutes * time.Minute
+	}
+	if duration > 0 {
+		isoDuration += strconv.FormatFloat(duration.Seconds(), 'f', -1, 64) + "S"
+	}
+	return isoDuration
+}
+
+// Parses an ISO 8601 duration like "P2DT12H". A year is counted as 365 days and a month as 30 days.
+func ParseIsoDuration(isoDuration string) (time.Duration, error) {
+	match := isoDurationRegexp.FindStringSubmatch(isoDuration)
+	if match == nil || isoDuration == "P" || strings.HasSuffix(isoDuration, "T") {
+		return 0, errors.New("invalid ISO 8601 duration " + strconv.Quote(isoDuration))
+	}
+	units := []time.Duration{365 * 24 * time.Hour, 30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if len(match[i+1]) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(value * float64(unit))
+	}
+	return duration, nil
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseVissRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string
+		errField string // empty if the request is valid
+	}{
+		{"get", `{"action":"get","path":"Vehicle.Speed","requestId":"1"}`, ""},
+		{"get with routing info", `{"RouterId":"0?0", "origin":"external", "action":"get","path":"Vehicle/Speed","authorization":"a.b.c"}`, ""},
+		{"get missing path", `{"action":"get","requestId":"1"}`, "path"},
+		{"get path of wrong type", `{"action":"get","path":42}`, "path"},
+		{"unknown member", `{"action":"get","path":"Vehicle.Speed","pat":"x"}`, "pat"},
+		{"missing action", `{"path":"Vehicle.Speed"}`, "action"},
+		{"unknown action", `{"action":"fetch","path":"Vehicle.Speed"}`, "action"},
+		{"wildcard", `{"action":"get","path":"Vehicle.Cabin.Door.*.*.IsOpen"}`, ""},
+		{"wildcard any depth", `{"action":"subscribe","path":"Vehicle/Cabin/**"}`, ""},
+		{"wildcard within segment", `{"action":"get","path":"Vehicle.Cabin.Do*r.Row1"}`, "path"},
+		{"wildcard any depth not last", `{"action":"get","path":"Vehicle.**.IsOpen"}`, "path"},
+		{"wildcard any depth with paths filter", `{"action":"get","path":"Vehicle.**","filter":{"type":"paths","parameter":"Speed"}}`, "path"},
+		{"paths filter wildcard not last", `{"action":"get","path":"Vehicle.Cabin","filter":{"type":"paths","parameter":["Door.**.IsOpen"]}}`, "filter.parameter"},
+		{"path containing a filter name", `{"action":"subscribe","path":"Vehicle.Cabin.Sunroof.Position.range"}`, ""},
+
+		{"paths", `{"action":"get","path":"Vehicle.ADAS","filter":{"type":"paths","parameter":["ABS/*","CruiseControl/Error"]}}`, ""},
+		{"paths single", `{"action":"get","path":"Vehicle.ADAS.ABS","filter":{"type":"paths","parameter":"*"}}`, ""},
+		{"paths empty array", `{"action":"get","path":"Vehicle.ADAS","filter":{"type":"paths","parameter":[]}}`, "filter.parameter"},
+		{"paths two filters", `{"action":"get","path":"Vehicle","filter":[{"type":"paths","parameter":"Speed"},{"type":"paths","parameter":"Width"}]}`, "filter[1]"},
+
+		{"history", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":"P2DT12H"}}`, ""},
+		{"history malformed", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":"2 days"}}`, "filter.parameter"},
+		{"history time range", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"start":"2023-05-01T00:00:00Z","end":"2023-05-03T00:00:00Z","interval":"PT1H","aggregate":"avg"}}}`, ""},
+		{"history period", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"period":"P7D","interval":"PT1M"}}}`, ""},
+		{"history missing start", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"end":"2023-05-03T00:00:00Z"}}}`, "filter.parameter.start"},
+		{"history end before start", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"start":"2023-05-03T00:00:00Z","end":"2023-05-01T00:00:00Z"}}}`, "filter.parameter.end"},
+		{"history aggregate without interval", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"period":"P1D","aggregate":"max"}}}`, "filter.parameter.aggregate"},
+		{"history unknown aggregate", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"history","parameter":{"period":"P1D","interval":"PT1H","aggregate":"median"}}}`, "filter.parameter.aggregate"},
+
+		{"static-metadata", `{"action":"get","path":"Vehicle.ADAS.ABS","filter":{"type":"static-metadata","parameter":""}}`, ""},
+		{"static-metadata wrong type", `{"action":"get","path":"Vehicle.ADAS.ABS","filter":{"type":"static-metadata","parameter":2}}`, "filter.parameter"},
+
+		{"dynamic-metadata", `{"action":"get","path":"Vehicle","filter":{"type":"dynamic-metadata","parameter":"server_capabilities"}}`, ""},
+		{"dynamic-metadata unknown domain", `{"action":"get","path":"Vehicle","filter":{"type":"dynamic-metadata","parameter":"colour"}}`, "filter.parameter"},
+
+		{"timebased", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"timebased","parameter":{"period":"100"}}}`, ""},
+		{"timebased in get", `{"action":"get","path":"Vehicle.Speed","filter":{"type":"timebased","parameter":{"period":"100"}}}`, "filter.type"},
+		{"timebased zero period", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"timebased","parameter":{"period":"0"}}}`, "filter.parameter.period"},
+		{"timebased numeric period", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"timebased","parameter":{"period":100}}}`, "filter.parameter.period"},
+
+		{"range", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"range","parameter":{"logic-op":"gt","boundary":"100"}}}`, ""},
+		{"range two boundaries", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"range","parameter":[{"logic-op":"gt","boundary":"50"},{"logic-op":"lt","boundary":"100"}]}}`, ""},
+		{"range bad logic-op", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"range","parameter":{"logic-op":"greater","boundary":"100"}}}`, "filter.parameter.logic-op"},
+		{"range missing boundary", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"range","parameter":{"logic-op":"gt"}}}`, "filter.parameter.boundary"},
+
+		{"change", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"change","parameter":{"logic-op":"ne","diff":"0"}}}`, ""},
+		{"change bad diff", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"change","parameter":{"logic-op":"ne","diff":"some"}}}`, "filter.parameter.diff"},
+
+		{"curvelog", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"curvelog","parameter":{"maxerr":"0.5","bufsize":"100"}}}`, ""},
+		{"curvelog bad bufsize", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"curvelog","parameter":{"maxerr":"0.5","bufsize":"-1"}}}`, "filter.parameter.bufsize"},
+		{"curvelog unknown member", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"curvelog","parameter":{"maxerr":"0.5","bufsize":"10","x":"1"}}}`, "filter.parameter.x"},
+
+		{"filter array", `{"action":"subscribe","path":"Vehicle","filter":[{"type":"paths","parameter":["Speed","Width"]},{"type":"timebased","parameter":{"period":"500"}}]}`, ""},
+		{"filter missing parameter", `{"action":"subscribe","path":"Vehicle.Speed","filter":{"type":"timebased"}}`, "filter.parameter"},
+		{"filter missing type", `{"action":"subscribe","path":"Vehicle.Speed","filter":[{"parameter":"x"}]}`, "filter[0].type"},
+		{"filter of wrong type", `{"action":"subscribe","path":"Vehicle.Speed","filter":"range"}`, "filter"},
+
+		{"set", `{"action":"set","path":"Vehicle.Body.Trunk.Rear.IsOpen","value":"true"}`, ""},
+		{"set wildcard", `{"action":"set","path":"Vehicle.Body.Trunk.*.IsOpen","value":"true"}`, "path"},
+		{"set value of wrong type", `{"action":"set","path":"Vehicle.Body.Trunk.Rear.IsOpen","value":true}`, "value"},
+		{"set multiple", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[{"path":"Station.Row1.Driver.Temperature","value":"22"},{"path":"Station/Row1/Driver/FanSpeed","value":"40"}]}`, ""},
+		{"set multiple empty", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[]}`, "value"},
+		{"set multiple missing path", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[{"path":"Station.Row1.Driver.Temperature","value":"22"},{"value":"40"}]}`, "value[1].path"},
+		{"set multiple duplicate path", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[{"path":"Station.Row1.Driver.Temperature","value":"22"},{"path":"Station/Row1/Driver/Temperature","value":"23"}]}`, "value[1].path"},
+		{"set multiple wildcard", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[{"path":"Station.*.Driver.Temperature","value":"22"}]}`, "value[0].path"},
+		{"set multiple unknown member", `{"action":"set","path":"Vehicle.Cabin.HVAC","value":[{"path":"Station.Row1.Driver.Temperature","val":"22"}]}`, "value[0].val"},
+		{"set with timeout", `{"action":"set","path":"Vehicle.Body.Trunk.Rear.IsOpen","value":"true","timeout":"5000"}`, ""},
+		{"set with invalid timeout", `{"action":"set","path":"Vehicle.Body.Trunk.Rear.IsOpen","value":"true","timeout":"0"}`, "timeout"},
+		{"get with timeout", `{"action":"get","path":"Vehicle.Body.Trunk.Rear.IsOpen","timeout":"5000"}`, "timeout"},
+		{"set array value", `{"action":"set","path":"Vehicle.Cabin.Seat.Row1.DriverSide.Massage","value":"[\"1\", \"2\"]"}`, ""},
+		{"set array value not in a string", `{"action":"set","path":"Vehicle.Cabin.Seat.Row1.DriverSide.Massage","value":["1","2"]}`, "value[0]"},
+		{"set missing value", `{"action":"set","path":"Vehicle.Body.Trunk.Rear.IsOpen"}`, "value"},
+		{"history-control create", `{"action":"history-control","path":"Vehicle.Speed","command":"create","buf-size":"1000","retention":"P7D"}`, ""},
+		{"history-control start", `{"action":"history-control","path":"Vehicle.Cabin.Door.*.*.IsOpen","command":"start","frequency":"3600","authorization":"a.b.c"}`, ""},
+		{"history-control list", `{"action":"history-control","path":"Vehicle.**","command":"list"}`, ""},
+		{"history-control unknown command", `{"action":"history-control","path":"Vehicle.Speed","command":"pause"}`, "command"},
+		{"history-control start without frequency", `{"action":"history-control","path":"Vehicle.Speed","command":"start"}`, "frequency"},
+		{"history-control malformed retention", `{"action":"history-control","path":"Vehicle.Speed","command":"create","retention":"7 days"}`, "retention"},
+		{"history-control parameter of other command", `{"action":"history-control","path":"Vehicle.Speed","command":"stop","buf-size":"10"}`, "buf-size"},
+		{"subscribe resume", `{"action":"subscribe","resumeToken":"5f1c0a","requestId":"7"}`, ""},
+		{"subscribe resume with path", `{"action":"subscribe","path":"Vehicle.Speed","resumeToken":"5f1c0a"}`, "path"},
+		{"subscribe resume empty token", `{"action":"subscribe","resumeToken":""}`, "resumeToken"},
+		{"resume token in get", `{"action":"get","path":"Vehicle.Speed","resumeToken":"5f1c0a"}`, "resumeToken"},
+		{"unsubscribe", `{"action":"unsubscribe","subscriptionId":"1"}`, ""},
+		{"unsubscribe missing id", `{"action":"unsubscribe"}`, "subscriptionId"},
+		{"internal", `{"action": "internal-cancelsubscription", "gatingId":"abc"}`, ""},
+	}
+	for _, test := range tests {
+		_, err := ParseVissRequest(test.request)
+		if len(test.errField) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", test.name, err)
+			}
+			continue
+		}
+		requestError, ok := err.(*RequestError)
+		if !ok {
+			t.Errorf("%s: expected RequestError for %s, got %v", test.name, test.errField, err)
+			continue
+		}
+		if requestError.Field != test.errField {
+			t.Errorf("%s: expected error on %s, got %s", test.name, test.errField, requestError)
+		}
+	}
+}
+
+// A value of an array datatype is a JSON array in a string, as an array value is a multi-path set.
+func TestParseSetArrayValue(t *testing.T) {
+	vissRequest, err := ParseVissRequest(`{"action":"set","path":"Vehicle.Cabin.Seat.Row1.DriverSide.Massage","value":"[\"1\", \"2\"]"}`)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	var value string
+	if json.Unmarshal(vissRequest.Value, &value); value != `["1", "2"]` || len(vissRequest.ValueList) > 0 {
+		t.Errorf("array value parsed as value=%s, value list=%v", value, vissRequest.ValueList)
+	}
+}
+
+func TestParseIsoDuration(t *testing.T) {
+	tests := []struct {
+		isoDuration string
+		duration    time.Duration
+		valid       bool
+	}{
+		{"P2DT12H", 60 * time.Hour, true},
+		{"PT1M30S", 90 * time.Second, true},
+		{"PT0.5S", 500 * time.Millisecond, true},
+		{"P1W", 7 * 24 * time.Hour, true},
+		{"P7DT1H30M0.25S", 169*time.Hour + 30*time.Minute + 250*time.Millisecond, true},
+		{"P", 0, false},
+		{"PT", 0, false},
+		{"2DT12H", 0, false},
+	}
+	for _, test := range tests {
+		duration, err := ParseIsoDuration(test.isoDuration)
+		if (err == nil) != test.valid || duration != test.duration {
+			t.Errorf("ParseIsoDuration(%s)=%s, err=%v", test.isoDuration, duration, err)
+		}
+		if test.valid && test.isoDuration != "P1W" { // weeks are formatted as days
+			if isoDuration := FormatIsoDuration(duration); isoDuration != test.isoDuration {
+				t.Errorf("FormatIsoDuration(%s)=%s, expected %s", duration, isoDuration, test.isoDuration)
+			}
+		}
+	}
+}
+
+func TestParseHistoryFilter(t *testing.T) {
+	now := time.Date(2023, 5, 3, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		parameter string
+		expected  HistoryRange
+	}{
+		{"P2DT12H", HistoryRange{now.Add(-60 * time.Hour), now, 0, "last"}},
+		{`{"start":"2023-05-01T00:00:00Z","end":"2023-05-02T00:00:00Z"}`, HistoryRange{time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC), 0, "last"}},
+		{`{"period":"PT1H","interval":"PT1M","aggregate":"avg"}`, HistoryRange{now.Add(-time.Hour), now, time.Minute, "avg"}},
+	}
+	for _, test := range tests {
+		historyRange, err := ParseHistoryFilter(test.parameter, now)
+		if err != nil {
+			t.Errorf("ParseHistoryFilter(%s): unexpected error %s", test.parameter, err)
+			continue
+		}
+		if !historyRange.Start.Equal(test.expected.Start) || !historyRange.End.Equal(test.expected.End) ||
+			historyRange.Interval != test.expected.Interval || historyRange.Aggregate != test.expected.Aggregate {
+			t.Errorf("ParseHistoryFilter(%s)=%+v, expected %+v", test.parameter, historyRange, test.expected)
+		}
+	}
+}
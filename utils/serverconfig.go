@@ -0,0 +1,226 @@
+/**
+* (C) 2023 Ford Motor Company
+*
+* All files and artifacts in the repository at https://github.com/w3c/automotive-viss2
+* are licensed under the provisions of the license provided by the LICENSE file in this repository.
+*
+**/
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const unixAddressPrefix = "unix:"
+
+/*
+* The listener of a server component.
+* An address is host:port, [IPv6 address]:port, or unix:/path/to/socket. An empty host listens on all interfaces.
+* The TLS address is used instead when transport security is applied, and defaults to the port in transportSec.json.
+* Timeouts are Go durations, like "15s", where empty or "0" means no timeout.
+ */
+type ListenerConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	Address      string `json:"address" yaml:"address"`
+	TlsAddress   string `json:"tls_address" yaml:"tls_address"`
+	ReadTimeout  string `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout string `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout  string `json:"idle_timeout" yaml:"idle_timeout"`
+}
+
+/*
+* The listener configuration, read from the server-config.json file, or the listeners section of vissv2server.yaml. Members that are left out get the default values.
+* Only enabled is used for MQTT, as the server connects to the broker configured in mqtt-config.json.
+* The core listener serves the VSS path list and the client queue metrics, and cannot be disabled.
+* The ECF address is the address that the access token server connects to when consent support is enabled.
+ */
+type ServerConfig struct {
+	Http       ListenerConfig `json:"http" yaml:"http"`
+	Ws         ListenerConfig `json:"ws" yaml:"ws"`
+	Mqtt       ListenerConfig `json:"mqtt" yaml:"mqtt"`
+	Grpc       ListenerConfig `json:"grpc" yaml:"grpc"`
+	Ats        ListenerConfig `json:"ats" yaml:"ats"`
+	Core       ListenerConfig `json:"core" yaml:"core"`
+	EcfAddress string         `json:"ecf_address" yaml:"ecf_address"`
+}
+
+var ServerConfiguration = DefaultServerConfig()
+
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Http:       ListenerConfig{Enabled: true, Address: ":8888"},
+		Ws:         ListenerConfig{Enabled: true, Address: ":8080"},
+		Mqtt:       ListenerConfig{Enabled: false},
+		Grpc:       ListenerConfig{Enabled: true, Address: "0.0.0.0:8887"},
+		Ats:        ListenerConfig{Enabled: true, Address: ":8600"},
+		Core:       ListenerConfig{Enabled: true, Address: "0.0.0.0:8081", ReadTimeout: "15s", WriteTimeout: "15s", IdleTimeout: "60s"},
+		EcfAddress: "localhost:8445",
+	}
+}
+
+// Reads the server configuration file. The default configuration is returned if the file does not exist.
+func ReadServerConfig(fileName string) (ServerConfig, error) {
+	config := DefaultServerConfig()
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			Info.Printf("ReadServerConfig: %s not found, default configuration is used", fileName)
+			return config, nil
+		}
+		return config, err
+	}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return config, config.Validate()
+}
+
+func (config ServerConfig) Validate() error {
+	names := []string{"http", "ws", "grpc", "ats", "core"}
+	for i, listener := range []ListenerConfig{config.Http, config.Ws, config.Grpc, config.Ats, config.Core} {
+		if err := listener.validate(); err != nil {
+			return fmt.Errorf("%s: %s", names[i], err)
+		}
+	}
+	if !config.Core.Enabled {
+		return errors.New("core: the core listener cannot be disabled")
+	}
+	if config.EcfAddress == "" {
+		return errors.New("ecf_address: missing")
+	}
+	return nil
+}
+
+func (listener ListenerConfig) validate() error {
+	if !listener.Enabled {
+		return nil
+	}
+	if listener.Address == "" {
+		return errors.New("address: missing")
+	}
+	for _, address := range []string{listener.Address, listener.TlsAddress} {
+		if err := validateAddress(address); err != nil {
+			return err
+		}
+	}
+	for _, timeout := range []string{listener.ReadTimeout, listener.WriteTimeout, listener.IdleTimeout} {
+		if _, err := parseTimeout(timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAddress(address string) error {
+	if address == "" {
+		return nil
+	}
+	if strings.HasPrefix(address, unixAddressPrefix) {
+		if len(address) == len(unixAddressPrefix) {
+			return fmt.Errorf("address %s: missing socket path", address)
+		}
+		return nil
+	}
+	_, _, err := net.SplitHostPort(address) // the error names the address
+	return err
+}
+
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("timeout %s: not a positive duration", timeout)
+	}
+	return duration, nil
+}
+
+// Returns the address to listen on, which depends on whether transport security is applied. secPort is the port in transportSec.json.
+func (listener ListenerConfig) ListenAddress(secPort string) string {
+	if SecureConfiguration.TransportSec == "yes" {
+		if listener.TlsAddress != "" {
+			return listener.TlsAddress
+		}
+		return ":" + secPort
+	}
+	return listener.Address
+}
+
+// Returns the port of the address, or the address itself for a unix socket.
+func AddressPort(address string) string {
+	if strings.HasPrefix(address, unixAddressPrefix) {
+		return address
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return port
+}
+
+// Listens on a TCP address, or on a unix socket, in which case a socket file left by an earlier run is removed first.
+func Listen(address string) (net.Listener, error) {
+	if path, isUnix := strings.CutPrefix(address, unixAddressPrefix); isUnix {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", address)
+}
+
+// Returns the read, write, and idle timeouts of the listener, where 0 means no timeout.
+func (listener ListenerConfig) Timeouts() (time.Duration, time.Duration, time.Duration) {
+	readTimeout, _ := parseTimeout(listener.ReadTimeout) // validated when read
+	writeTimeout, _ := parseTimeout(listener.WriteTimeout)
+	idleTimeout, _ := parseTimeout(listener.IdleTimeout)
+	return readTimeout, writeTimeout, idleTimeout
+}
+
+// Returns an HTTP server with the timeouts of the listener.
+func (listener ListenerConfig) NewHttpServer(handler http.Handler) *http.Server {
+	readTimeout, writeTimeout, idleTimeout := listener.Timeouts()
+	return &http.Server{Handler: handler, ReadTimeout: readTimeout, WriteTimeout: writeTimeout, IdleTimeout: idleTimeout}
+}
+
+// Serves on the address, with TLS if the certificate and key files are given. It only returns on failure.
+func ServeHttp(server *http.Server, address string, certFile string, keyFile string) error {
+	listener, err := Listen(address)
+	if err != nil {
+		return err
+	}
+	Info.Printf("ServeHttp: listening on %s", address)
+	if certFile != "" {
+		return server.ServeTLS(listener, certFile, keyFile)
+	}
+	return server.Serve(listener)
+}
+
+/*
+* Returns an HTTP client that connects to a listener address, and the host:port to use in the request URL.
+* Hosts that listen on all interfaces are reached on defaultHost.
+ */
+func NewListenerClient(address string, defaultHost string, timeout time.Duration) (*http.Client, string) {
+	if path, isUnix := strings.CutPrefix(address, unixAddressPrefix); isUnix {
+		transport := &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}}
+		return &http.Client{Timeout: timeout, Transport: transport}, "localhost"
+	}
+	host, port, _ := net.SplitHostPort(address)
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = defaultHost
+	}
+	return &http.Client{Timeout: timeout}, net.JoinHostPort(host, port)
+}
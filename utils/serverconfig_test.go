@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadServerConfig(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	dir := t.TempDir()
+	config, err := ReadServerConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || config != DefaultServerConfig() {
+		t.Errorf("Expected the default configuration, got %+v, err=%v", config, err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"ipv6", `{"http":{"enabled":true, "address":"[::1]:9888"}, "mqtt":{"enabled":true}}`, true},
+		{"unix", `{"core":{"enabled":true, "address":"unix:/tmp/vissv2-core.sock"}}`, true},
+		{"disabled", `{"grpc":{"enabled":false, "address":""}}`, true},
+		{"port", `{"ws":{"enabled":true, "address":"localhost"}}`, false},
+		{"socket", `{"ats":{"enabled":true, "address":"unix:"}}`, false},
+		{"timeout", `{"http":{"enabled":true, "address":":8888", "read_timeout":"soon"}}`, false},
+		{"core", `{"core":{"enabled":false}}`, false},
+		{"json", `{"http":`, false},
+	}
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.name+".json")
+		os.WriteFile(fileName, []byte(test.content), 0644)
+		if _, err := ReadServerConfig(fileName); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid=%t, err=%v", test.name, test.valid, err)
+		}
+	}
+	config, _ = ReadServerConfig(filepath.Join(dir, "ipv6.json"))
+	if config.Http.Address != "[::1]:9888" || !config.Mqtt.Enabled || config.Ws != DefaultServerConfig().Ws {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+}
+
+func TestListenAddress(t *testing.T) {
+	defer func() { SecureConfiguration = SecConfig{} }()
+	listener := ListenerConfig{Enabled: true, Address: "127.0.0.1:8888"}
+	SecureConfiguration = SecConfig{TransportSec: "no", HttpSecPort: "443"}
+	if address := listener.ListenAddress(SecureConfiguration.HttpSecPort); address != "127.0.0.1:8888" {
+		t.Errorf("Expected 127.0.0.1:8888, got %s", address)
+	}
+	SecureConfiguration.TransportSec = "yes"
+	if address := listener.ListenAddress(SecureConfiguration.HttpSecPort); address != ":443" || AddressPort(address) != "443" {
+		t.Errorf("Expected :443, got %s", address)
+	}
+	listener.TlsAddress = "unix:/run/vissv2/https.sock"
+	if address := listener.ListenAddress(SecureConfiguration.HttpSecPort); AddressPort(address) != listener.TlsAddress {
+		t.Errorf("Expected %s, got %s", listener.TlsAddress, address)
+	}
+}
+
+func TestServeUnixSocket(t *testing.T) {
+	InitLog("utils-log.txt", t.TempDir(), false, "error")
+	address := "unix:" + filepath.Join(t.TempDir(), "core.sock")
+	os.WriteFile(address[len("unix:"):], nil, 0644) // left by an earlier run
+	listener := ListenerConfig{Enabled: true, Address: address, ReadTimeout: "1s", WriteTimeout: "1s", IdleTimeout: "1s"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vsspathlist", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"LeafPaths":[]}`)) })
+	server := listener.NewHttpServer(mux)
+	if server.ReadTimeout != time.Second || server.IdleTimeout != time.Second {
+		t.Errorf("Timeouts not applied, got %+v", server)
+	}
+	go ServeHttp(server, address, "", "")
+	defer server.Close()
+	client, host := NewListenerClient(address, "localhost", time.Second)
+	var response *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if response, err = client.Get("http://" + host + "/vsspathlist"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Get over unix socket failed, err=%s", err)
+	}
+	defer response.Body.Close()
+	if body, _ := io.ReadAll(response.Body); string(body) != `{"LeafPaths":[]}` {
+		t.Errorf("Unexpected response %s", body)
+	}
+	if _, host := NewListenerClient("0.0.0.0:8081", "10.0.0.2", time.Second); host != "10.0.0.2:8081" {
+		t.Errorf("Expected 10.0.0.2:8081, got %s", host)
+	}
+	if _, host := NewListenerClient("[::1]:8081", "10.0.0.2", time.Second); host != "[::1]:8081" {
+		t.Errorf("Expected [::1]:8081, got %s", host)
+	}
+}